@@ -0,0 +1,500 @@
+package main
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	passwordHashIterations = 100000
+	passwordHashLength     = 32
+	minPasswordLength      = 6
+)
+
+// sessionLifetime is how long a session token stays valid after it is issued.
+var sessionLifetime = 30 * 24 * time.Hour
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,20}$`)
+
+var (
+	ErrUsernameTaken      = errors.New("username is already taken")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrInvalidToken       = errors.New("invalid or expired session token")
+	ErrAccountNotFound    = errors.New("account not found")
+)
+
+// Account is a persistent player identity. Seats bind to an account's ID so that
+// results follow the person across games and server restarts.
+type Account struct {
+	ID           string        `json:"id"`
+	Username     string        `json:"username"`
+	PasswordHash string        `json:"passwordHash"` // hex-encoded PBKDF2-SHA256
+	Salt         string        `json:"salt"`         // hex-encoded
+	CreatedAt    time.Time     `json:"createdAt"`
+	LastLoginAt  time.Time     `json:"lastLoginAt"`
+	Rating       float64       `json:"rating"`
+	Matches      []MatchRecord `json:"matches,omitempty"` // Completed matches, oldest first
+	Rounds       []RoundRecord `json:"rounds,omitempty"`  // Completed rounds, oldest first
+}
+
+// MatchRecord is one account's result in a completed match.
+type MatchRecord struct {
+	FinishedAt  time.Time `json:"finishedAt"`
+	Ruleset     string    `json:"ruleset"`
+	Placement   int       `json:"placement"` // 1 = overall winner
+	PlayerCount int       `json:"playerCount"`
+	Score       int       `json:"score"`       // Final match score
+	RoundScores []int     `json:"roundScores"` // This player's score for each round
+	Won         bool      `json:"won"`
+}
+
+// RoundRecord is one account's result in a completed round.
+type RoundRecord struct {
+	FinishedAt  time.Time       `json:"finishedAt"`
+	Ruleset     string          `json:"ruleset"`
+	Won         bool            `json:"won"`
+	Penalty     int             `json:"penalty"`            // Round score from CalculateScores
+	CardsLeft   int             `json:"cardsLeft"`          // Cards still in hand when the round ended
+	NeverPlayed bool            `json:"neverPlayed"`        // Lost without playing from the hand, for the tripled penalty
+	Plays       map[string]int  `json:"plays"`              // Hands played this round, keyed by HandType name
+	Exchange    *ExchangeRecord `json:"exchange,omitempty"` // Cards swapped after the deal, if this account took part
+}
+
+// ExchangeRecord is one account's side of a round's card exchange.
+type ExchangeRecord struct {
+	Role     string `json:"role"` // "loser", who gave their highest cards, or "winner"
+	Gave     Deck   `json:"gave"`
+	Received Deck   `json:"received"`
+}
+
+// exchangeRecord returns the player's side of the exchange, or nil if they took no part.
+func exchangeRecord(ex *CardExchange, playerID string) *ExchangeRecord {
+	switch {
+	case ex == nil:
+		return nil
+	case playerID == ex.LoserID:
+		return &ExchangeRecord{Role: "loser", Gave: ex.GivenCards, Received: ex.ReturnedCards}
+	case playerID == ex.WinnerID:
+		return &ExchangeRecord{Role: "winner", Gave: ex.ReturnedCards, Received: ex.GivenCards}
+	}
+	return nil
+}
+
+// accountStoreData is the on-disk format of the account store.
+type accountStoreData struct {
+	NextID   int                 `json:"nextId"`
+	Accounts map[string]*Account `json:"accounts"` // Keyed by account ID
+	Tokens   map[string]*session `json:"tokens"`   // Keyed by SHA-256 of the session token
+}
+
+// session is an issued session token.
+type session struct {
+	AccountID string    `json:"accountId"`
+	IssuedAt  time.Time `json:"issuedAt"`
+}
+
+// UnmarshalJSON also reads the older format, a bare account ID, as a token issued long ago.
+func (t *session) UnmarshalJSON(data []byte) error {
+	var accountID string
+	if json.Unmarshal(data, &accountID) == nil {
+		*t = session{AccountID: accountID}
+		return nil
+	}
+	type plain session
+	return json.Unmarshal(data, (*plain)(t))
+}
+
+func (t *session) expired(now time.Time) bool {
+	return now.Sub(t.IssuedAt) > sessionLifetime
+}
+
+// AccountStore is a file-backed store of player accounts and session tokens.
+// The whole store is rewritten on each change, which is fine at the scale of a
+// single game server. Game history and ratings are only written by Flush, so that
+// a finished round is saved once rather than once per account. It is safe for
+// concurrent use.
+type AccountStore struct {
+	mu     sync.Mutex
+	path   string
+	data   accountStoreData
+	dirty  bool           // History or ratings changed since the last save
+	writes sync.WaitGroup // Writes started by FlushLater
+}
+
+// OpenAccountStore loads the account store at path, creating an empty one if the file does not exist.
+func OpenAccountStore(path string) (*AccountStore, error) {
+	s := &AccountStore{
+		path: path,
+		data: accountStoreData{Accounts: make(map[string]*Account), Tokens: make(map[string]*session)},
+	}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading account store: %w", err)
+	}
+	if err := json.Unmarshal(raw, &s.data); err != nil {
+		return nil, fmt.Errorf("parsing account store %s: %w", path, err)
+	}
+	if s.data.Accounts == nil {
+		s.data.Accounts = make(map[string]*Account)
+	}
+	if s.data.Tokens == nil {
+		s.data.Tokens = make(map[string]*session)
+	}
+	for _, a := range s.data.Accounts {
+		if a.Rating == 0 {
+			a.Rating = DefaultRating // Accounts created before ratings existed
+		}
+	}
+	return s, nil
+}
+
+// Register creates a new account and returns it with a fresh session token.
+func (s *AccountStore) Register(username, password string) (Account, string, error) {
+	username = strings.TrimSpace(username)
+	if !usernamePattern.MatchString(username) {
+		return Account{}, "", errors.New("username must be 3-20 letters, digits, '_' or '-'")
+	}
+	if len(password) < minPasswordLength {
+		return Account{}, "", fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.findByUsername(username) != nil {
+		return Account{}, "", ErrUsernameTaken
+	}
+
+	salt, err := randomHex(16)
+	if err != nil {
+		return Account{}, "", err
+	}
+	hash, err := hashPassword(password, salt)
+	if err != nil {
+		return Account{}, "", err
+	}
+	s.data.NextID++
+	now := time.Now()
+	account := &Account{
+		ID:           fmt.Sprintf("acct%d", s.data.NextID),
+		Username:     username,
+		PasswordHash: hash,
+		Salt:         salt,
+		CreatedAt:    now,
+		LastLoginAt:  now,
+		Rating:       DefaultRating,
+	}
+	s.data.Accounts[account.ID] = account
+
+	token, err := s.issueToken(account.ID)
+	if err != nil {
+		return Account{}, "", err
+	}
+	if err := s.save(); err != nil {
+		return Account{}, "", err
+	}
+	return *account, token, nil
+}
+
+// Login checks a username and password and returns the account with a fresh session token.
+func (s *AccountStore) Login(username, password string) (Account, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account := s.findByUsername(strings.TrimSpace(username))
+	if account == nil {
+		return Account{}, "", ErrInvalidCredentials
+	}
+	hash, err := hashPassword(password, account.Salt)
+	if err != nil {
+		return Account{}, "", err
+	}
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(account.PasswordHash)) != 1 {
+		return Account{}, "", ErrInvalidCredentials
+	}
+
+	account.LastLoginAt = time.Now()
+	token, err := s.issueToken(account.ID)
+	if err != nil {
+		return Account{}, "", err
+	}
+	if err := s.save(); err != nil {
+		return Account{}, "", err
+	}
+	return *account, token, nil
+}
+
+// AccountForToken returns the account a session token belongs to. An expired token is removed.
+func (s *AccountStore) AccountForToken(token string) (Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := hashToken(token)
+	sess, ok := s.data.Tokens[key]
+	if !ok {
+		return Account{}, ErrInvalidToken
+	}
+	if sess.expired(time.Now()) {
+		delete(s.data.Tokens, key)
+		if err := s.save(); err != nil {
+			return Account{}, err
+		}
+		return Account{}, ErrInvalidToken
+	}
+	account, ok := s.data.Accounts[sess.AccountID]
+	if !ok {
+		return Account{}, ErrInvalidToken
+	}
+	return *account, nil
+}
+
+// Logout revokes a session token.
+func (s *AccountStore) Logout(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := hashToken(token)
+	if _, ok := s.data.Tokens[key]; !ok {
+		return ErrInvalidToken
+	}
+	delete(s.data.Tokens, key)
+	return s.save()
+}
+
+// Account returns the account with the given ID.
+func (s *AccountStore) Account(id string) (Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account, ok := s.data.Accounts[id]
+	if !ok {
+		return Account{}, ErrAccountNotFound
+	}
+	return *account, nil
+}
+
+// RecordMatch appends a completed match result to an account's history. It is saved by the next Flush.
+func (s *AccountStore) RecordMatch(accountID string, record MatchRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account, ok := s.data.Accounts[accountID]
+	if !ok {
+		return ErrAccountNotFound
+	}
+	account.Matches = append(account.Matches, record)
+	s.dirty = true
+	return nil
+}
+
+// AdjustRating adds delta to an account's rating and returns the new rating. It is saved by the next Flush.
+func (s *AccountStore) AdjustRating(accountID string, delta float64) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account, ok := s.data.Accounts[accountID]
+	if !ok {
+		return 0, ErrAccountNotFound
+	}
+	account.Rating += delta
+	s.dirty = true
+	return account.Rating, nil
+}
+
+// RecordRound appends a completed round result to an account's history. It is saved by the next Flush.
+func (s *AccountStore) RecordRound(accountID string, record RoundRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account, ok := s.data.Accounts[accountID]
+	if !ok {
+		return ErrAccountNotFound
+	}
+	account.Rounds = append(account.Rounds, record)
+	s.dirty = true
+	return nil
+}
+
+// Flush writes the history and ratings recorded since the last save to disk.
+func (s *AccountStore) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return nil
+	}
+	return s.save()
+}
+
+// FlushLater runs Flush in the background, so that a caller holding gameInstanceMutex
+// does not keep every table waiting on the disk.
+func (s *AccountStore) FlushLater() {
+	s.writes.Add(1)
+	go func() {
+		defer s.writes.Done()
+		if err := s.Flush(); err != nil {
+			slog.Error("cannot save account store", "err", err)
+		}
+	}()
+}
+
+// Close waits for writes started by FlushLater and writes anything still unsaved.
+func (s *AccountStore) Close() error {
+	s.writes.Wait()
+	return s.Flush()
+}
+
+// Accounts returns a snapshot of every account.
+func (s *AccountStore) Accounts() []Account {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	accounts := make([]Account, 0, len(s.data.Accounts))
+	for _, a := range s.data.Accounts {
+		accounts = append(accounts, *a)
+	}
+	return accounts
+}
+
+// findByUsername looks up an account case-insensitively. Assumes s.mu is held.
+func (s *AccountStore) findByUsername(username string) *Account {
+	for _, a := range s.data.Accounts {
+		if strings.EqualFold(a.Username, username) {
+			return a
+		}
+	}
+	return nil
+}
+
+// issueToken creates a session token for the account. Only its hash is stored. Expired tokens
+// are dropped at the same time, so that the store does not grow with every login.
+// Assumes s.mu is held.
+func (s *AccountStore) issueToken(accountID string) (string, error) {
+	token, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	for key, sess := range s.data.Tokens {
+		if sess.expired(now) {
+			delete(s.data.Tokens, key)
+		}
+	}
+	s.data.Tokens[hashToken(token)] = &session{AccountID: accountID, IssuedAt: now}
+	return token, nil
+}
+
+// save writes the store to disk atomically. Assumes s.mu is held.
+func (s *AccountStore) save() error {
+	raw, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding account store: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("creating account store directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return fmt.Errorf("writing account store: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("replacing account store: %w", err)
+	}
+	s.dirty = false
+	return nil
+}
+
+func hashPassword(password, saltHex string) (string, error) {
+	salt, err := hex.DecodeString(saltHex)
+	if err != nil {
+		return "", fmt.Errorf("decoding password salt: %w", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordHashIterations, passwordHashLength)
+	if err != nil {
+		return "", fmt.Errorf("hashing password: %w", err)
+	}
+	return hex.EncodeToString(key), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating random bytes: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// recordRoundResults stores the finished round in the history of every seat bound to an account.
+// Assumes gameInstanceMutex is held by the caller.
+func recordRoundResults(store *AccountStore, game *GameState, finishedAt time.Time) {
+	if store == nil || !game.IsGameOver || len(game.RoundScoresHistory) == 0 {
+		return
+	}
+	roundScores := game.RoundScoresHistory[len(game.RoundScoresHistory)-1]
+	exchange := game.completedExchange()
+	for _, p := range game.Players {
+		if p.AccountID == "" || p.IsEliminated {
+			continue // Guests and players knocked out of the match
+		}
+		if len(p.Hand) == 0 && p.ID != game.WinnerID && !containsString(game.FinishOrder, p.ID) {
+			continue // Not dealt into this round
+		}
+		plays := make(map[string]int)
+		for handType, count := range game.RoundPlays[p.ID] {
+			plays[handType.String()] = count
+		}
+		record := RoundRecord{
+			FinishedAt:  finishedAt,
+			Ruleset:     game.RuleEngine.Options.Name,
+			Won:         p.ID == game.WinnerID,
+			Penalty:     roundScores[p.ID],
+			CardsLeft:   len(p.Hand),
+			NeverPlayed: !wonRound(game, p.ID) && len(p.Hand) >= game.dealtHandSize(p.ID),
+			Plays:       plays,
+			Exchange:    exchangeRecord(exchange, p.ID),
+		}
+		if err := store.RecordRound(p.AccountID, record); err != nil {
+			playerLog(game, p).Error("cannot record round result", "account", p.AccountID, "err", err)
+		}
+	}
+}
+
+// recordMatchResults stores the finished match in the history of every seat bound to an account.
+// Assumes gameInstanceMutex is held by the caller.
+func recordMatchResults(store *AccountStore, game *GameState, finishedAt time.Time) {
+	if store == nil || !game.IsMatchOver {
+		return
+	}
+	for placement, p := range FinalStandings(game) {
+		if p.AccountID == "" {
+			continue
+		}
+		roundScores := make([]int, len(game.RoundScoresHistory))
+		for i, round := range game.RoundScoresHistory {
+			roundScores[i] = round[p.ID]
+		}
+		record := MatchRecord{
+			FinishedAt:  finishedAt,
+			Ruleset:     game.RuleEngine.Options.Name,
+			Placement:   placement + 1,
+			PlayerCount: len(game.Players),
+			Score:       game.Scores[p.ID],
+			RoundScores: roundScores,
+			Won:         p.ID == game.OverallWinnerID,
+		}
+		if err := store.RecordMatch(p.AccountID, record); err != nil {
+			playerLog(game, p).Error("cannot record match result", "account", p.AccountID, "err", err)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestAccountStore_RegisterLoginAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounts.json")
+	store, err := OpenAccountStore(path)
+	if err != nil {
+		t.Fatalf("OpenAccountStore() error = %v", err)
+	}
+
+	account, token, err := store.Register("alice", "secret1")
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if _, _, err := store.Register("ALICE", "secret2"); !errors.Is(err, ErrUsernameTaken) {
+		t.Errorf("Register() duplicate username error = %v, want ErrUsernameTaken", err)
+	}
+	if _, _, err := store.Register("bo", "secret1"); err == nil {
+		t.Error("Register() with short username error = nil, want error")
+	}
+	if _, _, err := store.Login("alice", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Login() wrong password error = %v, want ErrInvalidCredentials", err)
+	}
+
+	if err := store.RecordMatch(account.ID, MatchRecord{FinishedAt: time.Now(), Placement: 1, Won: true}); err != nil {
+		t.Fatalf("RecordMatch() error = %v", err)
+	}
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	// Everything survives a reload from disk.
+	reloaded, err := OpenAccountStore(path)
+	if err != nil {
+		t.Fatalf("OpenAccountStore() reload error = %v", err)
+	}
+	byToken, err := reloaded.AccountForToken(token)
+	if err != nil || byToken.ID != account.ID {
+		t.Fatalf("AccountForToken() = %v, %v; want account %s", byToken.ID, err, account.ID)
+	}
+	if len(byToken.Matches) != 1 || !byToken.Matches[0].Won {
+		t.Errorf("reloaded account matches = %+v, want one won match", byToken.Matches)
+	}
+	loggedIn, _, err := reloaded.Login("alice", "secret1")
+	if err != nil || loggedIn.ID != account.ID {
+		t.Errorf("Login() after reload = %v, %v; want account %s", loggedIn.ID, err, account.ID)
+	}
+	if _, err := reloaded.AccountForToken("not-a-token"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("AccountForToken() bad token error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestAssignSeat_BindsAndReclaimsAccountSeats(t *testing.T) {
+	game := &GameState{Players: []*Player{NewPlayer(1, "P1"), NewPlayer(2, "P2")}}
+	alice := &Account{ID: "acct1", Username: "alice"}
+
+	seat := assignSeat(game, alice)
+	if seat != game.Players[0] || seat.AccountID != "acct1" || seat.Name != "alice" {
+		t.Fatalf("assignSeat(alice) = %+v, want player1 bound to acct1", seat)
+	}
+
+	// A guest cannot take alice's reserved seat even while she is disconnected.
+	guestSeat := assignSeat(game, nil)
+	if guestSeat != game.Players[1] {
+		t.Errorf("assignSeat(guest) = %v, want player2", guestSeat)
+	}
+
+	// Alice reconnecting gets her own seat back.
+	if again := assignSeat(game, alice); again != game.Players[0] {
+		t.Errorf("assignSeat(alice) on reconnect = %v, want player1", again)
+	}
+}
+
+func TestResetMatchState_ReleasesIdleAccountSeats(t *testing.T) {
+	game := NewGameState("release", []*Player{NewPlayer(1, "alice"), NewPlayer(2, "bob"), NewPlayer(3, "P3")}, 100)
+	game.Players[0].AccountID = "acct1"
+	game.Players[1].AccountID = "acct2"
+	conn := new(websocket.Conn) // Only used as the clients key
+	clientsMu.Lock()
+	clients[conn] = &client{player: game.Players[1], game: game}
+	clientsMu.Unlock()
+	t.Cleanup(func() {
+		clientsMu.Lock()
+		delete(clients, conn)
+		clientsMu.Unlock()
+	})
+
+	gameInstanceMutex.Lock()
+	resetMatchState(game)
+	gameInstanceMutex.Unlock()
+
+	if p := game.Players[0]; p.AccountID != "" || p.Name != "P1" {
+		t.Errorf("disconnected seat = %q bound to %q, want P1 released", p.Name, p.AccountID)
+	}
+	if p := game.Players[1]; p.AccountID != "acct2" {
+		t.Errorf("connected seat bound to %q, want it kept for acct2", p.AccountID)
+	}
+	if seat := assignSeat(game, &Account{ID: "acct3", Username: "carol"}); seat != game.Players[0] {
+		t.Errorf("assignSeat(carol) = %v, want the released player1", seat)
+	}
+
+	// Tournament seats stay reserved for their entrants.
+	game.Players[2].AccountID = "acct4"
+	game.TournamentID = "cup"
+	resetMatchState(game)
+	if game.Players[2].AccountID != "acct4" {
+		t.Error("tournament seat was released")
+	}
+}
+
+func TestAuthenticateHandshake(t *testing.T) {
+	store, err := OpenAccountStore(filepath.Join(t.TempDir(), "accounts.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, token, err := store.Register("alice", "secret1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		bearer      string
+		wantAccount string
+		wantErr     bool
+	}{
+		{"guest", "", "", "", false},
+		{"token in query", "?token=" + token, "", account.ID, false},
+		{"bearer header", "", token, account.ID, false},
+		{"bad token", "?token=nope", "", "", true},
+		{"password in URL", "?username=alice&password=secret1", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/ws"+tt.query, nil)
+			if tt.bearer != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			got, err := authenticateHandshake(store, r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			gotID := ""
+			if got != nil {
+				gotID = got.ID
+			}
+			if gotID != tt.wantAccount {
+				t.Errorf("account %q, want %q", gotID, tt.wantAccount)
+			}
+		})
+	}
+}
+
+func TestAccountStore_TokenExpiryAndLogout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounts.json")
+	store, err := OpenAccountStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, stale, err := store.Register("alice", "secret1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, other, _ := store.Login("alice", "secret1")
+	store.data.Tokens[hashToken(stale)].IssuedAt = time.Now().Add(-sessionLifetime - time.Minute)
+	store.data.Tokens[hashToken(other)].IssuedAt = time.Now().Add(-sessionLifetime - time.Minute)
+
+	if _, err := store.AccountForToken(stale); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("AccountForToken(expired) error = %v, want ErrInvalidToken", err)
+	}
+	if _, ok := store.data.Tokens[hashToken(stale)]; ok {
+		t.Error("expired token was not removed")
+	}
+	_, fresh, err := store.Login("alice", "secret1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(store.data.Tokens) != 1 {
+		t.Errorf("%d tokens stored after login, want only the fresh one", len(store.data.Tokens))
+	}
+
+	if err := store.Logout(fresh); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+	if _, err := store.AccountForToken(fresh); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("AccountForToken(after logout) error = %v, want ErrInvalidToken", err)
+	}
+	if err := store.Logout(fresh); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Logout() twice error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestOpenAccountStore_ReadsOldTokenFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounts.json")
+	old := `{"nextId": 1, "accounts": {"acct1": {"id": "acct1", "username": "alice"}}, "tokens": {"` + hashToken("tok") + `": "acct1"}}`
+	if err := os.WriteFile(path, []byte(old), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := OpenAccountStore(path)
+	if err != nil {
+		t.Fatalf("OpenAccountStore() error = %v", err)
+	}
+	if sess := store.data.Tokens[hashToken("tok")]; sess == nil || sess.AccountID != "acct1" {
+		t.Fatalf("old token read as %+v, want acct1", sess)
+	}
+	if _, err := store.AccountForToken("tok"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("old token error = %v, want ErrInvalidToken since its age is unknown", err)
+	}
+}
+
+func TestAccountStore_HistoryWrittenOnFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounts.json")
+	store, err := OpenAccountStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, _, err := store.Register("alice", "secret1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundsOnDisk := func() int {
+		t.Helper()
+		reloaded, err := OpenAccountStore(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, _ := reloaded.Account(account.ID)
+		return len(a.Rounds)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := store.RecordRound(account.ID, RoundRecord{FinishedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := roundsOnDisk(); n != 0 {
+		t.Errorf("%d rounds on disk before Flush, want 0", n)
+	}
+	store.FlushLater()
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if n := roundsOnDisk(); n != 3 {
+		t.Errorf("%d rounds on disk after Flush, want 3", n)
+	}
+}
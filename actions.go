@@ -3,7 +3,7 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
 
 	"github.com/gorilla/websocket"
@@ -18,196 +18,233 @@ import (
 // ActionContext holds dependencies for action handlers
 // This helps in reducing the number of arguments passed to handler functions.
 type ActionContext struct {
-	Game         *GameState
-	Clients      map[*websocket.Conn]*client // For direct client interactions if needed beyond broadcast
-	ClientsMu    *sync.Mutex                 // To protect Clients map if directly accessed
-	AssignedConn *websocket.Conn             // The connection of the player making the action
+	Game           *GameState
+	Clients        map[*websocket.Conn]*client // For direct client interactions if needed beyond broadcast
+	ClientsMu      *sync.Mutex                 // To protect Clients map if directly accessed
+	AssignedClient *client                     // The client of the player making the action; replies go through its write
+	Log            *slog.Logger                // Tagged with the table, round, player and message type
+}
+
+// sendRuleError writes a structured rule violation to the acting client.
+// Non-rule errors are sent with an empty code.
+func sendRuleError(c *client, err error) {
+	payload := map[string]string{"type": "error", "content": err.Error()}
+	reason := "rule" // Counted by code where there is one
+	if ruleErr, ok := err.(*RuleError); ok {
+		payload["code"] = ruleErr.Code
+		reason = ruleErr.Code
+	}
+	metrics.rejected.Inc(reason)
+	jsonMsg, _ := json.Marshal(payload)
+	c.write(websocket.TextMessage, jsonMsg)
+}
+
+// rejectAction tells the acting client why their message was refused and counts the refusal
+// by reason, a short fixed identifier such as "not_your_turn".
+func rejectAction(c *client, reason, content string) {
+	metrics.rejected.Inc(reason)
+	jsonMsg, _ := json.Marshal(map[string]string{"type": "error", "content": content})
+	c.write(websocket.TextMessage, jsonMsg)
 }
 
 // processPlayCardsAction handles the logic for a "playCards" message.
 // Assumes gameInstanceMutex is held by the caller (handleWebSocket).
 func processPlayCardsAction(ctx *ActionContext, assignedPlayer *Player, currentPlayerInGame *Player, receivedMsg map[string]interface{}) (shouldContinue bool, broadcastStateNeeded bool) {
 	if ctx.Game.IsGameOver {
-		ctx.AssignedConn.WriteMessage(websocket.TextMessage, []byte(`{"type": "error", "content": "Game is over."}`))
+		rejectAction(ctx.AssignedClient, "game_over", "Game is over.")
 		return true, false // continue listening for messages, no broadcast needed
 	}
 
+	if ctx.Game.exchangeInProgress() {
+		rejectAction(ctx.AssignedClient, "exchange_pending", "Play starts once the card exchange is complete.")
+		return true, false
+	}
+
 	if assignedPlayer != currentPlayerInGame {
-		errMsg := fmt.Sprintf(`{"type": "error", "content": "It's not your turn. Currently Player %s's turn."}`,
-			currentPlayerInGame.Name)
-		ctx.AssignedConn.WriteMessage(websocket.TextMessage, []byte(errMsg))
+		rejectAction(ctx.AssignedClient, "not_your_turn", fmt.Sprintf("It's not your turn. Currently Player %s's turn.",
+			currentPlayerInGame.Name))
 		return true, false // continue, no broadcast
 	}
 
 	playedCardsData, dataOk := receivedMsg["cards"]
 	if !dataOk {
-		ctx.AssignedConn.WriteMessage(websocket.TextMessage, []byte(`{"type": "error", "content": "Play message missing card data."}`))
+		rejectAction(ctx.AssignedClient, "missing_cards", "Play message missing card data.")
 		return true, false
 	}
 
 	parsedDeck, parseErr := parseCardsFromClientData(playedCardsData) // parseCardsFromClientData remains a global helper in main.go
 	if parseErr != nil {
-		errMsg := fmt.Sprintf(`{"type": "error", "content": "Invalid card data: %s"}`, parseErr.Error())
-		ctx.AssignedConn.WriteMessage(websocket.TextMessage, []byte(errMsg))
+		rejectAction(ctx.AssignedClient, "invalid_card_data", fmt.Sprintf("Invalid card data: %s", parseErr.Error()))
 		return true, false
 	}
 
-	canPlayCards := true
-	tempHandCheck := make(map[Card]int)
-	for _, c := range assignedPlayer.Hand {
-		tempHandCheck[c]++
-	}
-	for _, c := range parsedDeck {
-		if tempHandCheck[c] > 0 {
-			tempHandCheck[c]--
-		} else {
-			canPlayCards = false
-			break
-		}
+	play, err := validatePlay(ctx.Game, assignedPlayer, parsedDeck)
+	if err != nil {
+		sendRuleError(ctx.AssignedClient, err)
+		return true, false
 	}
-	if !canPlayCards {
-		ctx.AssignedConn.WriteMessage(websocket.TextMessage, []byte(`{"type": "error", "content": "Invalid play: You do not possess all the cards you are trying to play."}`))
+	if err := playCards(ctx.Game, assignedPlayer, play); err != nil {
+		sendRuleError(ctx.AssignedClient, err)
 		return true, false
 	}
+	runQueuedActions(ctx.Game)
+	return false, true // Broadcast state after any valid play (either turn advance or game/match end)
+}
+
+// validatedPlay is a play accepted by validatePlay, ready for playCards.
+type validatedPlay struct {
+	hand           *PlayedHand
+	cards          Deck
+	singleCardLeft error // Set in penalty mode when the play breaks the single-card-left rule
+}
+
+// validatePlay checks that player may play cards on the current trick and classifies them.
+// Turn order is checked by the caller. Assumes gameInstanceMutex is held.
+func validatePlay(game *GameState, player *Player, cards Deck) (validatedPlay, error) {
+	if !player.HasCards(cards) {
+		return validatedPlay{}, fmt.Errorf("Invalid play: You do not possess all the cards you are trying to play.")
+	}
 
-	determinedHand, errDet := ctx.Game.RuleEngine.DeterminePlayedHand(parsedDeck)
+	determinedHand, errDet := game.RuleEngine.DeterminePlayedHand(cards)
 	if errDet != nil {
-		ctx.AssignedConn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"type": "error", "content": "Invalid hand: %s"}`, errDet.Error())))
-		return true, false
+		return validatedPlay{}, fmt.Errorf("Invalid hand: %s", errDet.Error())
 	}
-	determinedHand.PlayerID = assignedPlayer.ID
+	determinedHand.PlayerID = player.ID
 	determinedHand.HandTypeString = determinedHand.HandType.String()
 
-	if !ctx.Game.RuleEngine.BeatsLastHand(determinedHand, ctx.Game.LastPlayedHand) {
-		ctx.AssignedConn.WriteMessage(websocket.TextMessage, []byte(`{"type": "error", "content": "Your hand does not beat the hand on the table."}`))
-		return true, false
+	if !game.RuleEngine.BeatsLastHand(determinedHand, game.LastPlayedHand) {
+		return validatedPlay{}, fmt.Errorf("Your hand does not beat the hand on the table.")
 	}
-
-	if !assignedPlayer.RemoveCards(parsedDeck) {
-		log.Printf("CRITICAL: Failed to remove cards %s from player %s hand %s after validation.", parsedDeck.String(), assignedPlayer.ID, assignedPlayer.Hand.String())
-		ctx.AssignedConn.WriteMessage(websocket.TextMessage, []byte(`{"type": "error", "content": "Server error: could not remove cards from hand. Play aborted."}`))
-		return true, false
+	if err := game.RuleEngine.ValidateOpeningPlay(determinedHand, game.OpeningCard); err != nil {
+		return validatedPlay{}, err
 	}
+	play := validatedPlay{hand: determinedHand, cards: cards}
+	nextPlayer := game.Players[game.nextPlayerInRound(game.CurrentTurnPlayerIndex)]
+	if err := game.RuleEngine.CheckSingleCardLeft(determinedHand, player.Hand, game.LastPlayedHand, len(nextPlayer.Hand)); err != nil {
+		if game.RuleEngine.Options.SingleCardLeftRule == SingleCardLeftReject {
+			return validatedPlay{}, err
+		}
+		play.singleCardLeft = err
+	}
+	return play, nil
+}
 
-	ctx.Game.LastPlayedHand = determinedHand
-	ctx.Game.PassCount = 0
-	assignedPlayer.HasPassed = false
-	log.Printf("Player %s (%s) played: %s. Cards remaining: %d", assignedPlayer.ID, assignedPlayer.Name, determinedHand.Cards, len(assignedPlayer.Hand))
+// playCards makes a play accepted by validatePlay: it takes the cards from the player's hand,
+// puts the hand on the table and either ends the round or passes the turn on.
+// Assumes gameInstanceMutex is held.
+func playCards(game *GameState, player *Player, play validatedPlay) error {
+	snapshot := newTurnSnapshot(game, player) // Recorded once the play goes through
+	nextPlayer := game.Players[game.nextPlayerInRound(game.CurrentTurnPlayerIndex)]
+	determinedHand := play.hand
+	if !player.RemoveCards(play.cards) {
+		playerLog(game, player).Error("cannot remove validated cards from hand", "cards", play.cards.String(), "hand", player.Hand.String())
+		return fmt.Errorf("Server error: could not remove cards from hand. Play aborted.")
+	}
+	recordAction(game, snapshot)
 
-	if len(assignedPlayer.Hand) == 0 {
-		// Player has won the round
-		ctx.Game.IsGameOver = true
-		ctx.Game.WinnerID = assignedPlayer.ID
-		log.Printf("Player %s (%s) has won Round %d!", assignedPlayer.Name, assignedPlayer.ID, ctx.Game.RoundNumber)
+	if play.singleCardLeft != nil {
+		penalty := game.RuleEngine.Options.SingleCardLeftPenalty
+		if game.Penalties == nil {
+			game.Penalties = make(map[string]int)
+		}
+		game.Penalties[player.ID] += penalty
+		playerLog(game, player).Info("single card left penalty", "points", penalty, "reason", play.singleCardLeft)
+		broadcastSystemMessage(game, fmt.Sprintf("%s did not play their highest single while %s had one card left: +%d penalty points.", player.Name, nextPlayer.Name, penalty))
+	}
 
-		// Calculate scores for the round
-		roundScores := CalculateScores(ctx.Game)
-		log.Printf("Round %d scores calculated: %v", ctx.Game.RoundNumber, roundScores)
+	game.LastPlayedHand = determinedHand
+	if game.RoundPlays == nil {
+		game.RoundPlays = make(map[string]map[HandType]int)
+	}
+	if game.RoundPlays[player.ID] == nil {
+		game.RoundPlays[player.ID] = make(map[HandType]int)
+	}
+	game.RoundPlays[player.ID][determinedHand.HandType]++
+	game.OpeningCard = nil // The opening play has been made
+	game.PassCount = 0
+	player.HasPassed = false
+	playerLog(game, player).Info("played", "cards", Deck(determinedHand.Cards).String(), "handType", determinedHand.HandType.String(), "cardsLeft", len(player.Hand))
 
-		// Append round scores to history
-		if ctx.Game.RoundScoresHistory == nil {
-			ctx.Game.RoundScoresHistory = make([]map[string]int, 0)
-		}
-		ctx.Game.RoundScoresHistory = append(ctx.Game.RoundScoresHistory, roundScores)
+	if len(player.Hand) == 1 {
+		broadcastSystemMessage(game, fmt.Sprintf("Last card! %s has one card left.", player.Name))
+	}
 
-		// Update overall scores and check for match end
-		matchShouldEnd := false
-		for _, p := range ctx.Game.Players {
-			if roundScore, ok := roundScores[p.ID]; ok {
-				ctx.Game.Scores[p.ID] += roundScore // Add round score to overall score
-			}
-			// Check if any player (not just the winner of the round) has reached/exceeded target score
-			if ctx.Game.Scores[p.ID] >= ctx.Game.TargetScore {
-				matchShouldEnd = true
-			}
+	roundOver := false
+	var roundWinner *Player
+	if len(player.Hand) == 0 {
+		roundWinner, roundOver = playerGoesOut(game, player)
+		if !roundOver {
+			broadcastSystemMessage(game, fmt.Sprintf("%s is out! Their partner plays on.", player.Name))
 		}
+	}
 
-		if matchShouldEnd {
-			ctx.Game.IsMatchOver = true
-			log.Printf("Match ends after Round %d! A player reached or exceeded target score of %d.", ctx.Game.RoundNumber, ctx.Game.TargetScore)
-			// Determine overall winner (lowest score)
-			lowestScore := -1
-			var overallWinner *Player = nil
-			for _, p := range ctx.Game.Players {
-				if overallWinner == nil || ctx.Game.Scores[p.ID] < lowestScore {
-					lowestScore = ctx.Game.Scores[p.ID]
-					overallWinner = p
-				}
-			}
-			if overallWinner != nil {
-				ctx.Game.OverallWinnerID = overallWinner.ID
-				log.Printf("Overall Winner of the Match: %s (%s) with %d points!", overallWinner.Name, overallWinner.ID, lowestScore)
-			} else {
-				log.Println("ERROR: Could not determine overall winner despite match ending.")
-			}
-		} else {
-			log.Printf("Round %d ended. Match continues. Current overall scores: %v", ctx.Game.RoundNumber, ctx.Game.Scores)
-		}
+	if roundOver {
+		// Player (or in team mode, the first of their team to go out) has won the round
+		completeRound(game, roundWinner)
 		// No turn advancement here, the round/match is over.
-	} else {
-		// Round is not over, advance turn
-		ctx.Game.CurrentTurnPlayerIndex = (ctx.Game.CurrentTurnPlayerIndex + 1) % len(ctx.Game.Players)
-		// The next player might have passed in a previous round of betting on the same trick,
-		// but for a new trick or continued play, their HasPassed should be reset when it becomes their turn to act.
-		// However, HasPassed is more about the *current trick sequence*.
-		// For simplicity, if we just advance turn, their HasPassed status from a previous trick might persist.
-		// It's generally reset when a trick is won (PassCount clears, all HasPassed reset), or for the new turn player.
-		// Let's ensure the next player to play is marked as not having passed FOR THIS TURN.
-		if ctx.Game.CurrentTurnPlayerIndex < len(ctx.Game.Players) && ctx.Game.CurrentTurnPlayerIndex >= 0 {
-			ctx.Game.Players[ctx.Game.CurrentTurnPlayerIndex].HasPassed = false
-		} else {
-			log.Printf("ERROR: CurrentTurnPlayerIndex out of bounds: %d", ctx.Game.CurrentTurnPlayerIndex)
-		}
-		log.Printf("Turn advances to Player %s (%s)", ctx.Game.Players[ctx.Game.CurrentTurnPlayerIndex].Name, ctx.Game.Players[ctx.Game.CurrentTurnPlayerIndex].ID)
+		return nil
 	}
-
-	// Debug log for game state after play
-	/* gameInstanceJSON, err := json.MarshalIndent(ctx.Game, "", "  ")
-	if err != nil {
-		log.Printf("DEBUG: Error marshalling gameInstance for logging in processPlayCardsAction: %v", err)
+	// Round is not over, advance turn. HasPassed is about the current trick sequence;
+	// the next player to act is marked as not having passed for this turn.
+	game.CurrentTurnPlayerIndex = game.nextPlayerInRound(game.CurrentTurnPlayerIndex)
+	if game.CurrentTurnPlayerIndex < len(game.Players) && game.CurrentTurnPlayerIndex >= 0 {
+		game.Players[game.CurrentTurnPlayerIndex].HasPassed = false
 	} else {
-		log.Printf("DEBUG: gameInstance in processPlayCardsAction (JSON):\n%s", string(gameInstanceJSON))
-	} */
-
-	// The original unconditional block that set IsGameOver etc. is now inside the if len(assignedPlayer.Hand) == 0 block.
-
-	return false, true // Broadcast state after any valid play (either turn advance or game/match end)
+		gameLog(game).Error("turn index out of bounds", "index", game.CurrentTurnPlayerIndex)
+	}
+	gameLog(game).Debug("turn advances", "next", game.Players[game.CurrentTurnPlayerIndex].ID)
+	return nil
 }
 
 // processPassTurnAction handles the logic for a "passTurn" message.
 // Assumes gameInstanceMutex is held by the caller.
 func processPassTurnAction(ctx *ActionContext, assignedPlayer *Player, currentPlayerInGame *Player, _ map[string]interface{}) (shouldContinue bool, broadcastStateNeeded bool) {
 	if ctx.Game.IsGameOver {
-		ctx.AssignedConn.WriteMessage(websocket.TextMessage, []byte(`{"type": "error", "content": "Game is over."}`))
+		rejectAction(ctx.AssignedClient, "game_over", "Game is over.")
 		return true, false // continue listening, no broadcast
 	}
 
 	if assignedPlayer != currentPlayerInGame {
-		errMsg := fmt.Sprintf(`{"type": "error", "content": "It's not your turn to pass. Currently Player %s's turn."}`,
-			currentPlayerInGame.Name)
-		ctx.AssignedConn.WriteMessage(websocket.TextMessage, []byte(errMsg))
+		rejectAction(ctx.AssignedClient, "not_your_turn", fmt.Sprintf("It's not your turn to pass. Currently Player %s's turn.",
+			currentPlayerInGame.Name))
 		return true, false
 	}
-	if ctx.Game.LastPlayedHand == nil && ctx.Game.PassCount == 0 {
-		ctx.AssignedConn.WriteMessage(websocket.TextMessage, []byte(`{"type": "error", "content": "You cannot pass when you are leading a new trick."}`))
+	if err := validatePass(ctx.Game); err != nil {
+		sendRuleError(ctx.AssignedClient, err)
 		return true, false
 	}
+	passTurn(ctx.Game, assignedPlayer)
+	runQueuedActions(ctx.Game)
+	return false, true // Do not continue loop, broadcast needed
+}
+
+// validatePass checks that the player whose turn it is may pass. Assumes gameInstanceMutex is held.
+func validatePass(game *GameState) error {
+	if game.LastPlayedHand == nil && game.PassCount == 0 {
+		return fmt.Errorf("You cannot pass when you are leading a new trick.")
+	}
+	return nil
+}
 
-	assignedPlayer.HasPassed = true
-	ctx.Game.PassCount++
-	log.Printf("Player %s (%s) passed. PassCount: %d", assignedPlayer.ID, assignedPlayer.Name, ctx.Game.PassCount)
+// passTurn records a pass accepted by validatePass. When everyone else has passed, the
+// trick is won and the next player leads a new one. Assumes gameInstanceMutex is held.
+func passTurn(game *GameState, player *Player) {
+	recordAction(game, newTurnSnapshot(game, player))
+	player.HasPassed = true
+	game.PassCount++
+	playerLog(game, player).Info("passed", "passCount", game.PassCount)
 
-	if ctx.Game.PassCount >= (len(ctx.Game.Players) - 1) {
-		log.Printf("All other players passed. Player %s wins the trick and starts new.", ctx.Game.Players[ctx.Game.CurrentTurnPlayerIndex].Name)
-		ctx.Game.LastPlayedHand = nil
-		ctx.Game.PassCount = 0
-		for _, p := range ctx.Game.Players {
+	if game.PassCount >= game.passesToWinTrick() {
+		gameLog(game).Debug("trick won", "leader", game.Players[game.CurrentTurnPlayerIndex].ID)
+		game.LastPlayedHand = nil
+		game.PassCount = 0
+		for _, p := range game.Players {
 			p.HasPassed = false
 		}
+		clearAutoPasses(game)
 	}
-	ctx.Game.CurrentTurnPlayerIndex = (ctx.Game.CurrentTurnPlayerIndex + 1) % len(ctx.Game.Players)
-	ctx.Game.Players[ctx.Game.CurrentTurnPlayerIndex].HasPassed = false
-	return false, true // Do not continue loop, broadcast needed
+	game.CurrentTurnPlayerIndex = game.nextPlayerInRound(game.CurrentTurnPlayerIndex)
+	game.Players[game.CurrentTurnPlayerIndex].HasPassed = false
 }
 
 // processChatAction handles the logic for a "chat" message.
@@ -220,31 +257,225 @@ func processChatAction(ctx *ActionContext, assignedPlayer *Player, receivedMsg m
 		broadcastMsgPayload := map[string]string{
 			"type": "chat", "sender": fmt.Sprintf("%s (%s)", assignedPlayer.Name, assignedPlayer.ID), "content": content,
 		}
+		if channel, _ := receivedMsg["channel"].(string); channel == "team" {
+			if ctx.Game.teamOf(assignedPlayer.ID) == nil {
+				rejectAction(ctx.AssignedClient, "team_chat_unavailable", "Team chat is only available in team mode.")
+				return
+			}
+			broadcastMsgPayload["channel"] = "team"
+			jsonTeamMsg, _ := json.Marshal(broadcastMsgPayload)
+			sendToTeam(ctx.Game, assignedPlayer.ID, jsonTeamMsg)
+			return
+		}
 		jsonBroadcast, _ := json.Marshal(broadcastMsgPayload)
-		// broadcastMessage function needs to be updated to accept clients map and mutex, or use a method on ActionContext
-		// For now, assuming a global broadcastMessage or one that can be called this way:
-		broadcastMessage(websocket.TextMessage, jsonBroadcast, ctx.AssignedConn) // broadcastMessage will use global clients and clientsMu
+		// Chat stays within the sender's table; broadcastTableMessage uses the global clients and clientsMu
+		broadcastTableMessage(ctx.Game, websocket.TextMessage, jsonBroadcast)
+	}
+}
+
+// processExchangeCardsAction handles the logic for an "exchangeCards" message during the
+// card exchange phase. Assumes gameInstanceMutex is held by the caller.
+func processExchangeCardsAction(ctx *ActionContext, assignedPlayer *Player, receivedMsg map[string]interface{}) (shouldContinue bool, broadcastStateNeeded bool) {
+	cardsData, dataOk := receivedMsg["cards"]
+	if !dataOk {
+		rejectAction(ctx.AssignedClient, "missing_cards", "Exchange message missing card data.")
+		return true, false
+	}
+	cards, parseErr := parseCardsFromClientData(cardsData)
+	if parseErr != nil {
+		rejectAction(ctx.AssignedClient, "invalid_card_data", fmt.Sprintf("Invalid card data: %s", parseErr.Error()))
+		return true, false
+	}
+
+	if err := ApplyExchangeCards(ctx.Game, assignedPlayer, cards); err != nil {
+		rejectAction(ctx.AssignedClient, "invalid_exchange", "Invalid exchange: "+err.Error())
+		return true, false
+	}
+
+	ctx.Log.Info("exchanged cards", "cards", cards.String(), "phase", ctx.Game.Exchange.Phase)
+	announceExchange(ctx.Game)
+	runQueuedActions(ctx.Game)
+	return false, true
+}
+
+// announceExchange prompts the player the card exchange is waiting on, or announces that
+// play can begin once it is complete.
+func announceExchange(game *GameState) {
+	ex := game.Exchange
+	if ex == nil {
+		return
+	}
+	loser, winner := game.playerByID(ex.LoserID), game.playerByID(ex.WinnerID)
+	switch ex.Phase {
+	case ExchangeGive:
+		broadcastSystemMessage(game, fmt.Sprintf("Card exchange: %s must give their %d highest card(s) to %s.", loser.Name, ex.Count, winner.Name))
+	case ExchangeReturn:
+		broadcastSystemMessage(game, fmt.Sprintf("Card exchange: %s must return %d card(s) of their choice to %s.", winner.Name, ex.Count, loser.Name))
+	case ExchangeComplete:
+		broadcastSystemMessage(game, fmt.Sprintf("Card exchange complete. %s leads.", game.Players[game.CurrentTurnPlayerIndex].Name))
+	}
+}
+
+// processQueueAction handles a "queueAction" message, which leaves an action for the
+// player's coming turns (or clears it, with kind "none"). Assumes gameInstanceMutex is held by the caller.
+func processQueueAction(ctx *ActionContext, assignedPlayer *Player, receivedMsg map[string]interface{}) (shouldContinue bool, broadcastStateNeeded bool) {
+	kind, _ := receivedMsg["kind"].(string)
+	if kind == "none" {
+		delete(ctx.Game.QueuedActions, assignedPlayer.ID)
+		return false, true
+	}
+	action := QueuedAction{Kind: QueuedActionKind(kind)}
+	if cardsData, ok := receivedMsg["cards"]; ok {
+		cards, err := parseCardsFromClientData(cardsData)
+		if err != nil {
+			rejectAction(ctx.AssignedClient, "invalid_card_data", fmt.Sprintf("Invalid card data: %s", err.Error()))
+			return true, false
+		}
+		action.Cards = cards
+	}
+	if err := QueueAction(ctx.Game, assignedPlayer, action); err != nil {
+		rejectAction(ctx.AssignedClient, "invalid_queued_action", "Cannot queue action: "+err.Error())
+		return true, false
+	}
+	return false, true
+}
+
+// processRequestUndoAction handles a "requestUndo" message: the player asks to take back
+// their last play or pass. Assumes gameInstanceMutex is held by the caller.
+func processRequestUndoAction(ctx *ActionContext, assignedPlayer *Player) (shouldContinue bool, broadcastStateNeeded bool) {
+	req, err := RequestUndo(ctx.Game, assignedPlayer)
+	if err != nil {
+		rejectAction(ctx.AssignedClient, "undo_refused", "Cannot undo: "+err.Error())
+		return true, false
+	}
+	expireUndoRequest(ctx.Game, req)
+	ctx.Log.Info("requested undo")
+	broadcastSystemMessage(ctx.Game, fmt.Sprintf("%s asks to take back their last move. Everyone else has %d seconds to accept.", assignedPlayer.Name, int(undoRequestTimeout.Seconds())))
+	return false, true
+}
+
+// processRespondUndoAction handles a "respondUndo" message accepting or refusing the
+// pending undo request. Assumes gameInstanceMutex is held by the caller.
+func processRespondUndoAction(ctx *ActionContext, assignedPlayer *Player, receivedMsg map[string]interface{}) (shouldContinue bool, broadcastStateNeeded bool) {
+	accept, _ := receivedMsg["accept"].(bool)
+	var requester *Player
+	if ctx.Game.UndoRequest != nil {
+		requester = ctx.Game.playerByID(ctx.Game.UndoRequest.PlayerID)
+	}
+	resolved, err := RespondUndo(ctx.Game, assignedPlayer, accept)
+	if err != nil {
+		rejectAction(ctx.AssignedClient, "undo_refused", "Cannot answer undo: "+err.Error())
+		return true, false
+	}
+	switch {
+	case !accept:
+		broadcastSystemMessage(ctx.Game, fmt.Sprintf("%s declined %s's undo request.", assignedPlayer.Name, requester.Name))
+	case resolved:
+		broadcastSystemMessage(ctx.Game, fmt.Sprintf("%s's last move was taken back.", requester.Name))
+	}
+	return false, true
+}
+
+// processPauseAction handles "pause" and "resume" messages from a player.
+// Assumes gameInstanceMutex is held by the caller.
+func processPauseAction(ctx *ActionContext, assignedPlayer *Player, pause bool) (shouldContinue bool, broadcastStateNeeded bool) {
+	verb, err := "pause", error(nil)
+	if pause {
+		err = PauseTable(ctx.Game, assignedPlayer.ID)
+	} else {
+		verb, err = "resume", ResumeTable(ctx.Game, assignedPlayer.ID)
+	}
+	if err != nil {
+		rejectAction(ctx.AssignedClient, "pause_refused", "Cannot "+verb+": "+err.Error())
+		return true, false
+	}
+	broadcastSystemMessage(ctx.Game, fmt.Sprintf("%s %sd the game.", assignedPlayer.Name, verb))
+	return false, true
+}
+
+// processCallVoteAction handles a "callVote" message opening a vote to restart or abandon
+// the match. Assumes gameInstanceMutex is held by the caller.
+func processCallVoteAction(ctx *ActionContext, assignedPlayer *Player, receivedMsg map[string]interface{}) (shouldContinue bool, broadcastStateNeeded bool) {
+	kind, _ := receivedMsg["kind"].(string)
+	vote, resolved, passed, err := CallVote(ctx.Game, assignedPlayer, VoteKind(kind))
+	if err != nil {
+		rejectAction(ctx.AssignedClient, "vote_refused", "Cannot call a vote: "+err.Error())
+		return true, false
+	}
+	ctx.Log.Info("called vote", "kind", vote.Kind)
+	if resolved {
+		announceVoteResult(ctx.Game, vote, passed)
+		return false, true
+	}
+	expireVote(ctx.Game, vote)
+	broadcastSystemMessage(ctx.Game, fmt.Sprintf("%s calls a vote to %s the match. %d of %d players must vote yes within %d seconds.",
+		assignedPlayer.Name, vote.Kind, vote.Needed, len(ctx.Game.Players), int(voteTimeout.Seconds())))
+	return false, true
+}
+
+// processCastVoteAction handles a "castVote" message with a yes or no ballot in the open
+// vote. Assumes gameInstanceMutex is held by the caller.
+func processCastVoteAction(ctx *ActionContext, assignedPlayer *Player, receivedMsg map[string]interface{}) (shouldContinue bool, broadcastStateNeeded bool) {
+	yes, _ := receivedMsg["yes"].(bool)
+	vote := ctx.Game.Vote
+	resolved, passed, err := CastVote(ctx.Game, assignedPlayer, yes)
+	if err != nil {
+		rejectAction(ctx.AssignedClient, "vote_refused", "Cannot vote: "+err.Error())
+		return true, false
+	}
+	if resolved {
+		announceVoteResult(ctx.Game, vote, passed)
+	}
+	return false, true
+}
+
+// announceVoteResult tells the table how a decided vote went.
+func announceVoteResult(game *GameState, vote *MatchVote, passed bool) {
+	switch {
+	case !passed:
+		broadcastSystemMessage(game, fmt.Sprintf("The vote to %s the match failed (%d yes, %d no).", vote.Kind, vote.Yes, vote.No))
+	case vote.Kind == VoteRestart:
+		broadcastSystemMessage(game, fmt.Sprintf("The table voted to restart (%d yes, %d no). A new match begins.", vote.Yes, vote.No))
+		announceRoundStart(game)
+	default:
+		broadcastSystemMessage(game, fmt.Sprintf("The table voted to abandon the match (%d yes, %d no). No result is recorded.", vote.Yes, vote.No))
+	}
+}
+
+// announceRoundStart tells the table about anything that happened on the deal of a new round.
+func announceRoundStart(game *GameState) {
+	if game.InstantWin != nil {
+		broadcastSystemMessage(game, instantWinMessage(game))
+	} else if game.exchangeInProgress() {
+		announceExchange(game)
 	}
 }
 
 // processNewGameAction handles the logic for a "newGame" message.
 // Assumes gameInstanceMutex is held by the caller.
 func processNewGameAction(ctx *ActionContext) (shouldContinue bool, broadcastStateNeeded bool) {
+	if ctx.Game.TournamentID != "" && (ctx.Game.IsMatchOver || !ctx.Game.IsGameOver) {
+		// Tournament matches are started and ended by the organizer, never restarted by players.
+		rejectAction(ctx.AssignedClient, "tournament_table", "Tournament matches cannot be restarted. The organizer seats the next stage.")
+		return true, false
+	}
+
 	if ctx.Game.IsMatchOver {
-		log.Printf("Processing 'newGame' action: Starting a New Match because current match is over.")
+		ctx.Log.Info("starting new match")
 		resetMatchState(ctx.Game) // Resets everything for a new match (defined in main.go)
 	} else if ctx.Game.IsGameOver { // Current round is over, but match continues
-		log.Printf("Processing 'newGame' action: Starting Next Round (Round %d).", ctx.Game.RoundNumber+1)
+		ctx.Log.Info("starting next round", "next", ctx.Game.RoundNumber+1)
 		ctx.Game.RoundNumber++
+		if ctx.Game.RotateSeats {
+			rotateSeats(ctx.Game)
+		}
 		resetRoundState(ctx.Game) // Resets only for the next round (defined in main.go)
 	} else {
-		// "New Game" clicked during an active round (neither round nor match is over).
-		// Typically, this means the user wants to abandon the current match and start a fresh one.
-		log.Printf("Processing 'newGame' action: Starting a New Match (abandoning current active round/match).")
-		resetMatchState(ctx.Game)
+		// One player cannot throw away everyone's progress; restarting mid-round takes a vote.
+		rejectAction(ctx.AssignedClient, "round_in_progress", "The round is still in progress. Call a vote to restart or abandon the match.")
+		return true, false
 	}
 
-	// Comment about resetGameInstance is no longer relevant here.
-	log.Println("Game state has been reset for new game/round. Broadcasting new game state.")
+	announceRoundStart(ctx.Game)
 	return false, true // Always broadcast after a new game/round action
 }
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// adminToken is the bearer token for the admin API, read from BIGTWO_ADMIN_TOKEN at startup.
+// The admin API is disabled while it is empty.
+var adminToken string
+
+// adminAudit records every admin API request.
+var adminAudit *AuditLog
+
+var errAdminDisabled = errors.New("the admin API is disabled")
+
+// adminRequest is the body of the admin POST endpoints. Each action reads the fields it needs.
+type adminRequest struct {
+	PlayerID    string `json:"playerId,omitempty"`
+	Name        string `json:"name,omitempty"`
+	WinnerID    string `json:"winnerId,omitempty"`
+	TargetScore int    `json:"targetScore,omitempty"`
+	Message     string `json:"message,omitempty"`
+}
+
+// adminEndpoint serves an authorized admin request, returning the response status and body.
+type adminEndpoint func(r *http.Request, req adminRequest) (status int, body interface{}, err error)
+
+// adminHandler wraps an admin endpoint with bearer token authorization and audit logging.
+// action names the endpoint in the audit log; table actions log their {action} instead.
+func adminHandler(action string, endpoint adminEndpoint) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		entry := AuditEntry{Time: time.Now(), Actor: r.RemoteAddr, Action: action, TableID: r.PathValue("id")}
+		if a := r.PathValue("action"); a != "" {
+			entry.Action = a
+		}
+		var req adminRequest
+		var body interface{}
+		status, err := authorizeAdmin(r)
+		if err == nil && r.Method == http.MethodPost && r.ContentLength != 0 {
+			if err = decodeJSONBody(r, &req); err != nil {
+				status = http.StatusBadRequest
+			}
+		}
+		if err == nil {
+			status, body, err = endpoint(r, req)
+		}
+		if req != (adminRequest{}) {
+			entry.Params = req
+		}
+		entry.Status = status
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		adminAudit.Record(entry)
+
+		if err != nil {
+			writeJSONError(w, status, err.Error())
+			return
+		}
+		writeJSON(w, status, body)
+	}
+}
+
+// authorizeAdmin checks the request's "Authorization: Bearer" header against adminToken.
+func authorizeAdmin(r *http.Request) (int, error) {
+	if adminToken == "" {
+		return http.StatusForbidden, errAdminDisabled
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+		return http.StatusUnauthorized, errors.New("a valid admin token is required")
+	}
+	return http.StatusOK, nil
+}
+
+// registerAdminRoutes adds the admin API to mux.
+func registerAdminRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/admin/tables", adminHandler("list-tables", adminListTables))
+	mux.HandleFunc("GET /api/admin/tables/{id}", adminHandler("inspect", adminGetTable))
+	mux.HandleFunc("POST /api/admin/tables/{id}/{action}", adminHandler("table-action", adminTableAction))
+	mux.HandleFunc("POST /api/admin/broadcast", adminHandler("broadcast", adminBroadcast))
+	mux.HandleFunc("GET /api/admin/audit", adminHandler("audit", adminAuditEntries))
+}
+
+// adminTableSummary is one entry of GET /api/admin/tables.
+type adminTableSummary struct {
+	ID           string   `json:"id"`
+	Players      []string `json:"players"`
+	Connected    int      `json:"connected"`
+	RoundNumber  int      `json:"roundNumber"`
+	IsGameOver   bool     `json:"isGameOver"`
+	IsMatchOver  bool     `json:"isMatchOver"`
+	Paused       bool     `json:"paused"`
+	TournamentID string   `json:"tournamentId,omitempty"`
+}
+
+// adminListTables serves GET /api/admin/tables.
+func adminListTables(r *http.Request, _ adminRequest) (int, interface{}, error) {
+	gameInstanceMutex.Lock()
+	defer gameInstanceMutex.Unlock()
+	clientsMu.Lock()
+	connected := make(map[*GameState]int)
+	for _, c := range clients {
+		connected[c.game]++
+	}
+	clientsMu.Unlock()
+
+	summaries := make([]adminTableSummary, 0, len(tables))
+	for _, id := range tableIDs() {
+		game := tables[id]
+		s := adminTableSummary{
+			ID: id, Connected: connected[game], RoundNumber: game.RoundNumber, IsGameOver: game.IsGameOver,
+			IsMatchOver: game.IsMatchOver, Paused: game.Paused, TournamentID: game.TournamentID,
+		}
+		for _, p := range game.Players {
+			s.Players = append(s.Players, p.ID)
+		}
+		summaries = append(summaries, s)
+	}
+	return http.StatusOK, summaries, nil
+}
+
+// adminGetTable serves GET /api/admin/tables/{id} with the table's admin view, every hand included.
+func adminGetTable(r *http.Request, _ adminRequest) (int, interface{}, error) {
+	gameInstanceMutex.Lock()
+	defer gameInstanceMutex.Unlock()
+	game := tables[r.PathValue("id")]
+	if game == nil {
+		return http.StatusNotFound, nil, errors.New("table not found")
+	}
+	return http.StatusOK, NewGameView(game, Viewer{Role: ViewerAdmin}), nil
+}
+
+// adminTableAction serves POST /api/admin/tables/{id}/{action}, where action is one of
+// kick, replace, pause, resume, end-round, target-score or rename. Players see the result
+// in a game state broadcast; the caller gets the table's admin view.
+func adminTableAction(r *http.Request, req adminRequest) (int, interface{}, error) {
+	gameInstanceMutex.Lock()
+	defer gameInstanceMutex.Unlock()
+	game := tables[r.PathValue("id")]
+	if game == nil {
+		return http.StatusNotFound, nil, errors.New("table not found")
+	}
+	seat := func() (*Player, error) {
+		if p := game.playerByID(req.PlayerID); p != nil {
+			return p, nil
+		}
+		return nil, fmt.Errorf("no player %q at this table", req.PlayerID)
+	}
+
+	var notice string
+	switch r.PathValue("action") {
+	case "kick":
+		// The seat is released as by replace, and whoever sat in it is refused a seat at this
+		// table until the match ends, so that they cannot simply reconnect.
+		p, err := seat()
+		if err != nil {
+			return http.StatusBadRequest, nil, err
+		}
+		identities := disconnectSeat(game, p, "You were removed from the table by an administrator.")
+		if len(identities) == 0 {
+			return http.StatusConflict, nil, fmt.Errorf("%s is not connected", p.ID)
+		}
+		if game.kicked == nil {
+			game.kicked = make(map[string]bool)
+		}
+		for _, identity := range identities {
+			game.kicked[identity] = true
+		}
+		p.AccountID = ""
+		p.Rating = 0
+		notice = fmt.Sprintf("%s was removed from the table by an administrator.", p.Name)
+
+	case "replace":
+		// The seat keeps its cards and score but is released for another person to take.
+		p, err := seat()
+		if err != nil {
+			return http.StatusBadRequest, nil, err
+		}
+		disconnectSeat(game, p, "Your seat was given to another player by an administrator.")
+		p.AccountID = ""
+		p.Rating = 0
+		if req.Name != "" {
+			p.Name = sanitizeAlias(req.Name, p.ID)
+		}
+		notice = fmt.Sprintf("Seat %s is open for a replacement player.", p.ID)
+
+	case "pause":
+		if err := PauseTable(game, ""); err != nil {
+			return http.StatusConflict, nil, err
+		}
+		notice = "An administrator has paused the table."
+
+	case "resume":
+		if err := ResumeTable(game, ""); err != nil {
+			return http.StatusConflict, nil, err
+		}
+		notice = "An administrator has resumed the table."
+
+	case "end-round":
+		if game.IsGameOver {
+			return http.StatusConflict, nil, errors.New("the round is already over")
+		}
+		winner := fewestCards(game)
+		if req.WinnerID != "" {
+			if winner = game.playerByID(req.WinnerID); winner == nil {
+				return http.StatusBadRequest, nil, fmt.Errorf("no player %q at this table", req.WinnerID)
+			}
+			if winner.IsEliminated {
+				return http.StatusBadRequest, nil, fmt.Errorf("%s is eliminated from the match", req.WinnerID)
+			}
+		}
+		game.forcedRound = true // Not rated; see updateRatings
+		completeRound(game, winner)
+		notice = fmt.Sprintf("An administrator ended the round. %s wins it. This match will not be rated.", winner.Name)
+
+	case "target-score":
+		if req.TargetScore <= 0 {
+			return http.StatusBadRequest, nil, errors.New("targetScore must be positive")
+		}
+		game.TargetScore = req.TargetScore
+		notice = fmt.Sprintf("An administrator set the target score to %d.", req.TargetScore)
+
+	case "rename":
+		p, err := seat()
+		if err != nil {
+			return http.StatusBadRequest, nil, err
+		}
+		if strings.TrimSpace(req.Name) == "" {
+			return http.StatusBadRequest, nil, errors.New("name is required")
+		}
+		p.Name = sanitizeAlias(req.Name, p.ID)
+
+	default:
+		return http.StatusNotFound, nil, errors.New("unknown action")
+	}
+
+	gameLog(game).Info("admin action applied", "action", r.PathValue("action"))
+	if notice != "" {
+		broadcastSystemMessage(game, notice)
+	}
+	broadcastGameState(game)
+	return http.StatusOK, NewGameView(game, Viewer{Role: ViewerAdmin}), nil
+}
+
+// adminBroadcast serves POST /api/admin/broadcast, sending a system message to every connection.
+func adminBroadcast(_ *http.Request, req adminRequest) (int, interface{}, error) {
+	if strings.TrimSpace(req.Message) == "" {
+		return http.StatusBadRequest, nil, errors.New("message is required")
+	}
+	chatPayload := map[string]string{"type": "chat", "sender": "System", "content": req.Message}
+	jsonMsg, _ := json.Marshal(chatPayload)
+	broadcastMessage(websocket.TextMessage, jsonMsg, nil)
+	return http.StatusOK, map[string]string{"status": "sent"}, nil
+}
+
+// adminAuditEntries serves GET /api/admin/audit with the most recent audit entries.
+func adminAuditEntries(_ *http.Request, _ adminRequest) (int, interface{}, error) {
+	return http.StatusOK, adminAudit.Entries(), nil
+}
+
+// fewestCards returns the active player with the fewest cards left, the first in seat order on ties.
+func fewestCards(game *GameState) *Player {
+	var best *Player
+	for _, p := range game.Players {
+		if p.IsEliminated {
+			continue
+		}
+		if best == nil || len(p.Hand) < len(best.Hand) {
+			best = p
+		}
+	}
+	return best
+}
+
+// disconnectSeat tells the connection seated as player why and closes it. Its handler then
+// cleans up, leaving the seat free. Returns the connection's seat and host identities, or
+// nil if the player was not connected. Assumes gameInstanceMutex is held.
+func disconnectSeat(game *GameState, player *Player, reason string) []string {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	for _, c := range clients {
+		if c.game != game || c.player != player {
+			continue
+		}
+		jsonMsg, _ := json.Marshal(map[string]string{"type": "error", "content": reason})
+		c.write(websocket.TextMessage, jsonMsg)
+		c.conn.Close()
+		return []string{c.identity, c.host}
+	}
+	return nil
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const adminTestToken = "test-admin-token"
+
+// newAdminTestMux enables the admin API with adminTestToken and an audit log in a temp dir.
+func newAdminTestMux(t *testing.T) *http.ServeMux {
+	t.Helper()
+	log.SetOutput(io.Discard)
+	audit, err := OpenAuditLog(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prevToken, prevAudit := adminToken, adminAudit
+	adminToken, adminAudit = adminTestToken, audit
+	t.Cleanup(func() {
+		adminToken, adminAudit = prevToken, prevAudit
+		audit.file.Close()
+		log.SetOutput(defaultLogOutput)
+	})
+	mux := http.NewServeMux()
+	registerAdminRoutes(mux)
+	return mux
+}
+
+// adminDo sends a request to mux and returns the status and decoded JSON body.
+func adminDo(t *testing.T, mux *http.ServeMux, method, path, token string, body interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	var reqBody io.Reader
+	if body != nil {
+		b, _ := json.Marshal(body)
+		reqBody = bytes.NewReader(b)
+	}
+	req := httptest.NewRequest(method, path, reqBody)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	var got map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &got)
+	return rec.Code, got
+}
+
+// adminTestTable registers queueTestGame's table as id for the duration of the test.
+func adminTestTable(t *testing.T, id string) *GameState {
+	t.Helper()
+	game := queueTestGame()
+	game.ID = id
+	gameInstanceMutex.Lock()
+	defer gameInstanceMutex.Unlock()
+	if err := registerTable(game); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		gameInstanceMutex.Lock()
+		delete(tables, id)
+		gameInstanceMutex.Unlock()
+	})
+	return game
+}
+
+func TestAdminAuthorization(t *testing.T) {
+	mux := newAdminTestMux(t)
+	adminTestTable(t, "admin-auth")
+
+	tests := []struct {
+		name       string
+		token      string
+		disabled   bool
+		wantStatus int
+	}{
+		{"valid token", adminTestToken, false, http.StatusOK},
+		{"no token", "", false, http.StatusUnauthorized},
+		{"wrong token", "guess", false, http.StatusUnauthorized},
+		{"api disabled", "", true, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.disabled {
+				adminToken = ""
+				defer func() { adminToken = adminTestToken }()
+			}
+			before := len(adminAudit.Entries())
+			status, body := adminDo(t, mux, "GET", "/api/admin/tables/admin-auth", tt.token, nil)
+			if status != tt.wantStatus {
+				t.Fatalf("status %d (%v), want %d", status, body, tt.wantStatus)
+			}
+			entries := adminAudit.Entries()
+			if len(entries) != before+1 {
+				t.Fatalf("%d audit entries recorded, want 1", len(entries)-before)
+			}
+			if e := entries[len(entries)-1]; e.Action != "inspect" || e.TableID != "admin-auth" || e.Status != tt.wantStatus {
+				t.Errorf("audit entry %+v", e)
+			}
+		})
+	}
+
+	persisted, err := os.ReadFile(adminAudit.file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := bytes.Count(persisted, []byte("\n")); lines != len(tests) {
+		t.Errorf("audit log file has %d lines, want %d", lines, len(tests))
+	}
+}
+
+func TestAdminInspectShowsEveryHand(t *testing.T) {
+	mux := newAdminTestMux(t)
+	adminTestTable(t, "admin-inspect")
+
+	status, body := adminDo(t, mux, "GET", "/api/admin/tables/admin-inspect", adminTestToken, nil)
+	if status != http.StatusOK {
+		t.Fatalf("status %d: %v", status, body)
+	}
+	hands, _ := body["hands"].(map[string]interface{})
+	if body["role"] != "admin" || len(hands) != 3 {
+		t.Errorf("role %v with %d hands, want admin with 3", body["role"], len(hands))
+	}
+
+	if status, _ := adminDo(t, mux, "GET", "/api/admin/tables/nowhere", adminTestToken, nil); status != http.StatusNotFound {
+		t.Errorf("unknown table: status %d, want 404", status)
+	}
+}
+
+func TestAdminTableActions(t *testing.T) {
+	tests := []struct {
+		action     string
+		body       adminRequest
+		setup      func(*GameState)
+		wantStatus int
+		check      func(*testing.T, *GameState)
+	}{
+		{"pause", adminRequest{}, nil, http.StatusOK, func(t *testing.T, g *GameState) {
+			if !g.Paused {
+				t.Error("table not paused")
+			}
+		}},
+		{"pause", adminRequest{}, func(g *GameState) { g.Paused = true }, http.StatusConflict, nil},
+		{"resume", adminRequest{}, func(g *GameState) { g.Paused = true }, http.StatusOK, func(t *testing.T, g *GameState) {
+			if g.Paused {
+				t.Error("table still paused")
+			}
+		}},
+		{"end-round", adminRequest{}, nil, http.StatusOK, func(t *testing.T, g *GameState) {
+			if !g.IsGameOver || g.WinnerID != "player2" {
+				t.Errorf("round over %v, winner %q; want player2 with the fewest cards", g.IsGameOver, g.WinnerID)
+			}
+		}},
+		{"end-round", adminRequest{WinnerID: "player3"}, nil, http.StatusOK, func(t *testing.T, g *GameState) {
+			if g.WinnerID != "player3" || g.Scores["player3"] != 0 || g.Scores["player1"] == 0 {
+				t.Errorf("winner %q, scores %v", g.WinnerID, g.Scores)
+			}
+		}},
+		{"end-round", adminRequest{}, func(g *GameState) { g.IsGameOver = true }, http.StatusConflict, nil},
+		{"end-round", adminRequest{WinnerID: "player9"}, nil, http.StatusBadRequest, nil},
+		{"end-round", adminRequest{WinnerID: "player3"}, func(g *GameState) { g.Players[2].IsEliminated = true }, http.StatusBadRequest, nil},
+		{"target-score", adminRequest{TargetScore: 50}, nil, http.StatusOK, func(t *testing.T, g *GameState) {
+			if g.TargetScore != 50 {
+				t.Errorf("target score %d, want 50", g.TargetScore)
+			}
+		}},
+		{"target-score", adminRequest{TargetScore: -1}, nil, http.StatusBadRequest, nil},
+		{"rename", adminRequest{PlayerID: "player2", Name: "  A very long name indeed  "}, nil, http.StatusOK, func(t *testing.T, g *GameState) {
+			if name := g.Players[1].Name; name != "A very long name ind" {
+				t.Errorf("renamed to %q", name)
+			}
+		}},
+		{"rename", adminRequest{PlayerID: "player2"}, nil, http.StatusBadRequest, nil},
+		{"replace", adminRequest{PlayerID: "player1", Name: "Sub"}, func(g *GameState) {
+			g.Players[0].AccountID, g.Players[0].Rating = "acct1", 1500
+		}, http.StatusOK, func(t *testing.T, g *GameState) {
+			p := g.Players[0]
+			if p.AccountID != "" || p.Rating != 0 || p.Name != "Sub" || len(p.Hand) != 3 {
+				t.Errorf("replaced seat %+v, want it unbound, renamed and holding its cards", p)
+			}
+		}},
+		{"kick", adminRequest{PlayerID: "player1"}, nil, http.StatusConflict, nil}, // Nobody connected
+		{"kick", adminRequest{PlayerID: "player9"}, nil, http.StatusBadRequest, nil},
+		{"shuffle", adminRequest{}, nil, http.StatusNotFound, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			mux := newAdminTestMux(t)
+			game := adminTestTable(t, "admin-actions")
+			if tt.setup != nil {
+				tt.setup(game)
+			}
+			status, body := adminDo(t, mux, "POST", "/api/admin/tables/admin-actions/"+tt.action, adminTestToken, tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status %d (%v), want %d", status, body, tt.wantStatus)
+			}
+			if tt.check != nil {
+				tt.check(t, game)
+			}
+			entries := adminAudit.Entries()
+			if len(entries) != 1 || entries[0].Action != tt.action || entries[0].Status != tt.wantStatus {
+				t.Errorf("audit log %+v, want one %s entry", entries, tt.action)
+			}
+		})
+	}
+}
+
+func TestAdminEndRound_MatchNotRated(t *testing.T) {
+	mux := newAdminTestMux(t)
+	store, err := OpenAccountStore(filepath.Join(t.TempDir(), "accounts.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	savedStore := accountStore
+	accountStore = store
+	t.Cleanup(func() {
+		accountStore = savedStore
+		if err := store.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	game := adminTestTable(t, "admin-unrated")
+	game.TargetScore = 1
+	for _, p := range game.Players {
+		account, _, err := store.Register("user-"+p.ID, "secret1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.AccountID = account.ID
+	}
+
+	if status, body := adminDo(t, mux, "POST", "/api/admin/tables/admin-unrated/end-round", adminTestToken, adminRequest{}); status != http.StatusOK {
+		t.Fatalf("end-round: status %d: %v", status, body)
+	}
+	if !game.IsMatchOver {
+		t.Fatal("match should end at a target score of 1")
+	}
+	for _, p := range game.Players {
+		if account, _ := store.Account(p.AccountID); account.Rating != DefaultRating {
+			t.Errorf("%s rated %v after a forced round, want %v", p.ID, account.Rating, DefaultRating)
+		}
+	}
+}
+
+func TestWebSocket_AdminPauseAndKick(t *testing.T) {
+	h := newWSHarness(t, 2, []Deck{
+		{{Rank3, Diamonds}, {Rank4, Clubs}},
+		{{Rank5, Diamonds}, {Rank6, Hearts}},
+	})
+	mux := newAdminTestMux(t)
+	cs, _ := h.connectAll(2)
+	path := "/api/admin/tables/" + h.game.ID + "/"
+
+	if status, body := adminDo(t, mux, "POST", path+"pause", adminTestToken, nil); status != http.StatusOK {
+		t.Fatalf("pause: status %d: %v", status, body)
+	}
+	if got := states(cs); !got[0].Paused || !got[1].Paused {
+		t.Errorf("players see paused %v / %v after pausing", got[0].Paused, got[1].Paused)
+	}
+	cs[0].play(Card{Rank3, Diamonds})
+	cs[0].expectError("paused")
+
+	if status, body := adminDo(t, mux, "POST", path+"resume", adminTestToken, nil); status != http.StatusOK {
+		t.Fatalf("resume: status %d: %v", status, body)
+	}
+	states(cs)
+	cs[0].play(Card{Rank3, Diamonds})
+	h.assertConsistent(cs, states(cs))
+
+	if status, body := adminDo(t, mux, "POST", path+"kick", adminTestToken, adminRequest{PlayerID: "player2"}); status != http.StatusOK {
+		t.Fatalf("kick: status %d: %v", status, body)
+	}
+	cs[1].expectError("removed from the table")
+	for range cs[1].frames { // Drained until the server closes the connection
+	}
+
+	// The kicked guest cannot reconnect into the seat, even once it is free.
+	h.waitForSeatFree("player2")
+	c := h.dial("")
+	c.expectError("cannot rejoin until the match ends")
+}
+
+func TestWebSocket_AdminKickReleasesAccountSeat(t *testing.T) {
+	h := newWSHarness(t, 2, nil)
+	mux := newAdminTestMux(t)
+	store, err := OpenAccountStore(filepath.Join(t.TempDir(), "accounts.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	savedStore := accountStore
+	accountStore = store
+	t.Cleanup(func() {
+		accountStore = savedStore
+		if err := store.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	_, alice, _ := store.Register("alice", "secret1")
+	bob, bobToken, _ := store.Register("bob", "secret1")
+
+	h.connect()
+	kicked := h.dial("&token=" + alice)
+	if state := kicked.nextState(); state.YourPlayerID != "player2" {
+		t.Fatalf("alice seated as %s, want player2", state.YourPlayerID)
+	}
+	if status, body := adminDo(t, mux, "POST", "/api/admin/tables/"+h.game.ID+"/kick", adminTestToken, adminRequest{PlayerID: "player2"}); status != http.StatusOK {
+		t.Fatalf("kick: status %d: %v", status, body)
+	}
+	h.waitForSeatFree("player2")
+
+	h.dial("&token=" + alice).expectError("cannot rejoin")
+	h.dial("").expectError("cannot rejoin") // alice's host, as a guest
+	replacement := h.dialFrom("127.0.0.2", "&token="+bobToken)
+	if state := replacement.nextState(); state.YourPlayerID != "player2" {
+		t.Fatalf("bob seated as %s, want the released player2", state.YourPlayerID)
+	}
+	gameInstanceMutex.Lock()
+	defer gameInstanceMutex.Unlock()
+	if owner := h.game.Players[1].AccountID; owner != bob.ID {
+		t.Errorf("player2 bound to %q, want bob's account %q", owner, bob.ID)
+	}
+}
+
+// waitForSeatFree blocks until no client is seated as playerID at the harness table.
+func (h *wsHarness) waitForSeatFree(playerID string) {
+	h.t.Helper()
+	deadline := time.Now().Add(wsTestTimeout)
+	for time.Now().Before(deadline) {
+		clientsMu.Lock()
+		taken := false
+		for _, c := range clients {
+			taken = taken || (c.game == h.game && c.player != nil && c.player.ID == playerID)
+		}
+		clientsMu.Unlock()
+		if !taken {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	h.t.Fatalf("seat %s still taken", playerID)
+}
+
+func TestAdminBroadcastReachesEveryTable(t *testing.T) {
+	mux := newAdminTestMux(t)
+	a, b := newWSHarness(t, 2, nil), newWSHarness(t, 2, nil)
+	ca, _ := a.connect()
+	cb, _ := b.connect()
+
+	if status, body := adminDo(t, mux, "POST", "/api/admin/broadcast", adminTestToken, adminRequest{}); status != http.StatusBadRequest {
+		t.Errorf("empty message: status %d (%v), want 400", status, body)
+	}
+	if status, body := adminDo(t, mux, "POST", "/api/admin/broadcast", adminTestToken, adminRequest{Message: "Restarting soon"}); status != http.StatusOK {
+		t.Fatalf("broadcast: status %d: %v", status, body)
+	}
+	for _, c := range []*wsTestClient{ca, cb} {
+		var chat struct{ Sender, Content string }
+		json.Unmarshal(c.next("chat"), &chat)
+		if chat.Sender != "System" || chat.Content != "Restarting soon" {
+			t.Errorf("%s got chat %+v", c.playerID, chat)
+		}
+	}
+}
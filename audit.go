@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// auditLogFile is where admin actions are recorded, one JSON object per line, in the data directory.
+const auditLogFile = "admin-audit.log"
+
+// auditLogMemory is how many recent entries are kept for GET /api/admin/audit.
+const auditLogMemory = 500
+
+// AuditEntry records one admin API request, including refused ones.
+type AuditEntry struct {
+	Time    time.Time   `json:"time"`
+	Actor   string      `json:"actor"` // Remote address of the caller
+	Action  string      `json:"action"`
+	TableID string      `json:"tableId,omitempty"`
+	Params  interface{} `json:"params,omitempty"` // Request body, if any
+	Status  int         `json:"status"`           // HTTP status of the response
+	Error   string      `json:"error,omitempty"`
+}
+
+// AuditLog is an append-only record of admin actions, persisted as JSON lines.
+// A nil *AuditLog only writes to the server log.
+type AuditLog struct {
+	mu      sync.Mutex
+	file    *os.File
+	entries []AuditEntry // Most recent auditLogMemory entries, oldest first
+}
+
+// OpenAuditLog opens (creating if needed) the audit log at path for appending.
+func OpenAuditLog(path string) (*AuditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	return &AuditLog{file: f}, nil
+}
+
+// Record appends an entry to the log.
+func (l *AuditLog) Record(e AuditEntry) {
+	slog.Info("admin audit", "action", e.Action, "actor", e.Actor, "game", e.TableID, "status", e.Status, "err", e.Error)
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, e)
+	if len(l.entries) > auditLogMemory {
+		l.entries = l.entries[len(l.entries)-auditLogMemory:]
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		slog.Error("cannot encode audit entry", "err", err)
+		return
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		slog.Error("cannot write audit log", "err", err)
+	}
+}
+
+// Entries returns the most recent entries, oldest first.
+func (l *AuditLog) Entries() []AuditEntry {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]AuditEntry(nil), l.entries...)
+}
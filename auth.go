@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// credentialsRequest is the body of /api/register and /api/login.
+type credentialsRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// sessionResponse is returned on successful registration or login.
+// The token is passed as ?token=... when opening the game websocket.
+type sessionResponse struct {
+	AccountID string `json:"accountId"`
+	Username  string `json:"username"`
+	Token     string `json:"token"`
+}
+
+// handleRegister creates an account and returns a session token.
+func handleRegister(w http.ResponseWriter, r *http.Request) {
+	handleCredentials(w, r, accountStore.Register)
+}
+
+// handleLogin checks credentials and returns a session token.
+func handleLogin(w http.ResponseWriter, r *http.Request) {
+	handleCredentials(w, r, accountStore.Login)
+}
+
+// handleLogout revokes the session token in the "Authorization: Bearer" header.
+func handleLogout(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		writeJSONError(w, http.StatusUnauthorized, "missing bearer token")
+		return
+	}
+	if err := accountStore.Logout(token); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidToken) {
+			status = http.StatusUnauthorized
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handleCredentials(w http.ResponseWriter, r *http.Request, authenticate func(username, password string) (Account, string, error)) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if ok, retryAfter := credentialsLimiter.allow(r.RemoteAddr, time.Now()); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		writeJSONError(w, http.StatusTooManyRequests, "too many attempts; try again later")
+		return
+	}
+	var req credentialsRequest
+	if err := decodeJSONBody(r, &req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	account, token, err := authenticate(req.Username, req.Password)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrInvalidCredentials) {
+			status = http.StatusUnauthorized
+		} else if errors.Is(err, ErrUsernameTaken) {
+			status = http.StatusConflict
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, sessionResponse{AccountID: account.ID, Username: account.Username, Token: token})
+}
+
+// authenticateHandshake identifies the account opening a websocket connection from a session
+// token, obtained from /api/login, in the "token" query parameter or an "Authorization: Bearer"
+// header. Passwords are never accepted here, so that they cannot end up in access logs.
+// Returns a nil account for guests who supply no token, and an error if the token is invalid.
+func authenticateHandshake(store *AccountStore, r *http.Request) (*Account, error) {
+	if store == nil {
+		return nil, nil
+	}
+	query := r.URL.Query()
+	if query.Has("password") {
+		return nil, errors.New("passwords are not accepted on the websocket; log in at /api/login and pass the token")
+	}
+	token := query.Get("token")
+	if auth := r.Header.Get("Authorization"); token == "" && strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if token == "" {
+		return nil, nil
+	}
+	account, err := store.AccountForToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// seatIdentity names who is connecting, for refusing a seat to someone an administrator
+// removed: the account, or for guests the remote host (see hostIdentity).
+func seatIdentity(account *Account, remoteAddr string) string {
+	if account != nil {
+		return "account:" + account.ID
+	}
+	return hostIdentity(remoteAddr)
+}
+
+// hostIdentity names the remote host of a connection. It is all that is known of a guest,
+// and a kicked account holder could come back as one, so a kick refuses the host as well.
+// The trade-off is that everyone behind the same address, such as players sharing a NAT,
+// is refused a seat at that table until the match ends.
+func hostIdentity(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	return "host:" + host
+}
+
+// assignSeat picks the seat for a new connection. An account reclaims the seat bound to it;
+// otherwise the first free seat not reserved for another account is used and, for
+// account holders, bound to them. Returns nil if no seat is available.
+// Assumes gameInstanceMutex and clientsMu are held by the caller.
+func assignSeat(game *GameState, account *Account) *Player {
+	isTaken := func(p *Player) bool {
+		for _, cl := range clients {
+			if cl.player == p {
+				return true
+			}
+		}
+		return false
+	}
+
+	if account != nil {
+		for _, p := range game.Players {
+			if p.AccountID == account.ID {
+				if isTaken(p) {
+					return nil // Already seated on another connection
+				}
+				p.Rating = account.Rating
+				return p
+			}
+		}
+	}
+	for _, p := range game.Players {
+		if p.AccountID != "" || isTaken(p) {
+			continue
+		}
+		if account != nil {
+			p.AccountID = account.ID
+			p.Name = account.Username
+			p.Rating = account.Rating
+		}
+		return p
+	}
+	return nil
+}
+
+// releaseIdleSeats unbinds the accounts of seats nobody is connected to, so that a new match
+// is open to whoever joins next rather than reserved for someone who has left. Seats of
+// tournament tables stay reserved for their entrants. Assumes gameInstanceMutex is held.
+func releaseIdleSeats(game *GameState) {
+	if game.TournamentID != "" {
+		return
+	}
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	connected := make(map[*Player]bool)
+	for _, c := range clients {
+		if c.game == game && c.player != nil {
+			connected[c.player] = true
+		}
+	}
+	for i, p := range game.Players {
+		if p.AccountID == "" || connected[p] {
+			continue
+		}
+		playerLog(game, p).Info("seat released", "account", p.AccountID)
+		p.AccountID = ""
+		p.Rating = 0
+		p.Name = fmt.Sprintf("P%d", i+1)
+	}
+}
+
+// decodeJSONBody decodes a JSON request body into v.
+func decodeJSONBody(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errors.New("malformed JSON")
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
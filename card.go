@@ -43,6 +43,7 @@ const (
 	King   Rank = 13
 	Ace    Rank = 14
 	Two    Rank = 15 // Highest rank in Big 2
+	Joker  Rank = 16 // Wildcard in the jokers variant; sorts above every natural card
 )
 
 // String returns a string representation of the rank.
@@ -58,6 +59,8 @@ func (r Rank) String() string {
 		return "A"
 	case Two:
 		return "2"
+	case Joker:
+		return "Joker"
 	default:
 		return fmt.Sprintf("%d", r)
 	}
@@ -69,8 +72,21 @@ type Card struct {
 	Suit Suit `json:"suit"`
 }
 
-// String returns a string representation of the card (e.g., "AS" for Ace of Spades).
+// NewJoker returns the n-th joker (0 or 1). Jokers share the Joker rank and are told apart by suit.
+func NewJoker(n int) Card {
+	return Card{Rank: Joker, Suit: Suit(n)}
+}
+
+// IsJoker reports whether the card is a joker.
+func (c Card) IsJoker() bool {
+	return c.Rank == Joker
+}
+
+// String returns a string representation of the card (e.g., "AS" for Ace of Spades, "JK1" for the first joker).
 func (c Card) String() string {
+	if c.IsJoker() {
+		return fmt.Sprintf("JK%d", int(c.Suit)+1)
+	}
 	suitStr := c.Suit.String()
 	if len(suitStr) > 0 {
 		return fmt.Sprintf("%s%c", c.Rank.String(), suitStr[0])
@@ -85,7 +101,12 @@ type Deck []Card
 
 // NewDeck creates a standard 52-card deck.
 func NewDeck() Deck {
-	deck := make(Deck, 0, 52)
+	return NewDeckWithJokers(0)
+}
+
+// NewDeckWithJokers creates a standard 52-card deck plus up to two jokers.
+func NewDeckWithJokers(jokers int) Deck {
+	deck := make(Deck, 0, 52+jokers)
 	suits := []Suit{Diamonds, Clubs, Hearts, Spades}
 	ranks := []Rank{
 		Rank3, Rank4, Rank5, Rank6, Rank7, Rank8, Rank9, Rank10,
@@ -97,6 +118,9 @@ func NewDeck() Deck {
 			deck = append(deck, Card{Rank: rank, Suit: suit})
 		}
 	}
+	for i := 0; i < jokers && i < maxJokers; i++ {
+		deck = append(deck, NewJoker(i))
+	}
 	return deck
 }
 
@@ -113,17 +137,19 @@ func (d Deck) Shuffle() {
 
 // Deal removes and returns the top 'n' cards from the deck.
 // Returns the dealt cards and a boolean indicating success (e.g., enough cards).
+// The dealt cards are capped at n, so appending to them never overwrites the rest of the deck.
 func (d *Deck) Deal(n int) (Deck, bool) {
 	if len(*d) < n {
 		return nil, false
 	}
-	dealtCards := (*d)[:n]
+	dealtCards := (*d)[:n:n]
 	*d = (*d)[n:]
 	return dealtCards, true
 }
 
 // Sorts a deck of cards (typically a player's hand).
 // Big 2 sort order: Rank (3 low, 2 high), then Suit (Diamonds low, Spades high).
+// Jokers sort after every natural card, first joker before second.
 func (d Deck) Sort() {
 	sort.SliceStable(d, func(i, j int) bool {
 		if d[i].Rank == d[j].Rank {
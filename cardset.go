@@ -0,0 +1,249 @@
+package main
+
+import "math/bits"
+
+// CardSet is a set of cards stored as a bitmask, for evaluating hands without allocating.
+// Bit (rank-3)*4 + suit holds each card, so iterating bits from low to high visits cards in
+// Big 2 order. Bits 0-51 are the natural cards and bits 52-53 the two jokers.
+type CardSet uint64
+
+const (
+	numCardBits = 54
+	rankMask    = CardSet(0xF) // The four suits of one rank, shifted by rankShift
+)
+
+// rankShift returns the bit offset of the lowest card of the rank.
+func rankShift(r Rank) uint {
+	return uint(r-Rank3) * 4
+}
+
+// CardBit returns the set containing only c, or the empty set if c is not a valid card.
+func CardBit(c Card) CardSet {
+	if c.Rank < Rank3 || c.Rank > Joker || c.Suit < Diamonds || c.Suit > Spades || (c.IsJoker() && c.Suit > Clubs) {
+		return 0
+	}
+	return 1 << (rankShift(c.Rank) + uint(c.Suit))
+}
+
+// cardAt returns the card stored at bit index i.
+func cardAt(i int) Card {
+	return Card{Rank: Rank3 + Rank(i/4), Suit: Suit(i % 4)}
+}
+
+// NewCardSet returns the set of the given cards.
+func NewCardSet(cards ...Card) CardSet {
+	var s CardSet
+	for _, c := range cards {
+		s |= CardBit(c)
+	}
+	return s
+}
+
+// CardSet returns the deck as a set. Duplicate cards collapse into one.
+func (d Deck) CardSet() CardSet {
+	return NewCardSet(d...)
+}
+
+// Deck returns the cards in the set, sorted in Big 2 order.
+func (s CardSet) Deck() Deck {
+	deck := make(Deck, 0, s.Len())
+	for rest := s; rest != 0; rest &= rest - 1 {
+		deck = append(deck, cardAt(bits.TrailingZeros64(uint64(rest))))
+	}
+	return deck
+}
+
+// Len returns the number of cards in the set.
+func (s CardSet) Len() int { return bits.OnesCount64(uint64(s)) }
+
+// Contains reports whether the set holds c.
+func (s CardSet) Contains(c Card) bool {
+	bit := CardBit(c)
+	return bit != 0 && s&bit != 0
+}
+
+// ContainsAll reports whether every card of other is in the set.
+func (s CardSet) ContainsAll(other CardSet) bool { return s&other == other }
+
+// Union returns the cards in either set.
+func (s CardSet) Union(other CardSet) CardSet { return s | other }
+
+// Intersect returns the cards in both sets.
+func (s CardSet) Intersect(other CardSet) CardSet { return s & other }
+
+// Without returns the cards in s that are not in other.
+func (s CardSet) Without(other CardSet) CardSet { return s &^ other }
+
+// OfRank returns the cards of the given rank.
+func (s CardSet) OfRank(r Rank) CardSet { return s & (rankMask << rankShift(r)) }
+
+// OfSuit returns the natural cards of the given suit.
+func (s CardSet) OfSuit(suit Suit) CardSet {
+	return s & (lowBits << uint(suit))
+}
+
+// HasJokers reports whether the set holds a joker.
+func (s CardSet) HasJokers() bool { return s.OfRank(Joker) != 0 }
+
+// highest returns the highest card in the set in Big 2 order. The set must not be empty.
+func (s CardSet) highest() Card {
+	return cardAt(63 - bits.LeadingZeros64(uint64(s)))
+}
+
+// lowBits holds the lowest (Diamonds) bit of each of the 13 natural ranks.
+const lowBits = CardSet(0x1111111111111)
+
+// rankPresence returns the set with the Diamonds bit of every rank present in s set,
+// so that its popcount is the number of distinct ranks.
+func (s CardSet) rankPresence() CardSet {
+	return (s | s>>1 | s>>2 | s>>3) & lowBits
+}
+
+// ClassifyCardSet classifies a hand without allocating, returning its comparison value and
+// false if the cards are not a valid play. Rulesets other than Big Two, and hands with
+// jokers, are classified through DeterminePlayedHand.
+func (re *BigTwoRuleEngine) ClassifyCardSet(s CardSet) (HandValue, bool) {
+	invalid := HandValue{Type: InvalidHand, Rank: -1, Suit: -1}
+	if re.Options.Mode != ModeBigTwo || s.HasJokers() {
+		played, err := re.DeterminePlayedHand(s.Deck())
+		if err != nil {
+			return invalid, false
+		}
+		return played.Value(), true
+	}
+
+	n := s.Len()
+	top := s.highest()
+	switch n {
+	case 1:
+		return HandValue{Type: Single, Size: 1, Rank: top.Rank, Suit: top.Suit}, true
+	case 2, 3:
+		if s.OfRank(top.Rank) != s {
+			return invalid, false
+		}
+		if n == 2 {
+			return HandValue{Type: Pair, Size: 2, Rank: top.Rank, Suit: re.highestSuitInSet(s)}, true
+		}
+		return HandValue{Type: Triple, Size: 3, Rank: top.Rank, Suit: -1}, true
+	case 5:
+		return re.classifyFiveCardSet(s, top)
+	}
+	return invalid, false
+}
+
+// highestSuitInSet returns the strongest suit among the cards under the ruleset's suit order.
+func (re *BigTwoRuleEngine) highestSuitInSet(s CardSet) Suit {
+	best := Suit(-1)
+	for rest := s; rest != 0; rest &= rest - 1 {
+		suit := cardAt(bits.TrailingZeros64(uint64(rest))).Suit
+		if best < 0 || re.suitValue(suit) > re.suitValue(best) {
+			best = suit
+		}
+	}
+	return best
+}
+
+// classifyFiveCardSet classifies a five-card set the same way as the Deck-based checks:
+// straight flush, four of a kind plus one, full house, flush, then straight.
+func (re *BigTwoRuleEngine) classifyFiveCardSet(s CardSet, top Card) (HandValue, bool) {
+	present := s.rankPresence()
+	switch bits.OnesCount64(uint64(present)) {
+	case 2: // Four of a kind plus one, or a full house
+		low := cardAt(bits.TrailingZeros64(uint64(s))).Rank
+		lowCount := s.OfRank(low).Len()
+		switch lowCount {
+		case 4:
+			return HandValue{Type: FourOfAKindPlusOne, Size: 5, Rank: low, Suit: -1}, true
+		case 1:
+			return HandValue{Type: FourOfAKindPlusOne, Size: 5, Rank: top.Rank, Suit: -1}, true
+		case 3:
+			return HandValue{Type: FullHouse, Size: 5, Rank: low, Suit: -1}, true
+		default:
+			return HandValue{Type: FullHouse, Size: 5, Rank: top.Rank, Suit: -1}, true
+		}
+	case 5: // Straight, flush or straight flush
+		flush := s.OfSuit(top.Suit) == s
+		straight, straightRank, straightSuit := false, Rank(-1), Suit(-1)
+		lowest := bits.TrailingZeros64(uint64(present))
+		const fiveHigh = 1<<0 | 1<<4 | 1<<8 | 1<<44 | 1<<48 // 3-4-5-A-2 plays as a five-high straight
+		if present == CardSet(0x11111)<<uint(lowest) {
+			straight, straightRank, straightSuit = true, top.Rank, top.Suit
+		} else if present == fiveHigh {
+			straight, straightRank, straightSuit = true, Rank5, s.OfRank(Rank5).highest().Suit
+		}
+		switch {
+		case straight && flush:
+			return HandValue{Type: StraightFlush, Size: 5, Rank: straightRank, Suit: straightSuit}, true
+		case flush:
+			return HandValue{Type: Flush, Size: 5, Rank: top.Rank, Suit: top.Suit}, true
+		case straight:
+			return HandValue{Type: Straight, Size: 5, Rank: straightRank, Suit: straightSuit}, true
+		}
+	}
+	return HandValue{Type: InvalidHand, Rank: -1, Suit: -1}, false
+}
+
+// LegalMoves enumerates every valid play from hand that beats lastPlayed (any valid play
+// if lastPlayed is nil), like GenerateMoves but on card sets. Rulesets other than Big Two,
+// and hands with jokers, go through GenerateMoves.
+func (re *BigTwoRuleEngine) LegalMoves(hand CardSet, lastPlayed *PlayedHand) []CardSet {
+	if re.Options.Mode != ModeBigTwo || hand.HasJokers() {
+		generated := re.GenerateMoves(hand.Deck(), lastPlayed)
+		moves := make([]CardSet, len(generated))
+		for i, m := range generated {
+			moves[i] = NewCardSet(m.Cards...)
+		}
+		return moves
+	}
+
+	var last HandValue
+	if lastPlayed != nil {
+		last = lastPlayed.Value()
+	}
+	var moves []CardSet
+	consider := func(play CardSet) {
+		value, ok := re.ClassifyCardSet(play)
+		if ok && (lastPlayed == nil || re.ValueBeats(value, last)) {
+			moves = append(moves, play)
+		}
+	}
+
+	var cards [numCardBits]CardSet
+	n := 0
+	for rest := hand; rest != 0; rest &= rest - 1 {
+		cards[n] = rest & -rest
+		n++
+	}
+	for _, size := range []int{1, 2, 3, 5} {
+		if lastPlayed != nil && !re.canFollowWithSize(size, lastPlayed) {
+			continue
+		}
+		if size < 5 {
+			// Singles, pairs and triples come from within one rank.
+			for r := Rank3; r <= Two; r++ {
+				ofRank := hand.OfRank(r)
+				for sub := ofRank; sub != 0; sub = (sub - 1) & ofRank {
+					if sub.Len() == size {
+						consider(sub)
+					}
+				}
+			}
+			continue
+		}
+		if n < size {
+			continue
+		}
+		// Gosper's hack: visit every n-bit index mask with exactly size bits set.
+		for combo := uint64(1)<<uint(size) - 1; combo < 1<<uint(n); {
+			var play CardSet
+			for idx := combo; idx != 0; idx &= idx - 1 {
+				play |= cards[bits.TrailingZeros64(idx)]
+			}
+			consider(play)
+			c := combo & -combo
+			r := combo + c
+			combo = (((r ^ combo) >> 2) / c) | r
+		}
+	}
+	return moves
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestCardSet_DeckRoundTrip(t *testing.T) {
+	deck := NewDeckWithJokers(2)
+	set := deck.CardSet()
+	if set.Len() != 54 {
+		t.Fatalf("Len() = %d, want 54", set.Len())
+	}
+	back := set.Deck()
+	sorted := append(Deck(nil), deck...)
+	sorted.Sort()
+	if back.String() != sorted.String() {
+		t.Errorf("Deck() = %s, want %s", back, sorted)
+	}
+	if CardBit(Card{Rank: Rank(2), Suit: Spades}) != 0 || CardBit(Card{Rank: Joker, Suit: Spades}) != 0 {
+		t.Error("CardBit accepted an invalid card")
+	}
+}
+
+func TestCardSet_Operations(t *testing.T) {
+	hand := NewCardSet(C(Rank3, Diamonds), C(Rank3, Spades), C(King, Hearts), C(Two, Spades))
+	played := NewCardSet(C(Rank3, Diamonds), C(Rank3, Spades))
+
+	if !hand.Contains(C(King, Hearts)) || hand.Contains(C(King, Spades)) {
+		t.Error("Contains() gave the wrong answer")
+	}
+	if !hand.ContainsAll(played) || played.ContainsAll(hand) {
+		t.Error("ContainsAll() gave the wrong answer")
+	}
+	if got := hand.Without(played).Deck().String(); got != "[KH, 2S]" {
+		t.Errorf("Without() = %s, want [KH, 2S]", got)
+	}
+	if got := hand.OfRank(Rank3); got != played {
+		t.Errorf("OfRank(3) = %s, want %s", got.Deck(), played.Deck())
+	}
+	if got := hand.OfSuit(Spades).Deck().String(); got != "[3S, 2S]" {
+		t.Errorf("OfSuit(Spades) = %s, want [3S, 2S]", got)
+	}
+	if got := played.Union(NewCardSet(C(Ace, Clubs))).Intersect(hand); got != played {
+		t.Errorf("Union/Intersect = %s, want %s", got.Deck(), played.Deck())
+	}
+}
+
+// TestClassifyCardSet_MatchesDeterminePlayedHand checks the bitmask classifier against the
+// Deck-based one for every 1, 2, 3 and 5-card combination of a 20-card slice of the deck.
+func TestClassifyCardSet_MatchesDeterminePlayedHand(t *testing.T) {
+	deck := NewDeck()
+	sample := append(Deck{}, deck[:7]...)       // 3-9 of Diamonds
+	sample = append(sample, deck[7:13]...)      // 10-2 of Diamonds
+	sample = append(sample, deck[13:15]...)     // 3-4 of Clubs
+	sample = append(sample, deck[24:26]...)     // A-2 of Clubs
+	sample = append(sample, deck[26], deck[39]) // 3 of Hearts, 3 of Spades
+	sample = append(sample, deck[37], deck[50]) // A of Hearts, A of Spades
+	sample.Sort()
+
+	for _, re := range []*BigTwoRuleEngine{NewBigTwoRuleEngine(), newPusoyDosEngine(t)} {
+		for _, size := range []int{1, 2, 3, 5} {
+			forEachCombination(sample, size, func(combo Deck) {
+				want, err := re.DeterminePlayedHand(combo)
+				got, ok := re.ClassifyCardSet(combo.CardSet())
+				if (err == nil) != ok {
+					t.Fatalf("%s: ClassifyCardSet ok = %v, DeterminePlayedHand err = %v", combo, ok, err)
+				}
+				if ok && got != want.Value() {
+					t.Fatalf("%s: ClassifyCardSet = %+v, want %+v", combo, got, want.Value())
+				}
+			})
+		}
+	}
+}
+
+func TestLegalMoves_MatchesGenerateMoves(t *testing.T) {
+	re := NewBigTwoRuleEngine()
+	hand := Deck{
+		C(Rank3, Diamonds), C(Rank4, Diamonds), C(Rank5, Diamonds), C(Rank6, Diamonds), C(Rank7, Diamonds),
+		C(Rank7, Clubs), C(Rank7, Hearts), C(Rank9, Spades), C(King, Hearts), C(King, Spades),
+		C(Ace, Clubs), C(Two, Hearts), C(Two, Spades),
+	}
+	lastPlays := []*PlayedHand{
+		nil,
+		mustHand(t, re, C(Rank9, Hearts)),
+		mustHand(t, re, C(Queen, Diamonds), C(Queen, Spades)),
+		mustHand(t, re, C(Rank4, Clubs), C(Rank5, Clubs), C(Rank6, Hearts), C(Rank7, Spades), C(Rank8, Diamonds)),
+	}
+	for _, last := range lastPlays {
+		var want []string
+		for _, m := range re.GenerateMoves(hand, last) {
+			want = append(want, Deck(m.Cards).String())
+		}
+		var got []string
+		for _, m := range re.LegalMoves(hand.CardSet(), last) {
+			got = append(got, m.Deck().String())
+		}
+		sort.Strings(want)
+		sort.Strings(got)
+		if len(got) != len(want) {
+			t.Fatalf("LegalMoves returned %d moves, GenerateMoves %d", len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("LegalMoves move %s, GenerateMoves move %s", got[i], want[i])
+			}
+		}
+	}
+}
+
+var benchFiveCards = Deck{C(Rank9, Hearts), C(Rank10, Hearts), C(Jack, Hearts), C(Queen, Hearts), C(King, Hearts)}
+
+var benchHand = Deck{
+	C(Rank3, Diamonds), C(Rank4, Diamonds), C(Rank5, Clubs), C(Rank6, Diamonds), C(Rank7, Diamonds),
+	C(Rank7, Clubs), C(Rank7, Hearts), C(Rank9, Spades), C(King, Hearts), C(King, Spades),
+	C(Ace, Clubs), C(Two, Hearts), C(Two, Spades),
+}
+
+func BenchmarkDeterminePlayedHand(b *testing.B) {
+	re := NewBigTwoRuleEngine()
+	for i := 0; i < b.N; i++ {
+		re.DeterminePlayedHand(benchFiveCards)
+	}
+}
+
+func BenchmarkClassifyCardSet(b *testing.B) {
+	re := NewBigTwoRuleEngine()
+	set := benchFiveCards.CardSet()
+	for i := 0; i < b.N; i++ {
+		re.ClassifyCardSet(set)
+	}
+}
+
+func BenchmarkGenerateMoves(b *testing.B) {
+	re := NewBigTwoRuleEngine()
+	for i := 0; i < b.N; i++ {
+		re.GenerateMoves(benchHand, nil)
+	}
+}
+
+func BenchmarkLegalMoves(b *testing.B) {
+	re := NewBigTwoRuleEngine()
+	set := benchHand.CardSet()
+	for i := 0; i < b.N; i++ {
+		re.LegalMoves(set, nil)
+	}
+}
+
+func BenchmarkDeckContains(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		containsCard(benchHand, C(Two, Spades))
+	}
+}
+
+func BenchmarkCardSetContains(b *testing.B) {
+	set := benchHand.CardSet()
+	for i := 0; i < b.N; i++ {
+		set.Contains(C(Two, Spades))
+	}
+}
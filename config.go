@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Config is the server's runtime configuration. Each setting comes, in increasing order of
+// precedence, from its default, the config file, a BIGTWO_* environment variable and a
+// command-line flag. The environment variable is named after the flag: -tls-cert is
+// BIGTWO_TLS_CERT. The config file is JSON, keyed by the json tags below, and is named
+// with -config or BIGTWO_CONFIG.
+type Config struct {
+	Addr           string     `json:"addr"`           // Address to listen on
+	TLSCert        string     `json:"tlsCert"`        // Certificate file; serves HTTPS when set, with TLSKey
+	TLSKey         string     `json:"tlsKey"`         // Private key file for TLSCert
+	StaticDir      string     `json:"staticDir"`      // Built client files served at /
+	DataDir        string     `json:"dataDir"`        // Accounts, match history and the admin audit log
+	Players        int        `json:"players"`        // Seats at the default table; 1 is a debug table
+	TargetScore    int        `json:"targetScore"`    // Penalty limit that ends a match
+	UndoTimeout    Duration   `json:"undoTimeout"`    // How long opponents have to accept an undo
+	VoteTimeout    Duration   `json:"voteTimeout"`    // How long a vote on the match stays open
+	SessionTTL     Duration   `json:"sessionTTL"`     // How long a login token stays valid
+	AllowedOrigins stringList `json:"allowedOrigins"` // Origins that may open a websocket; empty is this host only, "*" any
+	ReadBuffer     int        `json:"readBuffer"`     // Websocket read buffer size in bytes
+	WriteBuffer    int        `json:"writeBuffer"`    // Websocket write buffer size in bytes
+	MaxMessageSize int64      `json:"maxMessageSize"` // Largest websocket message accepted, in bytes
+	RateLimits     rateLimits `json:"rateLimits"`     // Per connection and message type; merged into the defaults
+	MaxViolations  int        `json:"maxViolations"`  // Refused messages a client may send per minute before it is disconnected
+	LogLevel       string     `json:"logLevel"`
+	LogFormat      string     `json:"logFormat"`
+
+	// TableConfig holds the rules of the default table. Its keys sit at the top level of
+	// the config file, like the others.
+	TableConfig
+}
+
+// DefaultConfig returns the configuration used when nothing is set.
+func DefaultConfig() *Config {
+	return &Config{
+		Addr:           ":8080",
+		StaticDir:      "./static",
+		DataDir:        "./data",
+		TableConfig:    DefaultTableConfig(),
+		Players:        2,
+		TargetScore:    100,
+		UndoTimeout:    Duration(30 * time.Second),
+		VoteTimeout:    Duration(60 * time.Second),
+		SessionTTL:     Duration(30 * 24 * time.Hour),
+		ReadBuffer:     1024,
+		WriteBuffer:    1024,
+		MaxMessageSize: 4096,
+		RateLimits:     defaultRateLimits(),
+		MaxViolations:  10,
+		LogLevel:       "info",
+		LogFormat:      "text",
+	}
+}
+
+// flagSet returns the command-line flags, bound to c's fields.
+func (c *Config) flagSet(configPath *string) *flag.FlagSet {
+	fs := flag.NewFlagSet("big-two", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // LoadConfig returns the error; main prints usage for -h
+	fs.StringVar(configPath, "config", "", "JSON config file")
+	fs.StringVar(&c.Addr, "addr", c.Addr, "address to listen on")
+	fs.StringVar(&c.TLSCert, "tls-cert", c.TLSCert, "TLS certificate file; serves HTTPS together with -tls-key")
+	fs.StringVar(&c.TLSKey, "tls-key", c.TLSKey, "TLS private key file")
+	fs.StringVar(&c.StaticDir, "static-dir", c.StaticDir, "directory of client files served at /")
+	fs.StringVar(&c.DataDir, "data-dir", c.DataDir, "directory for accounts, match history and the admin audit log")
+	c.TableConfig.bindFlags(fs)
+	fs.IntVar(&c.Players, "players", c.Players, "seats at the default table, 1 to 4; 1 is a debug table")
+	fs.IntVar(&c.TargetScore, "target-score", c.TargetScore, "penalty limit that ends a match")
+	fs.Var(&c.UndoTimeout, "undo-timeout", "how long opponents have to accept an undo request, as a `duration` such as 30s")
+	fs.Var(&c.VoteTimeout, "vote-timeout", "how long a vote on the match stays open, as a `duration`")
+	fs.Var(&c.SessionTTL, "session-ttl", "how long a login token stays valid, as a `duration`")
+	fs.Var(&c.AllowedOrigins, "allowed-origins", "comma-separated `origins` that may open a websocket, e.g. https://example.com; empty allows this host only, * any (the Vite dev server needs http://localhost:5173)")
+	fs.IntVar(&c.ReadBuffer, "read-buffer", c.ReadBuffer, "websocket read buffer size in bytes")
+	fs.IntVar(&c.WriteBuffer, "write-buffer", c.WriteBuffer, "websocket write buffer size in bytes")
+	fs.Int64Var(&c.MaxMessageSize, "max-message-size", c.MaxMessageSize, "largest websocket message accepted, in bytes; larger ones close the connection")
+	fs.Var(&c.RateLimits, "rate-limits", "comma-separated per-connection `limits` as type=perSecond/burst, e.g. chat=1/5; * is every other type")
+	fs.IntVar(&c.MaxViolations, "max-violations", c.MaxViolations, "refused messages a client may send per minute before it is disconnected")
+	fs.StringVar(&c.LogLevel, "log-level", c.LogLevel, "debug, info, warn or error")
+	fs.StringVar(&c.LogFormat, "log-format", c.LogFormat, "text or json")
+	return fs
+}
+
+// envName returns the environment variable for the flag name.
+func envName(flagName string) string {
+	return "BIGTWO_" + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// LoadConfig builds the configuration from args (without the program name), the environment
+// as seen through getenv, and the config file they name, then validates it.
+func LoadConfig(args []string, getenv func(string) string) (*Config, error) {
+	// The flags are parsed twice: first to find the config file, then, once the file and the
+	// environment are applied, again so that they take precedence.
+	var path string
+	if err := DefaultConfig().flagSet(&path).Parse(args); err != nil {
+		return nil, err
+	}
+	if path == "" {
+		path = getenv(envName("config"))
+	}
+
+	cfg := DefaultConfig()
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	fs := cfg.flagSet(&path)
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if v := getenv(envName(f.Name)); v != "" && f.Name != "config" && err == nil {
+			if setErr := fs.Set(f.Name, v); setErr != nil {
+				err = fmt.Errorf("%s=%q: %v", envName(f.Name), v, setErr)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile applies the settings in the JSON file at path. Unknown keys are an error, so
+// that a misspelt setting is not silently ignored.
+func (c *Config) loadFile(path string) error {
+	if ext := strings.ToLower(filepath.Ext(path)); ext != ".json" {
+		return fmt.Errorf("config file %s: unsupported format %q, want .json", path, ext)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	defer f.Close()
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate checks every setting, returning all problems found together.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if _, _, err := net.SplitHostPort(c.Addr); err != nil {
+		fail("addr %q: %v", c.Addr, err)
+	}
+	switch {
+	case (c.TLSCert == "") != (c.TLSKey == ""):
+		fail("tls-cert and tls-key must be set together")
+	case c.TLSCert != "":
+		for _, file := range []string{c.TLSCert, c.TLSKey} {
+			if _, err := os.Stat(file); err != nil {
+				fail("tls: %v", err)
+			}
+		}
+	}
+	if info, err := os.Stat(c.StaticDir); err == nil && !info.IsDir() {
+		fail("static-dir %s is not a directory", c.StaticDir)
+	}
+	if c.DataDir == "" {
+		fail("data-dir must be set")
+	}
+	if _, err := c.TableConfig.Settings(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Players < 1 || c.Players > 4 {
+		fail("players must be between 1 and 4, not %d", c.Players)
+	}
+	if c.TeamMode && c.Players != 4 {
+		fail("team-mode needs 4 players, not %d", c.Players)
+	}
+	if c.TargetScore <= 0 {
+		fail("target-score must be positive, not %d", c.TargetScore)
+	}
+	if c.UndoTimeout <= 0 {
+		fail("undo-timeout must be positive, not %s", c.UndoTimeout)
+	}
+	if c.VoteTimeout <= 0 {
+		fail("vote-timeout must be positive, not %s", c.VoteTimeout)
+	}
+	if c.SessionTTL <= 0 {
+		fail("session-ttl must be positive, not %s", c.SessionTTL)
+	}
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" {
+			fail("allowed-origins: %q is not an origin such as https://example.com", origin)
+		}
+	}
+	if c.ReadBuffer <= 0 || c.WriteBuffer <= 0 {
+		fail("read-buffer and write-buffer must be positive")
+	}
+	if c.MaxMessageSize < 512 {
+		fail("max-message-size must be at least 512 bytes, not %d", c.MaxMessageSize)
+	}
+	if err := c.RateLimits.validate(); err != nil {
+		fail("rate-limits: %v", err)
+	}
+	if _, ok := c.RateLimits["*"]; !ok {
+		fail("rate-limits: a default limit for * is required")
+	}
+	if c.MaxViolations < 1 {
+		fail("max-violations must be at least 1, not %d", c.MaxViolations)
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		fail("log-level %q: want debug, info, warn or error", c.LogLevel)
+	}
+	if f := strings.ToLower(c.LogFormat); f != "text" && f != "json" {
+		fail("log-format %q: want text or json", c.LogFormat)
+	}
+	return errors.Join(errs...)
+}
+
+// Duration is a time.Duration written as a string such as "30s", both in the config file
+// and on the command line.
+type Duration time.Duration
+
+func (d Duration) String() string { return time.Duration(d).String() }
+
+func (d *Duration) Set(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\"")
+	}
+	return d.Set(s)
+}
+
+// stringList is a list of strings, written comma-separated on the command line.
+type stringList []string
+
+func (l stringList) String() string { return strings.Join(l, ",") }
+
+func (l *stringList) Set(s string) error {
+	*l = nil
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfigFile writes content to a file named name in a temporary directory.
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig_Precedence(t *testing.T) {
+	file := writeConfigFile(t, "bigtwo.json", `{
+		"addr": ":9000",
+		"players": 4,
+		"ruleset": "tienLen",
+		"voteTimeout": "2m",
+		"allowedOrigins": ["https://cards.example.com"]
+	}`)
+	env := map[string]string{
+		"BIGTWO_CONFIG":       file,
+		"BIGTWO_ADDR":         ":9100",
+		"BIGTWO_TEAM_MODE":    "true",
+		"BIGTWO_TARGET_SCORE": "50",
+		"BIGTWO_SCORING":      "chop",
+		"BIGTWO_END_MODE":     "roundWins",
+		"BIGTWO_ROUND_WINS":   "3",
+	}
+	cfg, err := LoadConfig([]string{"-addr", "127.0.0.1:9200", "-undo-timeout", "45s", "-single-card-left", "reject", "-team-round-ends-when-team-out=false"}, func(k string) string { return env[k] })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		setting   string
+		got, want interface{}
+	}{
+		{"addr (flag over env over file)", cfg.Addr, "127.0.0.1:9200"},
+		{"players (file)", cfg.Players, 4},
+		{"ruleset (file)", cfg.Ruleset, RulesTienLen},
+		{"vote timeout (file)", time.Duration(cfg.VoteTimeout), 2 * time.Minute},
+		{"undo timeout (flag)", time.Duration(cfg.UndoTimeout), 45 * time.Second},
+		{"team mode (env)", cfg.TeamMode, true},
+		{"team round ends when team out (flag)", cfg.TeamRoundEndsWhenTeamOut, false},
+		{"target score (env)", cfg.TargetScore, 50},
+		{"scoring (env)", cfg.Scoring, ScoringChop},
+		{"end mode (env)", cfg.EndMode, "roundWins"},
+		{"round wins (env)", cfg.RoundWinsToWin, 3},
+		{"single card left (flag)", cfg.SingleCardLeft, "reject"},
+		{"tie-breakers (default)", cfg.TieBreakers.String(), "mostRoundWins,bestLastRound,fewestBigLosses"},
+		{"allowed origins (file)", cfg.AllowedOrigins.String(), "https://cards.example.com"},
+		{"static dir (default)", cfg.StaticDir, "./static"},
+		{"read buffer (default)", cfg.ReadBuffer, 1024},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.setting, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	badKey := writeConfigFile(t, "bigtwo.json", `{"adress": ":9000"}`)
+	yaml := writeConfigFile(t, "bigtwo.yaml", "addr: :9000\n")
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		wantErr []string
+	}{
+		{"unknown flag", []string{"-port", "80"}, nil, []string{"-port"}},
+		{"stray argument", []string{"serve"}, nil, []string{"unexpected arguments: serve"}},
+		{"unknown file key", []string{"-config", badKey}, nil, []string{`unknown field "adress"`}},
+		{"unsupported file format", []string{"-config", yaml}, nil, []string{`unsupported format ".yaml"`}},
+		{"missing file", []string{"-config", "/nonexistent/bigtwo.json"}, nil, []string{"config file"}},
+		{"bad env value", nil, map[string]string{"BIGTWO_PLAYERS": "four"}, []string{`BIGTWO_PLAYERS="four"`}},
+		{"bad duration", []string{"-vote-timeout", "soon"}, nil, []string{"vote-timeout"}},
+		{"all problems reported", []string{
+			"-addr", "8080", "-players", "3", "-team-mode", "-ruleset", "president", "-scoring", "golf",
+			"-tls-cert", "cert.pem", "-allowed-origins", "cards.example.com", "-target-score", "0",
+		}, nil, []string{
+			"addr", "team-mode needs 4 players", "unknown ruleset", "unknown scoring scheme", "tls-cert and tls-key",
+			`"cards.example.com" is not an origin`, "target-score must be positive",
+		}},
+		{"missing TLS files", []string{"-tls-cert", "/nonexistent/cert.pem", "-tls-key", "/nonexistent/key.pem"}, nil, []string{"cert.pem", "key.pem"}},
+		{"websocket limits", []string{"-max-message-size", "100", "-rate-limits", "teleport=1/1", "-max-violations", "0"}, nil, []string{
+			"max-message-size", `unknown message type "teleport"`, "max-violations",
+		}},
+		{"match end", []string{"-end-mode", "timeLimit", "-tie-breakers", "mostRoundWins,coinToss"}, nil, []string{
+			"timeLimit matches need a positive timeLimit", `unknown tie-breaker "coinToss"`,
+		}},
+		{"unknown end mode", []string{"-end-mode", "suddenDeath"}, nil, []string{"endMode", "suddenDeath"}},
+		{"single card left", []string{"-single-card-left", "warn"}, nil, []string{`unknown single-card-left mode "warn"`}},
+		{"single card left penalty", []string{"-single-card-left", "penalty", "-single-card-left-penalty", "0"}, nil, []string{"singleCardLeftPenalty"}},
+		{"card exchange", []string{"-card-exchange", "4"}, nil, []string{"cardExchangeCount must be between 0 and 3"}},
+		{"instant wins", []string{"-instant-wins", "dragon,royalFlush", "-instant-win-points", "-1"}, nil, []string{
+			`unknown instant win "royalFlush"`, "instantWinPoints must not be negative",
+		}},
+		{"jokers", []string{"-jokers", "3"}, nil, []string{"jokers must be between 0 and 2"}},
+		{"negative big loss", []string{"-big-loss-points", "-5"}, nil, []string{"bigLossPoints must not be negative"}},
+		{"log settings", []string{"-log-level", "loud", "-log-format", "xml"}, nil, []string{"log-level", "log-format"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(tt.args, func(k string) string { return tt.env[k] })
+			if err == nil {
+				t.Fatal("LoadConfig succeeded, want an error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigureUpgrader_AllowedOrigins(t *testing.T) {
+	saved := upgrader
+	t.Cleanup(func() { upgrader = saved })
+	cfg := DefaultConfig()
+	cfg.AllowedOrigins = stringList{"https://cards.example.com"}
+	configureUpgrader(cfg)
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://cards.example.com", true},
+		{"HTTPS://Cards.Example.com", true},
+		{"", true}, // Not a browser
+		{"https://evil.example.com", false},
+		{"http://cards.example.com", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/ws", nil)
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		if got := upgrader.CheckOrigin(r); got != tt.want {
+			t.Errorf("origin %q allowed = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+}
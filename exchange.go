@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ExchangePhase is the step a card exchange is waiting on.
+type ExchangePhase string
+
+const (
+	ExchangeGive     ExchangePhase = "give"     // Loser must hand over their highest cards
+	ExchangeReturn   ExchangePhase = "return"   // Winner must return cards of their choice
+	ExchangeComplete ExchangePhase = "complete" // Both sides have exchanged
+)
+
+// CardExchange is the swap between the previous round's biggest loser and its winner
+// that happens after the deal and before the first play.
+type CardExchange struct {
+	Round         int           `json:"round"`
+	LoserID       string        `json:"loserId"`
+	WinnerID      string        `json:"winnerId"`
+	Count         int           `json:"count"`
+	Phase         ExchangePhase `json:"phase"`
+	GivenCards    Deck          `json:"-"` // Loser's highest cards, sent to the winner
+	ReturnedCards Deck          `json:"-"` // Winner's chosen cards, sent to the loser
+}
+
+// AwaitingPlayerID returns the player who must act next in the exchange, or "" once complete.
+func (ex *CardExchange) AwaitingPlayerID() string {
+	switch ex.Phase {
+	case ExchangeGive:
+		return ex.LoserID
+	case ExchangeReturn:
+		return ex.WinnerID
+	}
+	return ""
+}
+
+// exchangeInProgress reports whether play is blocked on a pending card exchange.
+func (g *GameState) exchangeInProgress() bool {
+	return g.Exchange != nil && g.Exchange.Phase != ExchangeComplete
+}
+
+// maxCardExchangeCount is the most cards a table may exchange after the deal.
+const maxCardExchangeCount = 3
+
+// completedExchange returns the exchange made this round, or nil if there was none.
+func (g *GameState) completedExchange() *CardExchange {
+	if g.Exchange == nil || g.Exchange.Phase != ExchangeComplete || g.Exchange.Round != g.RoundNumber {
+		return nil
+	}
+	return g.Exchange
+}
+
+// startCardExchange opens the exchange phase for a freshly dealt round, if the table's
+// rules call for one. The loser is the player with the highest score in the previous
+// round (ties go to the first in seat order), excluding the winner's partner in team mode.
+func startCardExchange(game *GameState, previousWinnerID string) {
+	game.Exchange = nil
+	count := game.RuleEngine.Options.CardExchangeCount
+	if count <= 0 || previousWinnerID == "" || len(game.RoundScoresHistory) == 0 {
+		return
+	}
+	winner := game.playerByID(previousWinnerID)
+	if winner == nil || winner.IsEliminated {
+		return
+	}
+	lastRound := game.RoundScoresHistory[len(game.RoundScoresHistory)-1]
+	var loser *Player
+	for _, p := range game.Players {
+		if p == winner || p.IsEliminated || game.areTeammates(p.ID, winner.ID) || len(p.Hand) < count {
+			continue
+		}
+		if loser == nil || lastRound[p.ID] > lastRound[loser.ID] {
+			loser = p
+		}
+	}
+	if loser == nil || lastRound[loser.ID] == 0 {
+		return
+	}
+	game.Exchange = &CardExchange{
+		Round:    game.RoundNumber,
+		LoserID:  loser.ID,
+		WinnerID: winner.ID,
+		Count:    count,
+		Phase:    ExchangeGive,
+	}
+	gameLog(game).Info("card exchange", "loser", loser.ID, "winner", winner.ID, "count", count)
+}
+
+// highestCards returns the n highest cards of the hand in the given card order.
+func highestCards(hand Deck, n int, cardLess func(a, b Card) bool) Deck {
+	sorted := append(Deck(nil), hand...)
+	sort.Slice(sorted, func(i, j int) bool { return cardLess(sorted[i], sorted[j]) })
+	if n > len(sorted) {
+		n = len(sorted)
+	}
+	return sorted[len(sorted)-n:]
+}
+
+// sameCards reports whether a and b hold the same cards, ignoring order.
+func sameCards(a, b Deck) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[Card]int, len(a))
+	for _, c := range a {
+		counts[c]++
+	}
+	for _, c := range b {
+		if counts[c] == 0 {
+			return false
+		}
+		counts[c]--
+	}
+	return true
+}
+
+// ApplyExchangeCards validates and applies one side of the pending exchange for the
+// given player. When the exchange completes the opening player is recomputed, since the
+// lowest card may have changed hands. finishRound records it with the round's scores.
+func ApplyExchangeCards(game *GameState, player *Player, cards Deck) error {
+	ex := game.Exchange
+	if ex == nil || ex.Phase == ExchangeComplete {
+		return errors.New("there is no card exchange in progress")
+	}
+	if player.ID != ex.AwaitingPlayerID() {
+		return fmt.Errorf("waiting for %s to exchange cards", game.playerByID(ex.AwaitingPlayerID()).Name)
+	}
+	if len(cards) != ex.Count {
+		return fmt.Errorf("you must exchange exactly %d card(s)", ex.Count)
+	}
+
+	switch ex.Phase {
+	case ExchangeGive:
+		if !sameCards(cards, highestCards(player.Hand, ex.Count, game.RuleEngine.CardLess)) {
+			return fmt.Errorf("you must give your %d highest card(s)", ex.Count)
+		}
+		if !player.RemoveCards(cards) {
+			return errors.New("you do not possess all the cards you are trying to give")
+		}
+		winner := game.playerByID(ex.WinnerID)
+		winner.Hand = append(winner.Hand, cards...)
+		winner.Hand.Sort()
+		ex.GivenCards = cards
+		ex.Phase = ExchangeReturn
+
+	case ExchangeReturn:
+		if !player.RemoveCards(cards) {
+			return errors.New("you do not possess all the cards you are trying to return")
+		}
+		loser := game.playerByID(ex.LoserID)
+		loser.Hand = append(loser.Hand, cards...)
+		loser.Hand.Sort()
+		ex.ReturnedCards = cards
+		ex.Phase = ExchangeComplete
+		setOpeningPlayer(game)
+	}
+	return nil
+}
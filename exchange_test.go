@@ -0,0 +1,147 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// exchangeTestGame builds a three-player round after player2 won the previous round
+// and player3 lost the most, with a two-card exchange.
+func exchangeTestGame() *GameState {
+	players := []*Player{NewPlayer(1, "P1"), NewPlayer(2, "P2"), NewPlayer(3, "P3")}
+	players[0].Hand = Deck{C(Rank4, Clubs), C(Rank9, Hearts), C(King, Spades)}
+	players[1].Hand = Deck{C(Rank3, Diamonds), C(Rank5, Clubs), C(Rank6, Hearts)}
+	players[2].Hand = Deck{C(Rank7, Diamonds), C(Ace, Clubs), C(Two, Diamonds), C(Two, Spades)}
+	opts := DefaultRuleOptions()
+	opts.CardExchangeCount = 2
+	game := &GameState{
+		Players:            players,
+		RuleEngine:         NewBigTwoRuleEngineWithOptions(opts),
+		RoundNumber:        2,
+		RoundScoresHistory: []map[string]int{{"player1": 5, "player2": 0, "player3": 9}},
+		RoundExchanges:     []*CardExchange{nil},
+	}
+	startCardExchange(game, "player2")
+	return game
+}
+
+func TestStartCardExchange(t *testing.T) {
+	game := exchangeTestGame()
+	ex := game.Exchange
+	if ex == nil {
+		t.Fatal("expected an exchange to start")
+	}
+	if ex.LoserID != "player3" || ex.WinnerID != "player2" || ex.Count != 2 || ex.Phase != ExchangeGive {
+		t.Errorf("got exchange %+v; want player3 -> player2, 2 cards, give phase", ex)
+	}
+
+	game.RuleEngine.Options.CardExchangeCount = 0
+	startCardExchange(game, "player2")
+	if game.Exchange != nil {
+		t.Error("exchange started with CardExchangeCount 0")
+	}
+}
+
+func TestApplyExchangeCards(t *testing.T) {
+	game := exchangeTestGame()
+	winner, loser := game.Players[1], game.Players[2]
+
+	if err := ApplyExchangeCards(game, winner, Deck{C(Rank5, Clubs), C(Rank6, Hearts)}); err == nil {
+		t.Error("winner was allowed to act during the give phase")
+	}
+	if err := ApplyExchangeCards(game, loser, Deck{C(Rank7, Diamonds), C(Two, Spades)}); err == nil {
+		t.Error("loser was allowed to give cards that are not their highest")
+	}
+	if err := ApplyExchangeCards(game, loser, Deck{C(Two, Spades), C(Two, Diamonds)}); err != nil {
+		t.Fatalf("give: %v", err)
+	}
+	if game.Exchange.Phase != ExchangeReturn || len(winner.Hand) != 5 || len(loser.Hand) != 2 {
+		t.Fatalf("after give: phase %s, winner %s, loser %s", game.Exchange.Phase, winner.Hand, loser.Hand)
+	}
+	if !game.exchangeInProgress() {
+		t.Error("exchange should still be in progress before the winner returns cards")
+	}
+
+	if err := ApplyExchangeCards(game, winner, Deck{C(Rank3, Diamonds)}); err == nil {
+		t.Error("winner was allowed to return the wrong number of cards")
+	}
+	// Returning the 3 of Diamonds moves the opening lead to the loser.
+	if err := ApplyExchangeCards(game, winner, Deck{C(Rank3, Diamonds), C(Rank5, Clubs)}); err != nil {
+		t.Fatalf("return: %v", err)
+	}
+	if game.exchangeInProgress() || game.completedExchange() == nil {
+		t.Fatalf("exchange not complete: %+v", game.Exchange)
+	}
+	if !sameCards(game.Exchange.GivenCards, Deck{C(Two, Diamonds), C(Two, Spades)}) {
+		t.Errorf("given cards = %s", game.Exchange.GivenCards)
+	}
+	if game.CurrentTurnPlayerIndex != 2 || game.OpeningCard == nil || *game.OpeningCard != C(Rank3, Diamonds) {
+		t.Errorf("opening player = %d, card %v; want player3 with 3D", game.CurrentTurnPlayerIndex, game.OpeningCard)
+	}
+}
+
+func TestFinishRound_RecordsExchange(t *testing.T) {
+	game := exchangeTestGame()
+	game.Scoring, game.MatchRules, game.TargetScore = StandardScoring{}, DefaultMatchRules(), 100
+	game.Scores = map[string]int{"player1": 5, "player2": 0, "player3": 9}
+	winner, loser := game.Players[1], game.Players[2]
+	ApplyExchangeCards(game, loser, Deck{C(Two, Spades), C(Two, Diamonds)})
+	ApplyExchangeCards(game, winner, Deck{C(Rank3, Diamonds), C(Rank5, Clubs)})
+
+	store, err := OpenAccountStore(filepath.Join(t.TempDir(), "accounts.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range game.Players {
+		account, _, err := store.Register(p.ID, "secret1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.AccountID = account.ID
+	}
+	game.Players[0].Hand = nil
+	now := time.Now()
+	finishRound(game, game.Players[0], now)
+	recordRoundResults(store, game, now)
+
+	if len(game.RoundExchanges) != 2 || game.RoundExchanges[0] != nil || game.RoundExchanges[1] != game.Exchange {
+		t.Fatalf("RoundExchanges = %v, want the exchange at round 2 only", game.RoundExchanges)
+	}
+	tests := []struct {
+		player   *Player
+		role     string
+		received Deck
+	}{
+		{game.Players[0], "", nil},
+		{winner, "winner", Deck{C(Two, Diamonds), C(Two, Spades)}},
+		{loser, "loser", Deck{C(Rank3, Diamonds), C(Rank5, Clubs)}},
+	}
+	for _, tt := range tests {
+		rounds := store.data.Accounts[tt.player.AccountID].Rounds
+		if len(rounds) != 1 {
+			t.Fatalf("%s has %d round records, want 1", tt.player.ID, len(rounds))
+		}
+		got := rounds[0].Exchange
+		if tt.role == "" {
+			if got != nil {
+				t.Errorf("%s recorded exchange %+v, want none", tt.player.ID, got)
+			}
+			continue
+		}
+		if got == nil || got.Role != tt.role || !sameCards(got.Received, tt.received) {
+			t.Errorf("%s recorded exchange %+v, want %s receiving %s", tt.player.ID, got, tt.role, tt.received)
+		}
+	}
+}
+
+func TestDeal_HandsDoNotShareTheDeck(t *testing.T) {
+	deck := NewDeck()
+	winner, _ := deck.Deal(13)
+	next, _ := deck.Deal(13)
+	before := next.String()
+	winner = append(winner, C(Two, Spades)) // As when the loser's cards are handed over
+	if next.String() != before {
+		t.Errorf("appending to one hand changed the next: %s, was %s", next, before)
+	}
+}
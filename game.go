@@ -1,5 +1,7 @@
 package main
 
+import "time"
+
 // HandType represents the type of a 5-card poker hand or other valid Big 2 play.
 type HandType int
 
@@ -13,6 +15,11 @@ const (
 	FullHouse          // 5 cards
 	FourOfAKindPlusOne // 5 cards (Bomb)
 	StraightFlush      // 5 cards
+
+	// Tiến Lên combinations
+	Sequence     // 3+ consecutive singles, no 2s
+	PairSequence // 3+ consecutive pairs, no 2s
+	FourOfAKind  // 4 cards
 )
 
 // String returns a string representation of the hand type.
@@ -20,6 +27,7 @@ func (ht HandType) String() string {
 	return []string{
 		"Invalid", "Single", "Pair", "Triple", "Straight", "Flush",
 		"Full House", "Four of a Kind", "Straight Flush",
+		"Sequence", "Pair Sequence", "Four of a Kind",
 	}[ht]
 }
 
@@ -32,25 +40,114 @@ type PlayedHand struct {
 	Rank           Rank     `json:"rank"`                     // Good.
 	EffectiveRank  Rank     // For straights/flushes, the rank of the highest card. For pairs/triples/quads, the rank of the set. For full house, rank of the triple.
 	EffectiveSuit  Suit     // For tie-breaking pairs or highest card in flushes/straights.
+	ResolvedCards  []Card   `json:"resolvedCards,omitempty"` // The hand as interpreted when jokers were played
+}
+
+// IsBomb reports whether the hand is a bomb (four of a kind or straight flush).
+func (ph *PlayedHand) IsBomb() bool {
+	return ph.Value().IsBomb()
+}
+
+// HandValue is what hands are compared on: type, number of cards, and effective rank and suit.
+type HandValue struct {
+	Type HandType
+	Size int
+	Rank Rank
+	Suit Suit
+}
+
+// Value returns the hand's comparison value.
+func (ph *PlayedHand) Value() HandValue {
+	return HandValue{Type: ph.HandType, Size: len(ph.Cards), Rank: ph.EffectiveRank, Suit: ph.EffectiveSuit}
+}
+
+// IsBomb reports whether the hand is a bomb (four of a kind or straight flush).
+func (v HandValue) IsBomb() bool {
+	return v.Type == FourOfAKindPlusOne || v.Type == StraightFlush
 }
 
 // GameState represents the overall state of the Big Two game.
 type GameState struct {
+	ID                     string            `json:"tableId"`
 	Players                []*Player         `json:"players"`
 	CurrentTurnPlayerIndex int               `json:"currentPlayerIndex"`
 	LastPlayedHand         *PlayedHand       `json:"lastPlayedHand"` // Pointer to allow nil
 	RuleEngine             *BigTwoRuleEngine `json:"-"`              // Not serialized directly
+	Scoring                ScoringStrategy   `json:"-"`              // Per-table scoring scheme; standard if nil
 	PassCount              int               `json:"passCount"`
 	IsGameOver             bool              `json:"isGameOver"`         // True if the current ROUND is over
 	WinnerID               string            `json:"winnerId,omitempty"` // Winner of the current ROUND
 	Scores                 map[string]int    `json:"scores,omitempty"`   // Overall accumulated scores for the MATCH
 
 	// New fields for multi-round/match play
-	RoundNumber        int              `json:"roundNumber"`
-	TargetScore        int              `json:"targetScore"` // Max penalty points before match ends
-	IsMatchOver        bool             `json:"isMatchOver"`
-	OverallWinnerID    string           `json:"overallWinnerId,omitempty"`
-	RoundScoresHistory []map[string]int `json:"roundScoresHistory,omitempty"` // History of scores for each round
+	RoundNumber     int    `json:"roundNumber"`
+	TargetScore     int    `json:"targetScore"` // Max penalty points before match ends
+	IsMatchOver     bool   `json:"isMatchOver"`
+	OverallWinnerID string `json:"overallWinnerId,omitempty"`
+	// OverallWinningTeamID is the winning team in team mode
+	OverallWinningTeamID string           `json:"overallWinningTeamId,omitempty"`
+	RoundScoresHistory   []map[string]int `json:"roundScoresHistory,omitempty"` // History of scores for each round
+
+	// Match end conditions and the per-match state they depend on.
+	MatchRules     MatchRules     `json:"-"`
+	MatchStartedAt time.Time      `json:"matchStartedAt"`
+	roundStartedAt time.Time      // For the round duration metric
+	RoundWins      map[string]int `json:"roundWins,omitempty"` // Rounds won by each player this match
+
+	// OpeningCard is the lowest card dealt this round, which must be part of the first play.
+	// It is cleared once the opening play has been made.
+	OpeningCard *Card `json:"openingCard,omitempty"`
+
+	// Penalties holds house-rule penalty points incurred this round, added to the round scores.
+	Penalties map[string]int `json:"penalties,omitempty"`
+
+	// InstantWin is set when the current round was won on the deal.
+	InstantWin *InstantWin `json:"instantWin,omitempty"`
+
+	// Exchange is the card exchange for the current round, if one is in use.
+	// RoundExchanges holds the completed exchange of each round in RoundScoresHistory, at
+	// the same index, or nil for rounds without one.
+	Exchange       *CardExchange   `json:"exchange,omitempty"`
+	RoundExchanges []*CardExchange `json:"-"`
+
+	// Team (2v2) mode. Teams is empty for individual play.
+	Teams                    []Team   `json:"teams,omitempty"`
+	TeamRoundEndsWhenTeamOut bool     `json:"teamRoundEndsWhenTeamOut"`
+	FinishOrder              []string `json:"finishOrder,omitempty"` // Players who have gone out this round, in order
+
+	// Tournament tables record their tournament and rotate seats between rounds.
+	TournamentID string    `json:"tournamentId,omitempty"`
+	RotateSeats  bool      `json:"-"`
+	baseSeating  []*Player // Original seat order that rotations are applied to
+
+	// RoundPlays counts the hands each player has played this round, by type, for statistics.
+	RoundPlays map[string]map[HandType]int `json:"-"`
+	// dealtHandSizes holds how many cards each player was dealt this round, jokers included.
+	dealtHandSizes map[string]int
+
+	// QueuedActions holds each player's pre-selected action, keyed by player ID. Private to the player.
+	QueuedActions map[string]*QueuedAction `json:"-"`
+
+	// Paused holds the table: no game actions are accepted and its clocks stop meanwhile.
+	// PausedBy is the player who paused it, empty if an administrator did.
+	Paused   bool      `json:"paused"`
+	PausedBy string    `json:"pausedBy,omitempty"`
+	pausedAt time.Time // When the pause began
+
+	// Administrator interventions this match. kicked holds the seat identities removed from
+	// the table (see seatIdentity and hostIdentity), which are refused a seat until the match ends. A match in
+	// which an administrator ended a round is not rated.
+	kicked      map[string]bool
+	forcedRound bool
+
+	// Vote is the open vote to restart or abandon the match, if any.
+	// MatchAbandoned is set when a vote ended the match without a result.
+	Vote           *MatchVote `json:"vote,omitempty"`
+	MatchAbandoned bool       `json:"matchAbandoned"`
+
+	// UndoRequest is a pending request to take back the last action, which lastAction can restore.
+	UndoRequest *UndoRequest  `json:"undoRequest,omitempty"`
+	lastAction  *turnSnapshot // Nil when there is nothing to take back
 }
 
 // --- Game Initialization & Helper Functions ---
@@ -68,6 +165,38 @@ func FindPlayerWith3D(players []*Player) int {
 	return 0 // Fallback, though in a real game 3D must exist.
 }
 
+// FindPlayerWithLowestCard finds the player holding the lowest card dealt.
+// With a full deal this is the 3 of Diamonds, but in 3-player games the 3 of Diamonds
+// may be left undealt. Returns the player index, the card, and false if no cards were dealt.
+func FindPlayerWithLowestCard(players []*Player) (int, Card, bool) {
+	return findPlayerWithLowestCard(players, cardLess)
+}
+
+// findPlayerWithLowestCard is FindPlayerWithLowestCard with a ruleset's card order.
+func findPlayerWithLowestCard(players []*Player, cardLess func(a, b Card) bool) (int, Card, bool) {
+	found := false
+	lowestIndex := 0
+	var lowest Card
+	for i, p := range players {
+		for _, card := range p.Hand {
+			if !found || cardLess(card, lowest) {
+				lowest = card
+				lowestIndex = i
+				found = true
+			}
+		}
+	}
+	return lowestIndex, lowest, found
+}
+
+// cardLess reports whether a ranks below b in Big 2 order (rank first, then suit).
+func cardLess(a, b Card) bool {
+	if a.Rank == b.Rank {
+		return a.Suit < b.Suit
+	}
+	return a.Rank < b.Rank
+}
+
 // Helper function to check if a deck contains a specific card.
 func containsCard(deck Deck, target Card) bool {
 	for _, c := range deck {
@@ -78,5 +207,32 @@ func containsCard(deck Deck, target Card) bool {
 	return false
 }
 
-// NewGameState initializes a new game state.
-// ... existing code ...
+// NewGameState initializes a new table with the standard rules and deals the first round
+// of a new match. Callers may adjust the rules, scoring and match rules before play starts.
+func NewGameState(tableID string, players []*Player, targetScore int) *GameState {
+	return NewGameStateWithSettings(tableID, players, targetScore, DefaultTableSettings())
+}
+
+// NewGameStateWithSettings is NewGameState for a table played under settings. The first
+// round is dealt under them, so the deck and opening card follow the ruleset.
+func NewGameStateWithSettings(tableID string, players []*Player, targetScore int, settings TableSettings) *GameState {
+	for i, p := range players {
+		p.OrderInTurn = i // Assign turn order index explicitly
+	}
+	game := &GameState{
+		ID:          tableID,
+		Players:     players,
+		RuleEngine:  NewBigTwoRuleEngineWithOptions(settings.Rules),
+		Scoring:     settings.Scoring,
+		MatchRules:  settings.MatchRules,
+		TargetScore: targetScore, // Penalty limit
+	}
+	if settings.TeamMode {
+		// Before the deal, so that an instant win on it is scored by team.
+		if err := SetupPartnerships(game, settings.TeamRoundEndsWhenTeamOut); err != nil {
+			gameLog(game).Error("team mode not enabled", "err", err)
+		}
+	}
+	resetMatchState(game)
+	return game
+}
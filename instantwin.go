@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// InstantWinKind names a special deal that wins the round before any card is played.
+type InstantWinKind string
+
+const (
+	InstantWinDragon    InstantWinKind = "dragon"    // One of every rank, 3 through 2
+	InstantWinFourTwos  InstantWinKind = "fourTwos"  // All four 2s
+	InstantWinSixPairs  InstantWinKind = "sixPairs"  // Six pairs (a four of a kind counts as two)
+	InstantWinOneColour InstantWinKind = "oneColour" // Every card red, or every card black
+)
+
+// instantWinPriority orders the kinds from strongest to weakest; when several players
+// are dealt an instant win, the strongest kind wins, then seat order.
+var instantWinPriority = []InstantWinKind{InstantWinDragon, InstantWinFourTwos, InstantWinSixPairs, InstantWinOneColour}
+
+// ParseInstantWinKinds returns the instant-win kinds with the given names.
+func ParseInstantWinKinds(names []string) ([]InstantWinKind, error) {
+	kinds := make([]InstantWinKind, 0, len(names))
+	for _, name := range names {
+		kind := InstantWinKind(name)
+		if !slices.Contains(instantWinPriority, kind) {
+			return nil, fmt.Errorf("unknown instant win %q (available: %v)", name, instantWinPriority)
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds, nil
+}
+
+// instantWinDetectors report whether a dealt hand qualifies for each kind.
+// Jokers never complete a dragon.
+var instantWinDetectors = map[InstantWinKind]func(hand Deck) bool{
+	InstantWinDragon: func(hand Deck) bool {
+		ranks := make(map[Rank]bool)
+		for _, c := range hand {
+			if !c.IsJoker() {
+				ranks[c.Rank] = true
+			}
+		}
+		return len(ranks) == 13
+	},
+	InstantWinFourTwos: func(hand Deck) bool {
+		twos := 0
+		for _, c := range hand {
+			if c.Rank == Two {
+				twos++
+			}
+		}
+		return twos == 4
+	},
+	InstantWinSixPairs: func(hand Deck) bool {
+		counts := make(map[Rank]int)
+		for _, c := range hand {
+			counts[c.Rank]++
+		}
+		pairs := 0
+		for _, n := range counts {
+			pairs += n / 2
+		}
+		return pairs >= 6
+	},
+	InstantWinOneColour: func(hand Deck) bool {
+		if len(hand) < 13 {
+			return false
+		}
+		colours := make(map[bool]bool)
+		for _, c := range hand {
+			if !c.IsJoker() { // Jokers match either colour
+				colours[isRed(c)] = true
+			}
+		}
+		return len(colours) == 1
+	},
+}
+
+func isRed(c Card) bool {
+	return c.Suit == Diamonds || c.Suit == Hearts
+}
+
+// InstantWin records a round won on the deal. Hand is revealed to every player.
+type InstantWin struct {
+	PlayerID string         `json:"playerId"`
+	Kind     InstantWinKind `json:"kind"`
+	Hand     Deck           `json:"hand"`
+}
+
+// DetectInstantWin checks the dealt hands for the instant wins enabled in the table's
+// rules and returns the winning player and hand, or nil if there is none.
+func DetectInstantWin(game *GameState) *InstantWin {
+	enabled := make(map[InstantWinKind]bool)
+	for _, kind := range game.RuleEngine.Options.InstantWins {
+		enabled[kind] = true
+	}
+	for _, kind := range instantWinPriority {
+		if !enabled[kind] {
+			continue
+		}
+		for _, p := range game.Players {
+			if p.IsEliminated || !instantWinDetectors[kind](p.Hand) {
+				continue
+			}
+			return &InstantWin{PlayerID: p.ID, Kind: kind, Hand: append(Deck(nil), p.Hand...)}
+		}
+	}
+	return nil
+}
+
+// instantWinScores charges every other player the table's flat instant-win penalty.
+func instantWinScores(game *GameState, points int) map[string]int {
+	scores := make(map[string]int)
+	for _, player := range game.Players {
+		if wonRound(game, player.ID) || player.IsEliminated {
+			scores[player.ID] = 0
+		} else {
+			scores[player.ID] = points
+		}
+	}
+	return scores
+}
+
+// instantWinMessage describes an instant win for the table.
+func instantWinMessage(game *GameState) string {
+	win := game.InstantWin
+	name := win.PlayerID
+	if p := game.playerByID(win.PlayerID); p != nil {
+		name = p.Name
+	}
+	return fmt.Sprintf("%s wins the round on the deal with %s: %s", name, win.Kind, win.Hand.String())
+}
+
+// checkInstantWin ends a freshly dealt round if a player was dealt an instant win.
+// It reports whether the round was ended.
+func checkInstantWin(game *GameState) bool {
+	game.InstantWin = DetectInstantWin(game)
+	if game.InstantWin == nil {
+		return false
+	}
+	gameLog(game).Info("instant win", "player", game.InstantWin.PlayerID, "reason", instantWinMessage(game))
+	completeRound(game, game.playerByID(game.InstantWin.PlayerID))
+	return true
+}
@@ -0,0 +1,78 @@
+package main
+
+import "testing"
+
+// handOf builds a hand from ranks, cycling suits as given.
+func handOf(suits []Suit, ranks ...Rank) Deck {
+	hand := make(Deck, len(ranks))
+	for i, r := range ranks {
+		hand[i] = C(r, suits[i%len(suits)])
+	}
+	return hand
+}
+
+func TestInstantWinDetectors(t *testing.T) {
+	allSuits := []Suit{Diamonds, Clubs, Hearts, Spades}
+	tests := []struct {
+		name string
+		kind InstantWinKind
+		hand Deck
+		want bool
+	}{
+		{"Dragon", InstantWinDragon, handOf(allSuits, Rank3, Rank4, Rank5, Rank6, Rank7, Rank8, Rank9, Rank10, Jack, Queen, King, Ace, Two), true},
+		{"Dragon missing the 2", InstantWinDragon, handOf(allSuits, Rank3, Rank4, Rank5, Rank6, Rank7, Rank8, Rank9, Rank10, Jack, Queen, King, Ace, Ace), false},
+		{"Four 2s", InstantWinFourTwos, handOf(allSuits, Two, Two, Two, Two, Rank3), true},
+		{"Three 2s", InstantWinFourTwos, handOf(allSuits, Two, Two, Two, Rank3), false},
+		{"Six pairs", InstantWinSixPairs, handOf(allSuits, Rank3, Rank3, Rank5, Rank5, Rank7, Rank7, Rank9, Rank9, Jack, Jack, King, King, Two), true},
+		{"Four of a kind counts as two pairs", InstantWinSixPairs, handOf(allSuits, Rank3, Rank3, Rank3, Rank3, Rank7, Rank7, Rank9, Rank9, Jack, Jack, King, King, Two), true},
+		{"Five pairs", InstantWinSixPairs, handOf(allSuits, Rank3, Rank3, Rank5, Rank5, Rank7, Rank7, Rank9, Rank9, Jack, Jack, Queen, King, Two), false},
+		{"All red", InstantWinOneColour, handOf([]Suit{Diamonds, Hearts}, Rank3, Rank3, Rank5, Rank5, Rank7, Rank7, Rank9, Rank9, Jack, Jack, King, King, Two), true},
+		{"One black card", InstantWinOneColour, append(handOf([]Suit{Diamonds, Hearts}, Rank3, Rank3, Rank5, Rank5, Rank7, Rank7, Rank9, Rank9, Jack, Jack, King, King), C(Two, Spades)), false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := instantWinDetectors[tc.kind](tc.hand); got != tc.want {
+				t.Errorf("%s(%s) = %v, want %v", tc.kind, tc.hand, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheckInstantWin(t *testing.T) {
+	players := []*Player{NewPlayer(1, "P1"), NewPlayer(2, "P2"), NewPlayer(3, "P3"), NewPlayer(4, "P4")}
+	opts := DefaultRuleOptions()
+	opts.InstantWins = []InstantWinKind{InstantWinFourTwos, InstantWinDragon}
+	opts.InstantWinPoints = 20
+	game := &GameState{
+		Players:     players,
+		RuleEngine:  NewBigTwoRuleEngineWithOptions(opts),
+		Scores:      map[string]int{},
+		TargetScore: 100,
+		MatchRules:  DefaultMatchRules(),
+		RoundNumber: 1,
+		RoundWins:   map[string]int{},
+	}
+	allSuits := []Suit{Diamonds, Clubs, Hearts, Spades}
+	players[0].Hand = handOf(allSuits, Rank3, Rank4, Rank5)
+	players[1].Hand = handOf(allSuits, Two, Two, Two, Two, Rank3)
+	players[2].Hand = handOf(allSuits, Rank3, Rank4, Rank5, Rank6, Rank7, Rank8, Rank9, Rank10, Jack, Queen, King, Ace, Ace)
+	players[3].Hand = handOf(allSuits, Rank6, Rank6)
+
+	if !checkInstantWin(game) {
+		t.Fatal("four 2s were not detected")
+	}
+	if !game.IsGameOver || game.WinnerID != "player2" || game.InstantWin.Kind != InstantWinFourTwos {
+		t.Errorf("IsGameOver = %v, WinnerID = %q, InstantWin = %+v", game.IsGameOver, game.WinnerID, game.InstantWin)
+	}
+	want := map[string]int{"player1": 20, "player2": 0, "player3": 20, "player4": 20}
+	for id, score := range want {
+		if game.Scores[id] != score {
+			t.Errorf("score for %s = %d, want %d", id, game.Scores[id], score)
+		}
+	}
+
+	game.RuleEngine.Options.InstantWins = nil
+	if checkInstantWin(game) {
+		t.Error("instant win detected with none enabled")
+	}
+}
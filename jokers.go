@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// countJokers returns the number of jokers in the cards.
+func countJokers(cards Deck) int {
+	n := 0
+	for _, c := range cards {
+		if c.IsJoker() {
+			n++
+		}
+	}
+	return n
+}
+
+// maxJokers is the most jokers a deck may hold.
+const maxJokers = 2
+
+// dealJokers gives each of the table's jokers to a different active player chosen at random.
+// The natural cards are split as they would be without jokers (13 each at four players), so
+// every joker is in play and the hands holding one are a card longer.
+func dealJokers(game *GameState, jokers int) {
+	var active []*Player
+	for _, p := range game.Players {
+		if !p.IsEliminated {
+			active = append(active, p)
+		}
+	}
+	if len(active) == 0 {
+		return
+	}
+	order := rand.Perm(len(active))
+	for i := 0; i < jokers && i < maxJokers; i++ {
+		p := active[order[i%len(active)]]
+		p.Hand = append(p.Hand, NewJoker(i))
+		p.Hand.Sort()
+	}
+}
+
+// resolveJokers classifies a hand containing jokers by trying every card each joker could
+// stand for and keeping the strongest valid interpretation. The returned hand keeps the
+// cards actually played in Cards and reports the interpretation in ResolvedCards.
+func (re *BigTwoRuleEngine) resolveJokers(selectedCards Deck) (*PlayedHand, error) {
+	played := make(Deck, len(selectedCards))
+	copy(played, selectedCards)
+	played.Sort()
+
+	switch len(played) {
+	case 1:
+		if re.Options.JokerSingleBeatsTwo {
+			return &PlayedHand{
+				Cards:         played,
+				HandType:      Single,
+				EffectiveRank: Joker,
+				EffectiveSuit: played[0].Suit,
+			}, nil
+		}
+	case 2, 3, 5:
+	default:
+		if re.Options.Mode != ModeTienLen {
+			return nil, fmt.Errorf("invalid number of cards played: %d. Must be 1, 2, 3, or 5", len(played))
+		}
+	}
+
+	naturals := make(Deck, 0, len(played))
+	inHand := make(map[Card]bool)
+	for _, c := range played {
+		if !c.IsJoker() {
+			naturals = append(naturals, c)
+			inHand[c] = true
+		}
+	}
+	// A joker may stand for any natural card not already in the hand.
+	var substitutes Deck
+	for _, c := range NewDeck() {
+		if !inHand[c] {
+			substitutes = append(substitutes, c)
+		}
+	}
+
+	var best *PlayedHand
+	candidate := make(Deck, len(played))
+	var try func(start, jokersLeft int, hand Deck)
+	try = func(start, jokersLeft int, hand Deck) {
+		if jokersLeft == 0 {
+			copy(candidate, hand)
+			candidate.Sort()
+			resolved, err := re.classifyNatural(candidate)
+			if err == nil && (best == nil || re.BeatsLastHand(resolved, best)) {
+				best = resolved
+			}
+			return
+		}
+		// Jokers are interchangeable, so each set of substitutes is tried once.
+		for i := start; i < len(substitutes); i++ {
+			try(i+1, jokersLeft-1, append(hand, substitutes[i]))
+		}
+	}
+	try(0, len(played)-len(naturals), naturals)
+
+	if best == nil {
+		return nil, fmt.Errorf("selected cards do not form a valid Big 2 hand, even with jokers")
+	}
+	best.ResolvedCards = best.Cards
+	best.Cards = played
+	return best, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestJokerCards(t *testing.T) {
+	deck := NewDeckWithJokers(2)
+	if len(deck) != 54 || countJokers(deck) != 2 {
+		t.Fatalf("NewDeckWithJokers(2) has %d cards and %d jokers; want 54 and 2", len(deck), countJokers(deck))
+	}
+	hand := Deck{NewJoker(1), C(Two, Spades), NewJoker(0), C(Rank3, Diamonds)}
+	hand.Sort()
+	if got := hand.String(); got != "[3D, 2S, JK1, JK2]" {
+		t.Errorf("sorted hand = %s, want [3D, 2S, JK1, JK2]", got)
+	}
+}
+
+func TestResolveJokers(t *testing.T) {
+	re := NewBigTwoRuleEngine()
+	jk1, jk2 := NewJoker(0), NewJoker(1)
+
+	tests := []struct {
+		name         string
+		cards        Deck
+		wantHandType HandType
+		wantEffRank  Rank
+		wantEffSuit  Suit
+		wantErr      bool
+	}{
+		{"Pair with a joker takes the highest suit", Deck{C(Rank5, Diamonds), jk1}, Pair, Rank5, Spades, false},
+		{"Triple with a joker", Deck{C(Rank9, Clubs), C(Rank9, Hearts), jk1}, Triple, Rank9, -1, false},
+		{"Pair of jokers is a pair of 2s", Deck{jk1, jk2}, Pair, Two, Spades, false},
+		{"Joker completes the best straight flush", Deck{C(Rank9, Diamonds), C(Rank10, Diamonds), C(Jack, Diamonds), C(Queen, Diamonds), jk1}, StraightFlush, King, Diamonds, false},
+		{"Two jokers make four of a kind", Deck{C(Rank4, Clubs), C(King, Hearts), C(King, Spades), jk1, jk2}, FourOfAKindPlusOne, King, -1, false},
+		{"Lone joker counts as the 2 of Spades", Deck{jk1}, Single, Two, Spades, false},
+		{"No valid interpretation", Deck{C(Rank3, Diamonds), C(Rank9, Clubs), jk1}, InvalidHand, -1, -1, true},
+		{"Four cards", Deck{C(Rank3, Diamonds), C(Rank3, Clubs), C(Rank3, Hearts), jk1}, InvalidHand, -1, -1, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			played, err := re.DeterminePlayedHand(tc.cards)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("DeterminePlayedHand(%s) error = %v, wantErr %v", tc.cards, err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if played.HandType != tc.wantHandType || played.EffectiveRank != tc.wantEffRank || played.EffectiveSuit != tc.wantEffSuit {
+				t.Errorf("got %s rank %s suit %d (resolved %v); want %s rank %s suit %d",
+					played.HandType, played.EffectiveRank, played.EffectiveSuit, Deck(played.ResolvedCards), tc.wantHandType, tc.wantEffRank, tc.wantEffSuit)
+			}
+			if countJokers(played.Cards) != countJokers(tc.cards) || countJokers(played.ResolvedCards) != 0 {
+				t.Errorf("Cards = %s, ResolvedCards = %v; want jokers kept in Cards and resolved away", played.Cards, Deck(played.ResolvedCards))
+			}
+		})
+	}
+}
+
+func TestJokerSingleBeatsTwo(t *testing.T) {
+	twoOfSpades := &PlayedHand{Cards: Deck{C(Two, Spades)}, HandType: Single, EffectiveRank: Two, EffectiveSuit: Spades}
+
+	re := NewBigTwoRuleEngine()
+	joker, err := re.DeterminePlayedHand(Deck{NewJoker(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if re.BeatsLastHand(joker, twoOfSpades) {
+		t.Error("lone joker beat the 2 of Spades with JokerSingleBeatsTwo off")
+	}
+
+	opts := DefaultRuleOptions()
+	opts.JokerSingleBeatsTwo = true
+	re = NewBigTwoRuleEngineWithOptions(opts)
+	joker, err = re.DeterminePlayedHand(Deck{NewJoker(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !re.BeatsLastHand(joker, twoOfSpades) {
+		t.Error("lone joker did not beat the 2 of Spades with JokerSingleBeatsTwo on")
+	}
+}
+
+func TestGenerateMoves_WithJoker(t *testing.T) {
+	re := NewBigTwoRuleEngine()
+	moves := re.GenerateMoves(Deck{C(Rank5, Diamonds), C(Rank8, Clubs), NewJoker(0)}, nil)
+	pairs := 0
+	for _, m := range moves {
+		if m.HandType == Pair {
+			pairs++
+		}
+	}
+	if pairs != 2 { // 5D+JK and 8C+JK
+		t.Errorf("got %d pairs, want 2", pairs)
+	}
+}
+
+func TestResetRoundState_DealsJokers(t *testing.T) {
+	for _, players := range []int{2, 3, 4} {
+		seats := make([]*Player, players)
+		for i := range seats {
+			seats[i] = NewPlayer(i+1, fmt.Sprintf("P%d", i+1))
+		}
+		game := NewGameState("jokers", seats, 100)
+		game.RuleEngine.Options.Jokers = 2
+		resetRoundState(game)
+
+		natural := 52 / players
+		jokers, longHands := 0, 0
+		for _, p := range game.Players {
+			jokers += countJokers(p.Hand)
+			switch len(p.Hand) {
+			case natural:
+			case natural + 1:
+				longHands++
+			default:
+				t.Errorf("%d players: %s holds %d cards, want %d or %d", players, p.ID, len(p.Hand), natural, natural+1)
+			}
+		}
+		if jokers != 2 || longHands != 2 {
+			t.Errorf("%d players: %d jokers dealt, %d hands with an extra card; want 2 and 2", players, jokers, longHands)
+		}
+	}
+}
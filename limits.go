@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Limits on what a websocket client may send. They are set from the configuration at startup.
+var (
+	maxMessageSize    int64 = 4096 // Bytes; a larger message closes the connection
+	messageRateLimits       = defaultRateLimits()
+	maxViolations           = 10 // Refused messages allowed per minute before a client is disconnected
+)
+
+// RateLimit is a token bucket: a client may send Burst messages at once, and PerSecond
+// messages a second after that.
+type RateLimit struct {
+	PerSecond float64 `json:"perSecond"`
+	Burst     int     `json:"burst"`
+}
+
+// rateLimits holds the rate limit for each message type. "*" applies to types without their own.
+type rateLimits map[string]RateLimit
+
+func defaultRateLimits() rateLimits {
+	return rateLimits{
+		"*":           {PerSecond: 10, Burst: 20},
+		"chat":        {PerSecond: 1, Burst: 5},
+		"setAlias":    {PerSecond: 0.2, Burst: 3},
+		"requestUndo": {PerSecond: 0.2, Burst: 2},
+		"callVote":    {PerSecond: 0.1, Burst: 2},
+	}
+}
+
+// String writes the limits as type=perSecond/burst pairs, comma-separated and sorted.
+func (l rateLimits) String() string {
+	types := make([]string, 0, len(l))
+	for t := range l {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	pairs := make([]string, len(types))
+	for i, t := range types {
+		pairs[i] = fmt.Sprintf("%s=%s/%d", t, strconv.FormatFloat(l[t].PerSecond, 'g', -1, 64), l[t].Burst)
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Set parses type=perSecond/burst pairs, as written by String, replacing the limits for the
+// types given and keeping the others.
+func (l *rateLimits) Set(s string) error {
+	if *l == nil {
+		*l = make(rateLimits)
+	}
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		msgType, limit, ok := strings.Cut(pair, "=")
+		perSecond, burst, ok2 := strings.Cut(limit, "/")
+		if !ok || !ok2 {
+			return fmt.Errorf("%q: want type=perSecond/burst, e.g. chat=1/5", pair)
+		}
+		var rl RateLimit
+		var err error
+		if rl.PerSecond, err = strconv.ParseFloat(perSecond, 64); err != nil {
+			return fmt.Errorf("%q: %v", pair, err)
+		}
+		if rl.Burst, err = strconv.Atoi(burst); err != nil {
+			return fmt.Errorf("%q: %v", pair, err)
+		}
+		(*l)[strings.TrimSpace(msgType)] = rl
+	}
+	return nil
+}
+
+// validate checks that every limit is for a known message type and lets some messages through.
+func (l rateLimits) validate() error {
+	for t, rl := range l {
+		if t != "*" && !actionTypes[t] {
+			return fmt.Errorf("unknown message type %q", t)
+		}
+		if rl.PerSecond <= 0 || rl.Burst < 1 || math.IsInf(rl.PerSecond, 0) {
+			return fmt.Errorf("%s: perSecond must be positive and burst at least 1", t)
+		}
+	}
+	return nil
+}
+
+// tokenBucket is the state of one RateLimit. It starts full.
+type tokenBucket struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit, now time.Time) *tokenBucket {
+	return &tokenBucket{limit: limit, tokens: float64(limit.Burst), last: now}
+}
+
+// take spends a token if one is available at now. If not, it returns how long until one is.
+func (b *tokenBucket) take(now time.Time) (ok bool, retryAfter time.Duration) {
+	b.tokens = math.Min(float64(b.limit.Burst), b.tokens+now.Sub(b.last).Seconds()*b.limit.PerSecond)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / b.limit.PerSecond * float64(time.Second))
+}
+
+// connLimiter enforces the rate limits of one connection and tracks its refused messages.
+// It is used only by the connection's reading goroutine.
+type connLimiter struct {
+	limits     rateLimits
+	buckets    map[string]*tokenBucket // By message type, as counted by countAction
+	violations *tokenBucket
+}
+
+func newConnLimiter(limits rateLimits, maxViolations int, now time.Time) *connLimiter {
+	return &connLimiter{
+		limits:     limits,
+		buckets:    make(map[string]*tokenBucket),
+		violations: newTokenBucket(RateLimit{PerSecond: float64(maxViolations) / 60, Burst: maxViolations}, now),
+	}
+}
+
+// allow reports whether a message of msgType may be handled at now, and if not, when to retry.
+func (l *connLimiter) allow(msgType string, now time.Time) (bool, time.Duration) {
+	if !actionTypes[msgType] {
+		msgType = "unknown" // One bucket for all unknown types, as in the metrics
+	}
+	b := l.buckets[msgType]
+	if b == nil {
+		limit, ok := l.limits[msgType]
+		if !ok {
+			limit = l.limits["*"]
+		}
+		b = newTokenBucket(limit, now)
+		l.buckets[msgType] = b
+	}
+	return b.take(now)
+}
+
+// violation records a message refused for abuse: flooding, or sending something that is
+// not a message at all. Reports whether the client has now run out of allowance and should
+// be disconnected.
+func (l *connLimiter) violation(now time.Time) bool {
+	ok, _ := l.violations.take(now)
+	return !ok
+}
+
+// credentialsLimiter limits /api/register and /api/login per remote address, to slow down
+// password guessing: 5 attempts at once, then one every 10 seconds.
+var credentialsLimiter = newAddrLimiter(RateLimit{PerSecond: 0.1, Burst: 5})
+
+// maxAddrBuckets is how many addresses an addrLimiter tracks before it forgets the ones
+// whose buckets have refilled.
+const maxAddrBuckets = 10000
+
+// addrLimiter applies a RateLimit to each remote host. It is safe for concurrent use.
+type addrLimiter struct {
+	mu      sync.Mutex
+	limit   RateLimit
+	buckets map[string]*tokenBucket // By host
+}
+
+func newAddrLimiter(limit RateLimit) *addrLimiter {
+	return &addrLimiter{limit: limit, buckets: make(map[string]*tokenBucket)}
+}
+
+// allow reports whether a request from remoteAddr may be handled at now, and if not, when to retry.
+func (l *addrLimiter) allow(remoteAddr string, now time.Time) (bool, time.Duration) {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.buckets[host]
+	if b == nil {
+		if len(l.buckets) >= maxAddrBuckets {
+			l.forgetRefilled(now)
+		}
+		b = newTokenBucket(l.limit, now)
+		l.buckets[host] = b
+	}
+	return b.take(now)
+}
+
+// forgetRefilled drops the buckets that are full again, as a new bucket would be. Assumes l.mu is held.
+func (l *addrLimiter) forgetRefilled(now time.Time) {
+	for host, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*b.limit.PerSecond >= float64(b.limit.Burst) {
+			delete(l.buckets, host)
+		}
+	}
+}
+
+// sendLimitError refuses a message that broke a limit. Unlike rejectAction it carries a code,
+// and when the message may be retried, how many milliseconds to wait.
+func sendLimitError(c *client, reason, code, content string, retryAfter time.Duration) {
+	metrics.rejected.Inc(reason)
+	payload := map[string]interface{}{"type": "error", "code": code, "content": content}
+	if retryAfter > 0 {
+		payload["retryAfterMs"] = retryAfter.Milliseconds() + 1
+	}
+	jsonMsg, _ := json.Marshal(payload)
+	c.write(websocket.TextMessage, jsonMsg)
+}
+
+// disconnectAbusive tells the client why and closes the connection with a policy violation.
+// The caller's deferred cleanup then unregisters it.
+func disconnectAbusive(c *client, log *slog.Logger) {
+	log.Warn("disconnecting abusive client")
+	metrics.abuseDisconnects.Inc()
+	sendLimitError(c, "abusive_disconnect", "disconnected", "Disconnected for sending too many refused messages.", 0)
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	closeMsg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "too many refused messages")
+	c.conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second))
+}
+
+// originChecker returns the upgrader's CheckOrigin for the allowed origins. With none, only
+// pages served by this host may connect; "*" allows any origin. Requests without an Origin
+// header do not come from a browser and are always allowed.
+func originChecker(allowedOrigins []string) func(r *http.Request) bool {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[strings.ToLower(origin)] = true
+	}
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" || allowed["*"] || allowed[strings.ToLower(origin)] {
+			return true
+		}
+		if len(allowed) == 0 {
+			if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+				return true
+			}
+		}
+		metrics.originRefused.Inc()
+		slog.Warn("websocket origin refused", "origin", origin, "remote", r.RemoteAddr)
+		return false
+	}
+}
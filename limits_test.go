@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// setRateLimits replaces the websocket rate limits for the rest of the test. It affects
+// connections made afterwards.
+func setRateLimits(t *testing.T, limits rateLimits) {
+	saved := messageRateLimits
+	messageRateLimits = limits
+	t.Cleanup(func() { messageRateLimits = saved })
+}
+
+// waitClosed waits for the server to close c's connection and returns the close code.
+func (c *wsTestClient) waitClosed() int {
+	c.t.Helper()
+	timeout := time.After(wsTestTimeout)
+	for {
+		select {
+		case _, ok := <-c.frames:
+			if !ok {
+				if closeErr, isClose := c.readErr.(*websocket.CloseError); isClose {
+					return closeErr.Code
+				}
+				c.t.Fatalf("%s: connection ended without a close frame: %v", c.playerID, c.readErr)
+			}
+		case <-timeout:
+			c.t.Fatalf("%s: connection still open", c.playerID)
+		}
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	start := time.Unix(0, 0)
+	b := newTokenBucket(RateLimit{PerSecond: 2, Burst: 3}, start)
+	tests := []struct {
+		at         time.Duration
+		ok         bool
+		retryAfter time.Duration
+	}{
+		{0, true, 0},
+		{0, true, 0},
+		{0, true, 0},
+		{0, false, 500 * time.Millisecond}, // Burst spent
+		{250 * time.Millisecond, false, 250 * time.Millisecond},
+		{500 * time.Millisecond, true, 0},
+		{10 * time.Second, true, 0}, // Refilled, but only to the burst
+		{10 * time.Second, true, 0},
+		{10 * time.Second, true, 0},
+		{10 * time.Second, false, 500 * time.Millisecond},
+	}
+	for i, tt := range tests {
+		ok, retryAfter := b.take(start.Add(tt.at))
+		if ok != tt.ok || retryAfter != tt.retryAfter {
+			t.Errorf("take %d at %v = %v, %v; want %v, %v", i, tt.at, ok, retryAfter, tt.ok, tt.retryAfter)
+		}
+	}
+}
+
+func TestCredentialsRateLimit(t *testing.T) {
+	store, err := OpenAccountStore(filepath.Join(t.TempDir(), "accounts.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	savedStore, savedLimiter := accountStore, credentialsLimiter
+	accountStore, credentialsLimiter = store, newAddrLimiter(RateLimit{PerSecond: 0.01, Burst: 2})
+	t.Cleanup(func() { accountStore, credentialsLimiter = savedStore, savedLimiter })
+
+	login := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/login", strings.NewReader(`{"username": "alice", "password": "wrong"}`))
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handleLogin(rec, req)
+		return rec
+	}
+	for i, want := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		if rec := login("192.0.2.1:" + strconv.Itoa(5000+i)); rec.Code != want {
+			t.Errorf("attempt %d status = %d, want %d", i+1, rec.Code, want)
+		} else if want == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+			t.Error("429 response has no Retry-After header")
+		}
+	}
+	if rec := login("192.0.2.2:5000"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("another address status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestRateLimits_Set(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr string
+	}{
+		{"chat=2/4", "*=10/20,callVote=0.1/2,chat=2/4,requestUndo=0.2/2,setAlias=0.2/3", ""},
+		{" *=5/5 , playCards=0.5/1,", "*=5/5,callVote=0.1/2,chat=1/5,playCards=0.5/1,requestUndo=0.2/2,setAlias=0.2/3", ""},
+		{"chat=2", "", "want type=perSecond/burst"},
+		{"chat=fast/4", "", "invalid syntax"},
+	}
+	for _, tt := range tests {
+		l := defaultRateLimits()
+		err := l.Set(tt.in)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Set(%q) error %v, want %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || l.String() != tt.want {
+			t.Errorf("Set(%q) = %s, %v; want %s", tt.in, l, err, tt.want)
+		}
+	}
+
+	for _, bad := range []rateLimits{
+		{"*": {PerSecond: 1, Burst: 1}, "teleport": {PerSecond: 1, Burst: 1}},
+		{"*": {PerSecond: 0, Burst: 1}},
+		{"*": {PerSecond: 1, Burst: 0}},
+	} {
+		if err := bad.validate(); err == nil {
+			t.Errorf("validate(%s) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestOriginChecker(t *testing.T) {
+	tests := []struct {
+		allowed []string
+		origin  string
+		want    bool
+	}{
+		{nil, "", true}, // Not a browser
+		{nil, "http://example.com", true},
+		{nil, "https://EXAMPLE.com", true},
+		{nil, "http://evil.example.com", false},
+		{[]string{"*"}, "http://evil.example.com", true},
+		{[]string{"https://cards.example.com"}, "https://cards.example.com", true},
+		{[]string{"https://cards.example.com"}, "http://example.com", false}, // The list replaces same-origin
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "http://example.com/ws", nil)
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		if got := originChecker(tt.allowed)(r); got != tt.want {
+			t.Errorf("allowed %v, origin %q: got %v, want %v", tt.allowed, tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestWebSocket_RateLimitDisconnectsFlooder(t *testing.T) {
+	h := newWSHarness(t, 2, nil)
+	setRateLimits(t, rateLimits{"*": {PerSecond: 100, Burst: 100}, "chat": {PerSecond: 0.01, Burst: 2}})
+	savedMax := maxViolations
+	maxViolations = 3
+	t.Cleanup(func() { maxViolations = savedMax })
+	cs, _ := h.connectAll(2)
+	flooder := cs[0]
+	disconnectsBefore := metrics.abuseDisconnects.Value()
+
+	for i := 0; i < 2; i++ {
+		flooder.send(map[string]interface{}{"type": "chat", "content": "hi"})
+		flooder.next("chat")
+	}
+	for i := 0; i < 3; i++ {
+		flooder.send(map[string]interface{}{"type": "chat", "content": "spam"})
+		var msg struct {
+			Code         string
+			Content      string
+			RetryAfterMs int64
+		}
+		json.Unmarshal(flooder.next("error"), &msg)
+		if msg.Code != "rateLimited" || msg.RetryAfterMs <= 0 || !strings.Contains(msg.Content, "chat") {
+			t.Fatalf("flood message %d refused with %+v", i, msg)
+		}
+	}
+	// Other message types have their own buckets.
+	flooder.send(map[string]interface{}{"type": "teleport"})
+	flooder.expectError("Unknown message type")
+
+	flooder.send(map[string]interface{}{"type": "chat", "content": "spam"}) // Fourth violation
+	var msg struct{ Code string }
+	json.Unmarshal(flooder.next("error"), &msg)
+	if msg.Code != "rateLimited" {
+		t.Fatalf("got %q, want rateLimited", msg.Code)
+	}
+	json.Unmarshal(flooder.next("error"), &msg)
+	if msg.Code != "disconnected" {
+		t.Fatalf("got %q, want disconnected", msg.Code)
+	}
+	if code := flooder.waitClosed(); code != websocket.ClosePolicyViolation {
+		t.Errorf("close code %d, want %d", code, websocket.ClosePolicyViolation)
+	}
+	if got := metrics.abuseDisconnects.Value() - disconnectsBefore; got != 1 {
+		t.Errorf("abuse disconnects rose by %d, want 1", got)
+	}
+	// The other player is unaffected.
+	cs[1].send(map[string]interface{}{"type": "chat", "content": "still here"})
+	cs[1].next("chat")
+}
+
+func TestWebSocket_MalformedMessagesDisconnect(t *testing.T) {
+	h := newWSHarness(t, 2, nil)
+	savedMax := maxViolations
+	maxViolations = 2
+	t.Cleanup(func() { maxViolations = savedMax })
+	c, _ := h.connect()
+
+	for _, frame := range []string{"not json", `{"content": "no type"}`, "{"} {
+		c.conn.WriteMessage(websocket.TextMessage, []byte(frame))
+	}
+	c.expectError("Malformed JSON")
+	c.expectError("missing 'type'")
+	c.expectError("Malformed JSON")
+	c.expectError("Disconnected")
+	if code := c.waitClosed(); code != websocket.ClosePolicyViolation {
+		t.Errorf("close code %d, want %d", code, websocket.ClosePolicyViolation)
+	}
+}
+
+func TestWebSocket_MessageTooLarge(t *testing.T) {
+	h := newWSHarness(t, 2, nil)
+	saved := maxMessageSize
+	maxMessageSize = 512
+	t.Cleanup(func() { maxMessageSize = saved })
+	c, _ := h.connect()
+
+	c.send(map[string]interface{}{"type": "chat", "content": strings.Repeat("x", 400)})
+	c.next("chat")
+	c.send(map[string]interface{}{"type": "chat", "content": strings.Repeat("x", 600)})
+	if code := c.waitClosed(); code != websocket.CloseMessageTooBig {
+		t.Errorf("close code %d, want %d", code, websocket.CloseMessageTooBig)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// logLevel is the minimum level logged. It can be changed while the server runs.
+var logLevel = new(slog.LevelVar)
+
+// setupLogging makes a structured logger writing to w the default for both log/slog and
+// the standard log package. level is debug, info, warn or error; format is text or json.
+func setupLogging(w io.Writer, level, format string) error {
+	if err := logLevel.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("log level %q: want debug, info, warn or error", level)
+	}
+	opts := &slog.HandlerOptions{Level: logLevel}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "text", "":
+		handler = slog.NewTextHandler(w, opts)
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("log format %q: want text or json", format)
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// gameLog returns a logger that tags each record with the table and round, so that one
+// table's logs can be picked out. Assumes gameInstanceMutex is held.
+func gameLog(game *GameState) *slog.Logger {
+	if game == nil {
+		return slog.Default()
+	}
+	return slog.With("game", game.ID, "round", game.RoundNumber)
+}
+
+// playerLog is gameLog with the acting player added.
+func playerLog(game *GameState, player *Player) *slog.Logger {
+	return gameLog(game).With("player", player.ID)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// restoreLogging puts back the default loggers that setupLogging replaces.
+func restoreLogging(t *testing.T) {
+	prev, flags := slog.Default(), log.Flags()
+	t.Cleanup(func() {
+		slog.SetDefault(prev)
+		log.SetOutput(defaultLogOutput)
+		log.SetFlags(flags)
+		logLevel.Set(slog.LevelInfo)
+	})
+}
+
+func TestSetupLogging_JSONWithGameContext(t *testing.T) {
+	game := NewGameState("logs", []*Player{NewPlayer(1, "P1"), NewPlayer(2, "P2")}, 100)
+	game.RoundNumber = 3
+	restoreLogging(t)
+	var buf bytes.Buffer
+	if err := setupLogging(&buf, "info", "json"); err != nil {
+		t.Fatal(err)
+	}
+
+	playerLog(game, game.Players[1]).With("action", "passTurn").Info("passed", "passCount", 1)
+	gameLog(game).Debug("hidden below info")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d records, want 1 (debug filtered out):\n%s", len(lines), buf.String())
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("record is not JSON: %v\n%s", err, lines[0])
+	}
+	want := map[string]interface{}{"msg": "passed", "level": "INFO", "game": "logs", "round": 3.0, "player": "player2", "action": "passTurn", "passCount": 1.0}
+	for k, v := range want {
+		if record[k] != v {
+			t.Errorf("%s = %v, want %v", k, record[k], v)
+		}
+	}
+}
+
+func TestSetupLogging_Options(t *testing.T) {
+	tests := []struct {
+		level, format string
+		wantErr       string
+		debugLogged   bool
+	}{
+		{"debug", "text", "", true},
+		{"INFO", "JSON", "", false},
+		{"warn", "", "", false},
+		{"verbose", "text", "log level", false},
+		{"info", "xml", "log format", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level+"/"+tt.format, func(t *testing.T) {
+			restoreLogging(t)
+			var buf bytes.Buffer
+			err := setupLogging(&buf, tt.level, tt.format)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("setupLogging error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			slog.Debug("detail")
+			if logged := buf.Len() > 0; logged != tt.debugLogged {
+				t.Errorf("debug record logged %v, want %v", logged, tt.debugLogged)
+			}
+		})
+	}
+}
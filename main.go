@@ -2,11 +2,16 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -14,25 +19,58 @@ import (
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool { // Allow all connections for simplicity
-		return true
-	},
+	CheckOrigin:     originChecker(nil), // Same origin only unless configured; see configureUpgrader
+}
+
+// configureUpgrader applies the websocket settings from cfg.
+func configureUpgrader(cfg *Config) {
+	upgrader.ReadBufferSize = cfg.ReadBuffer
+	upgrader.WriteBufferSize = cfg.WriteBuffer
+	upgrader.CheckOrigin = originChecker(cfg.AllowedOrigins)
 }
 
 // client represents a single WebSocket connection and its associated player.
 // We use a pointer to Player to share the Player state from GameState.
 type client struct {
-	conn   *websocket.Conn
-	player *Player // Reference to the Player struct in the GameState
+	conn     *websocket.Conn
+	player   *Player    // Reference to the Player struct in the GameState
+	game     *GameState // The table this connection is seated at
+	identity string     // Who is connected; see seatIdentity
+	host     string     // Where they connect from; see hostIdentity
+	writeMu  sync.Mutex // gorilla/websocket supports only one concurrent writer per connection
+}
+
+// viewer returns who the client's game state is projected for: its seat, or a spectator if unseated.
+func (c *client) viewer() Viewer {
+	if c.player == nil {
+		return Viewer{Role: ViewerSpectator}
+	}
+	return Viewer{Role: ViewerSeat, PlayerID: c.player.ID}
+}
+
+// write sends a message on the client's connection. Broadcasts, chat and action replies
+// run on different goroutines, so every write goes through here to stay serialized.
+func (c *client) write(messageType int, message []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	err := c.conn.WriteMessage(messageType, message)
+	if err != nil {
+		metrics.writeErrors.Inc()
+	}
+	return err
 }
 
 var (
 	clients           = make(map[*websocket.Conn]*client)
 	clientsMu         sync.Mutex
-	gameInstance      *GameState // Global pointer to our single game instance
-	gameInstanceMutex sync.Mutex // Mutex to protect gameInstance
+	gameInstance      *GameState // The default table; see tables for all tables
+	gameInstanceMutex sync.Mutex // Mutex to protect gameInstance and every other table
+	accountStore      *AccountStore
 )
 
+// accountStoreFile is where player accounts and match history are persisted, in the data directory.
+const accountStoreFile = "accounts.json"
+
 // Helper function to parse card data received from the client
 func parseCardsFromClientData(cardsData interface{}) (Deck, error) {
 	cardsArr, ok := cardsData.([]interface{})
@@ -59,158 +97,82 @@ func parseCardsFromClientData(cardsData interface{}) (Deck, error) {
 	return deck, nil
 }
 
-// broadcastGameState sends the current game state to all connected clients.
+// broadcastGameState sends the current game state to all clients seated at the table.
+// Each client receives its own GameView; see NewGameView for what a viewer may see.
 func broadcastGameState(game *GameState) {
-	log.Printf("DEBUG: broadcastGameState called. Game state players: %d, PassCount: %d, GameOver: %v", len(game.Players), game.PassCount, game.IsGameOver) // More concise log
-
 	if game == nil {
-		log.Println("ERROR: broadcastGameState called with nil game state")
+		slog.Error("broadcastGameState called with nil game state")
 		return
 	}
-
-	var currentPlayerID string
-	var currentPlayerName string
-	if game.CurrentTurnPlayerIndex >= 0 && game.CurrentTurnPlayerIndex < len(game.Players) {
-		currentPlayerID = game.Players[game.CurrentTurnPlayerIndex].ID
-		currentPlayerName = game.Players[game.CurrentTurnPlayerIndex].Name
-	} else {
-		log.Printf("Warning: CurrentTurnPlayerIndex (%d) is out of bounds for players list (len %d)", game.CurrentTurnPlayerIndex, len(game.Players))
-		// Assign default/empty values if index is out of bounds
-		currentPlayerID = ""
-		currentPlayerName = "N/A"
-	}
+	start := time.Now()
 
 	// Create a temporary list of clients to iterate over to avoid issues if clients map changes during iteration
 	// This also allows releasing locks sooner if applicable.
 	clientsSnapshot := make([]*client, 0, len(clients))
 	clientsMu.Lock() // Use Lock for sync.Mutex
 	for _, client := range clients {
-		clientsSnapshot = append(clientsSnapshot, client)
+		if client.game == game {
+			clientsSnapshot = append(clientsSnapshot, client)
+		}
 	}
 	clientsMu.Unlock() // Use Unlock for sync.Mutex
 
+	failed := 0
 	for _, c := range clientsSnapshot { // Iterate over the snapshot
-		clientHand := Deck{}
-		playerIDForClient := "Observer"
-		if c.player != nil {
-			playerIDForClient = c.player.ID
-			// Ensure client's player instance is up-to-date from game.Players for hand info
-			foundPlayerInGame := false
-			for _, gamePlayer := range game.Players {
-				if gamePlayer.ID == c.player.ID {
-					clientHand = gamePlayer.Hand // Get the most current hand from the game state
-					foundPlayerInGame = true
-					break
-				}
-			}
-			if !foundPlayerInGame {
-				log.Printf("Warning: Client %s player ID %s not found in current game.Players. Sending empty hand.", c.conn.RemoteAddr(), c.player.ID)
-			}
-		} else {
-			// Observer or unassigned client, receives empty hand
-		}
-
-		// playerInfoList is constructed for each client payload if it needs to be specific
-		// or can be constructed once outside the loop if it's identical for all.
-		// For simplicity here, assuming it's general player info.
-		playerInfoListPayload := make([]map[string]interface{}, len(game.Players))
-		for i, p := range game.Players {
-			playerInfoListPayload[i] = map[string]interface{}{
-				"id":        p.ID,   // Already camelCase from Player struct tag
-				"name":      p.Name, // Already camelCase from Player struct tag
-				"cardCount": len(p.Hand),
-				"hasPassed": p.HasPassed, // Already camelCase from Player struct tag
-			}
-		}
-
-		payload := struct {
-			Type              string                   `json:"type"`
-			Hand              Deck                     `json:"hand"`
-			LastPlayedHand    *PlayedHand              `json:"lastPlayedHand"`
-			CurrentPlayerID   string                   `json:"currentPlayerId"`
-			CurrentPlayerName string                   `json:"currentPlayerName"`
-			YourPlayerID      string                   `json:"yourPlayerId"`
-			PassCount         int                      `json:"passCount"`
-			PlayersInfo       []map[string]interface{} `json:"playersInfo"`
-			GameMessage       string                   `json:"gameMessage,omitempty"`
-			IsGameOver        bool                     `json:"isGameOver"`
-			WinnerID          string                   `json:"winnerId,omitempty"`
-			Scores            map[string]int           `json:"scores,omitempty"`
-
-			// New fields for multi-round/match payload
-			RoundNumber        int              `json:"roundNumber"`
-			TargetScore        int              `json:"targetScore"`
-			IsMatchOver        bool             `json:"isMatchOver"`
-			OverallWinnerID    string           `json:"overallWinnerId,omitempty"`
-			RoundScoresHistory []map[string]int `json:"roundScoresHistory,omitempty"`
-		}{
-			Type:              "gameState",
-			Hand:              clientHand,
-			LastPlayedHand:    game.LastPlayedHand,
-			CurrentPlayerID:   currentPlayerID,
-			CurrentPlayerName: currentPlayerName,
-			YourPlayerID:      playerIDForClient,
-			PassCount:         game.PassCount,
-			PlayersInfo:       playerInfoListPayload,
-			GameMessage:       "",
-			IsGameOver:        game.IsGameOver,
-			WinnerID:          game.WinnerID,
-			Scores:            game.Scores,
-
-			// New fields for multi-round/match payload
-			RoundNumber:        game.RoundNumber,
-			TargetScore:        game.TargetScore,
-			IsMatchOver:        game.IsMatchOver,
-			OverallWinnerID:    game.OverallWinnerID,
-			RoundScoresHistory: game.RoundScoresHistory,
-		}
-
-		log.Printf("DEBUG: Preparing payload for client. PlayerIDForClient: %s. Hand size: %d. LastPlayedHand: %v. RoundScoresHistory items: %d", playerIDForClient, len(clientHand), game.LastPlayedHand != nil, len(game.RoundScoresHistory))
-		jsonData, err := json.Marshal(payload)
+		view := NewGameView(game, c.viewer())
+		jsonData, err := json.Marshal(view)
 		if err != nil {
-			log.Printf("FATAL_ERROR Marshalling game state for client %s (player ID %s): %v. Payload: %+v", c.conn.RemoteAddr(), playerIDForClient, err, payload)
+			gameLog(game).Error("cannot marshal game state", "viewer", view.YourPlayerID, "err", err)
+			failed++
 			continue
 		}
-
-		if err := c.conn.WriteMessage(websocket.TextMessage, jsonData); err != nil {
-			log.Printf("FATAL_ERROR writing game state to client %s (player ID %s): %v", c.conn.RemoteAddr(), playerIDForClient, err)
-		} else {
-			log.Printf("DEBUG: Successfully sent gameState to client %s (player ID %s)", c.conn.RemoteAddr(), playerIDForClient)
+		if err := c.write(websocket.TextMessage, jsonData); err != nil {
+			gameLog(game).Warn("cannot send game state", "viewer", view.YourPlayerID, "remote", c.conn.RemoteAddr().String(), "err", err)
+			failed++
 		}
 	}
-	log.Println("Broadcasted game state update.")
+	metrics.broadcastSeconds.ObserveSince(start)
+	gameLog(game).Debug("broadcast game state", "clients", len(clientsSnapshot), "failed", failed)
 }
 
 func handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	// Authenticate before upgrading so bad credentials get a plain HTTP 401.
+	account, authErr := authenticateHandshake(accountStore, r)
+	if authErr != nil {
+		slog.Info("websocket authentication failed", "remote", r.RemoteAddr, "err", authErr)
+		http.Error(w, authErr.Error(), http.StatusUnauthorized)
+		return
+	}
+
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Println("Upgrade error:", err)
+		slog.Warn("websocket upgrade failed", "remote", r.RemoteAddr, "err", err)
 		return
 	}
 
-	currentWsClient := &client{conn: conn}
+	readLimit := maxMessageSize
+	conn.SetReadLimit(readLimit)
+	currentWsClient := &client{conn: conn, identity: seatIdentity(account, r.RemoteAddr), host: hostIdentity(r.RemoteAddr)}
+	limiter := newConnLimiter(messageRateLimits, maxViolations, time.Now())
 	var assignedPlayer *Player
+	connLog := slog.With("remote", conn.RemoteAddr().String())
 
 	// Assign player (critical section, uses gameInstanceMutex and clientsMu)
 	gameInstanceMutex.Lock()
 	clientsMu.Lock()
-	if gameInstance != nil && gameInstance.Players != nil {
-		for _, p := range gameInstance.Players {
-			isSlotTaken := false
-			for _, cl := range clients {
-				if cl.player == p {
-					isSlotTaken = true
-					break
-				}
-			}
-			if !isSlotTaken {
-				assignedPlayer = p
-				currentWsClient.player = p
-				clients[conn] = currentWsClient
-				break
-			}
+	game := lookupTable(r.URL.Query().Get("table"))
+	currentWsClient.game = game
+	kicked := game != nil && (game.kicked[currentWsClient.identity] || game.kicked[currentWsClient.host])
+	if game != nil && game.Players != nil && !kicked {
+		assignedPlayer = assignSeat(game, account)
+		if assignedPlayer != nil {
+			currentWsClient.player = assignedPlayer
+			clients[conn] = currentWsClient
 		}
 	}
+	if assignedPlayer != nil {
+		connLog = gameLog(game).With("player", assignedPlayer.ID, "remote", conn.RemoteAddr().String())
+	}
 	clientsMu.Unlock()
 	gameInstanceMutex.Unlock() // Unlock after initial player assignment setup
 
@@ -220,7 +182,6 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		clientInfo := clients[conn]
 		if clientInfo != nil && clientInfo.player != nil {
 			disconnectedPlayerName = clientInfo.player.Name
-			log.Printf("Player %s (%s) WebSocket disconnecting.", clientInfo.player.ID, disconnectedPlayerName)
 			// No gameInstance state modification for player.IsConnected here needed in defer
 		}
 		delete(clients, conn)
@@ -230,89 +191,106 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		clientsMu.Unlock()
 
 		conn.Close()
-		log.Println("Client connection closed and removed:", conn.RemoteAddr())
+		connLog.Info("client disconnected")
 
 		// Broadcast player disconnect system message if a player was associated
 		if disconnectedPlayerName != "" {
-			disconnectionMsg := fmt.Sprintf("%s has disconnected.", disconnectedPlayerName)
-			chatPayload := map[string]string{"type": "chat", "sender": "System", "content": disconnectionMsg}
-			jsonMsg, _ := json.Marshal(chatPayload)
-
-			// We need to lock clientsMu again for broadcastMessage if it iterates the live map.
-			// Or, ensure broadcastMessage uses a snapshot taken while locked.
-			// Given broadcastMessage's current implementation, it locks itself.
-			broadcastMessage(websocket.TextMessage, jsonMsg, nil) // Sender is nil (System)
+			// broadcastSystemMessage locks clientsMu itself.
+			broadcastSystemMessage(game, fmt.Sprintf("%s has disconnected.", disconnectedPlayerName))
 		}
 	}()
 
 	if assignedPlayer == nil {
-		log.Println("No available player slot for new client or game not ready. Disconnecting client:", conn.RemoteAddr())
+		connLog.Info("no seat available", "table", r.URL.Query().Get("table"), "kicked", kicked)
 		errMsg := `{"type": "error", "content": "Sorry, the game is full or not available."}`
-		if connErr := conn.WriteMessage(websocket.TextMessage, []byte(errMsg)); connErr != nil {
-			log.Printf("Error sending game full message to %s: %v", conn.RemoteAddr(), connErr)
+		if kicked {
+			errMsg = `{"type": "error", "content": "You were removed from this table by an administrator and cannot rejoin until the match ends."}`
+		}
+		if connErr := currentWsClient.write(websocket.TextMessage, []byte(errMsg)); connErr != nil {
+			connLog.Warn("cannot send table full message", "err", connErr)
 		}
 		return // Return directly, defer will handle cleanup
 	}
 
-	log.Printf("Client %s connected and assigned to Player %s (%s)", conn.RemoteAddr(), assignedPlayer.ID, assignedPlayer.Name)
+	connLog.Info("client connected", "name", assignedPlayer.Name, "account", assignedPlayer.AccountID)
 
 	// Broadcast player connection system message
-	connectionMsg := fmt.Sprintf("%s has connected.", assignedPlayer.Name)
-	chatPayload := map[string]string{"type": "chat", "sender": "System", "content": connectionMsg}
-	jsonMsg, _ := json.Marshal(chatPayload)
-	// clientsMu.Lock() // broadcastMessage handles its own locking
-	broadcastMessage(websocket.TextMessage, jsonMsg, nil)
-	// clientsMu.Unlock()
+	broadcastSystemMessage(game, fmt.Sprintf("%s has connected.", assignedPlayer.Name))
 
 	// Send initial game state to this newly connected player
-	log.Printf("DEBUG: About to send initial game state to player %s. Game instance players: %d. Client player ID: %s", assignedPlayer.ID, len(gameInstance.Players), currentWsClient.player.ID)
 	gameInstanceMutex.Lock()
-	broadcastGameState(gameInstance)
+	broadcastGameState(game)
 	gameInstanceMutex.Unlock()
 
 	for {
 		_, msgBytes, err := conn.ReadMessage()
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("Read error (client %s, player %s): %v", conn.RemoteAddr(), assignedPlayer.ID, err)
+			if errors.Is(err, websocket.ErrReadLimit) {
+				metrics.rejected.Inc("message_too_large") // The client gets a close frame saying so
+				connLog.Warn("message too large; disconnecting", "limit", readLimit)
+			} else if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				connLog.Warn("websocket read failed", "err", err)
 			} else {
-				log.Printf("Client %s (player %s) initiated disconnect.", conn.RemoteAddr(), assignedPlayer.ID)
+				connLog.Debug("client closed the connection")
 			}
 			break // Exit loop, defer will clean up client
 		}
 
 		var receivedMsg map[string]interface{}
 		if err := json.Unmarshal(msgBytes, &receivedMsg); err != nil {
-			log.Printf("Error unmarshalling message from Player %s: %v. Message: %s", assignedPlayer.ID, err, string(msgBytes))
-			conn.WriteMessage(websocket.TextMessage, []byte(`{"type": "error", "content": "Malformed JSON."}`))
+			connLog.Info("malformed message", "err", err, "message", string(msgBytes))
+			rejectAction(currentWsClient, "malformed_json", "Malformed JSON.")
+			if limiter.violation(time.Now()) {
+				disconnectAbusive(currentWsClient, connLog)
+				break
+			}
 			continue
 		}
 
 		msgType, typeOk := receivedMsg["type"].(string)
 		if !typeOk {
-			log.Printf("Message from Player %s missing 'type'. Msg: %s", assignedPlayer.ID, string(msgBytes))
-			conn.WriteMessage(websocket.TextMessage, []byte(`{"type": "error", "content": "Message missing 'type' field."}`))
+			connLog.Info("message without a type", "message", string(msgBytes))
+			rejectAction(currentWsClient, "missing_type", "Message missing 'type' field.")
+			if limiter.violation(time.Now()) {
+				disconnectAbusive(currentWsClient, connLog)
+				break
+			}
 			continue
 		}
 
-		log.Printf("Parsed message type \"%s\" from Player %s", msgType, assignedPlayer.ID)
-
+		countAction(msgType)
+		if ok, retryAfter := limiter.allow(msgType, time.Now()); !ok {
+			connLog.Info("message rate limited", "action", msgType)
+			sendLimitError(currentWsClient, "rate_limited", "rateLimited", fmt.Sprintf("Too many %s messages; slow down.", msgType), retryAfter)
+			if limiter.violation(time.Now()) {
+				disconnectAbusive(currentWsClient, connLog)
+				break
+			}
+			continue
+		}
 		gameInstanceMutex.Lock() // Lock game state for the duration of the action processing
+		msgLog := playerLog(game, assignedPlayer).With("action", msgType)
+		msgLog.Debug("message received")
 
-		if gameInstance == nil || gameInstance.Players == nil || gameInstance.CurrentTurnPlayerIndex < 0 || gameInstance.CurrentTurnPlayerIndex >= len(gameInstance.Players) {
-			log.Printf("Game not ready or invalid turn index for %s from Player %s", msgType, assignedPlayer.ID)
-			conn.WriteMessage(websocket.TextMessage, []byte(`{"type": "error", "content": "Game not ready to process action."}`))
-			log.Println("DEBUG: Explicit unlock before 'game not ready' continue in main loop")
+		if game.Players == nil || game.CurrentTurnPlayerIndex < 0 || game.CurrentTurnPlayerIndex >= len(game.Players) {
+			msgLog.Warn("game not ready for action", "turnIndex", game.CurrentTurnPlayerIndex)
+			rejectAction(currentWsClient, "game_not_ready", "Game not ready to process action.")
+			gameInstanceMutex.Unlock()
+			continue
+		}
+		if game.Paused && !allowedWhilePaused[msgType] {
+			rejectAction(currentWsClient, "paused", "The table is paused.")
 			gameInstanceMutex.Unlock()
 			continue
 		}
-		currentPlayerInGame := gameInstance.Players[gameInstance.CurrentTurnPlayerIndex]
+		currentPlayerInGame := game.Players[game.CurrentTurnPlayerIndex]
 
 		actionCtx := &ActionContext{
-			Game:         gameInstance,
-			Clients:      clients,    // Pass the global clients map
-			ClientsMu:    &clientsMu, // Pass the mutex for it
-			AssignedConn: conn,
+			Game:           game,
+			Clients:        clients,    // Pass the global clients map
+			ClientsMu:      &clientsMu, // Pass the mutex for it
+			AssignedClient: currentWsClient,
+			Log:            msgLog,
 		}
 
 		var shouldContinueLoop, needsBroadcast bool
@@ -322,69 +300,73 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 			processChatAction(actionCtx, assignedPlayer, receivedMsg)
 			needsBroadcast = false     // Chat doesn't trigger game state broadcast
 			shouldContinueLoop = false // Chat doesn't make the main loop continue
-			log.Println("DEBUG: chat - explicit unlock at end of case")
 			gameInstanceMutex.Unlock()
 
 		case "playCards":
 			shouldContinueLoop, needsBroadcast = processPlayCardsAction(actionCtx, assignedPlayer, currentPlayerInGame, receivedMsg)
-			if shouldContinueLoop {
-				log.Println("DEBUG: playCards - explicit unlock because handler signaled continue")
-				gameInstanceMutex.Unlock()
-			} else {
-				log.Println("DEBUG: playCards - explicit unlock at end of successful processing by handler")
-				gameInstanceMutex.Unlock()
-			}
+			gameInstanceMutex.Unlock()
 
 		case "passTurn":
 			shouldContinueLoop, needsBroadcast = processPassTurnAction(actionCtx, assignedPlayer, currentPlayerInGame, receivedMsg)
-			if shouldContinueLoop {
-				log.Println("DEBUG: passTurn - explicit unlock because handler signaled continue")
-				gameInstanceMutex.Unlock()
-			} else {
-				log.Println("DEBUG: passTurn - explicit unlock at end of successful processing by handler")
-				gameInstanceMutex.Unlock()
-			}
+			gameInstanceMutex.Unlock()
+
+		case "exchangeCards":
+			shouldContinueLoop, needsBroadcast = processExchangeCardsAction(actionCtx, assignedPlayer, receivedMsg)
+			gameInstanceMutex.Unlock()
+
+		case "queueAction":
+			shouldContinueLoop, needsBroadcast = processQueueAction(actionCtx, assignedPlayer, receivedMsg)
+			gameInstanceMutex.Unlock()
+
+		case "requestUndo":
+			shouldContinueLoop, needsBroadcast = processRequestUndoAction(actionCtx, assignedPlayer)
+			gameInstanceMutex.Unlock()
+
+		case "respondUndo":
+			shouldContinueLoop, needsBroadcast = processRespondUndoAction(actionCtx, assignedPlayer, receivedMsg)
+			gameInstanceMutex.Unlock()
+
+		case "pause", "resume":
+			shouldContinueLoop, needsBroadcast = processPauseAction(actionCtx, assignedPlayer, msgType == "pause")
+			gameInstanceMutex.Unlock()
+
+		case "callVote":
+			shouldContinueLoop, needsBroadcast = processCallVoteAction(actionCtx, assignedPlayer, receivedMsg)
+			gameInstanceMutex.Unlock()
+
+		case "castVote":
+			shouldContinueLoop, needsBroadcast = processCastVoteAction(actionCtx, assignedPlayer, receivedMsg)
+			gameInstanceMutex.Unlock()
 
 		case "newGame":
 			// No specific player context needed for newGame, but actionCtx provides gameInstance
 			// assignedPlayer and currentPlayerInGame are not strictly used by processNewGameAction
 			shouldContinueLoop, needsBroadcast = processNewGameAction(actionCtx)
-			// processNewGameAction always returns shouldContinueLoop = false
-			log.Println("DEBUG: newGame - explicit unlock at end of processing by handler")
 			gameInstanceMutex.Unlock()
 
 		case "setAlias":
-			log.Printf("Received setAlias action from %s", assignedPlayer.ID)
 			var aliasData struct { // Define struct for parsing alias
 				Alias string `json:"alias"`
 			}
 			if err := json.Unmarshal(msgBytes, &aliasData); err != nil {
-				log.Printf("Error unmarshalling setAlias payload: %v", err)
+				msgLog.Info("malformed setAlias payload", "err", err)
 				// Optionally send an error back to the client
 				shouldContinueLoop = false // Ensure loop continues
 				gameInstanceMutex.Unlock()
 				break
 			}
 
-			// Sanitize alias basic
-			alias := strings.TrimSpace(aliasData.Alias)
-			if len(alias) == 0 {
-				alias = assignedPlayer.ID // Default to ID if empty after trim
-			} else if len(alias) > 20 { // Max length example
-				alias = alias[:20]
-			}
-			assignedPlayer.Name = alias
-			log.Printf("Player %s set alias to %s", assignedPlayer.ID, assignedPlayer.Name)
+			assignedPlayer.Name = sanitizeAlias(aliasData.Alias, assignedPlayer.ID)
+			msgLog.Info("alias set", "name", assignedPlayer.Name)
 			shouldContinueLoop = true
 			needsBroadcast = true
 			gameInstanceMutex.Unlock()
 
 		default:
-			log.Printf("Received unhandled message type \"%s\" from Player %s", msgType, assignedPlayer.ID)
-			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"type": "error", "content": "Unknown message type: %s"}`, msgType)))
+			msgLog.Info("unknown message type")
+			rejectAction(currentWsClient, "unknown_type", fmt.Sprintf("Unknown message type: %s", msgType))
 			needsBroadcast = false
 			shouldContinueLoop = false
-			log.Println("DEBUG: default case - explicit unlock")
 			gameInstanceMutex.Unlock()
 		}
 
@@ -396,7 +378,7 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 			// So, we MUST re-acquire the lock here for the broadcast if gameInstance is read inside broadcastGameState.
 			// This is crucial for data consistency during broadcast.
 			gameInstanceMutex.Lock()
-			broadcastGameState(gameInstance)
+			broadcastGameState(game)
 			gameInstanceMutex.Unlock()
 		}
 
@@ -407,142 +389,159 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func broadcastMessage(messageType int, message []byte, sender *websocket.Conn) {
-	clientsMu.Lock()
-	defer clientsMu.Unlock()
-	for conn := range clients {
-		// if conn == sender { continue } // Uncomment to avoid sending echo to original sender for some message types
-		if err := conn.WriteMessage(messageType, message); err != nil {
-			log.Println("Broadcast write error:", err)
-		}
-	}
+// allowedWhilePaused are the messages handled while a table is paused: talking, and
+// deciding whether and how to carry on.
+var allowedWhilePaused = map[string]bool{
+	"chat": true, "setAlias": true, "pause": true, "resume": true, "callVote": true, "castVote": true,
 }
 
-func main() {
-	fmt.Println("Starting Big Two game server...")
+// sanitizeAlias trims a display name and limits it to 20 bytes, falling back to the
+// player's ID when nothing is left.
+func sanitizeAlias(alias, playerID string) string {
+	alias = strings.TrimSpace(alias)
+	if len(alias) == 0 {
+		return playerID
+	}
+	if len(alias) > 20 {
+		alias = alias[:20]
+	}
+	return alias
+}
 
-	fs := http.FileServer(http.Dir("./static"))
-	http.Handle("/", fs)
-	http.HandleFunc("/ws", handleWebSocket)
+// broadcastSystemMessage sends a chat message from "System" to all clients seated at the table.
+func broadcastSystemMessage(game *GameState, content string) {
+	chatPayload := map[string]string{"type": "chat", "sender": "System", "content": content}
+	jsonMsg, _ := json.Marshal(chatPayload)
+	broadcastTableMessage(game, websocket.TextMessage, jsonMsg)
+}
 
-	go func() {
-		log.Println("Web server starting on :8080")
-		if err := http.ListenAndServe(":8080", nil); err != nil {
-			log.Fatal("ListenAndServe: ", err)
+// sendToTeam sends a message to the connected members of the player's team (including the player).
+func sendToTeam(game *GameState, playerID string, message []byte) {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	for _, c := range clients {
+		if c.game != game || c.player == nil || (c.player.ID != playerID && !game.areTeammates(c.player.ID, playerID)) {
+			continue
 		}
-	}()
+		if err := c.write(websocket.TextMessage, message); err != nil {
+			gameLog(game).Warn("cannot send team message", "player", c.player.ID, "err", err)
+		}
+	}
+}
 
-	fmt.Println("Initializing game instance for web...")
-	deck := NewDeck()
-	deck.Shuffle()
-
-	// === Single Player Debug Mode: Initialize only one player ===
-	const singlePlayerDebug = true // Set to false for multiplayer
-	var players []*Player
-	if singlePlayerDebug {
-		log.Println("INFO: Initializing in SINGLE PLAYER debug mode.")
-		players = []*Player{
-			NewPlayer(1, "Player1"), NewPlayer(2, "P2"),
+// broadcastTableMessage sends a message to all clients seated at the table.
+func broadcastTableMessage(game *GameState, messageType int, message []byte) {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	for _, c := range clients {
+		if c.game != game {
+			continue
 		}
-	} else {
-		players = []*Player{
-			NewPlayer(1, "P1"), NewPlayer(2, "P2"), NewPlayer(3, "P3"), NewPlayer(4, "P4"),
+		if err := c.write(messageType, message); err != nil {
+			gameLog(game).Warn("cannot send table message", "remote", c.conn.RemoteAddr().String(), "err", err)
 		}
 	}
-	// ==========================================================
+}
 
-	cardsPerPlayer := 0
-	if len(players) > 0 {
-		cardsPerPlayer = len(deck) / len(players) // Deal all cards if single player, or 13 for 4 players
-	} else {
-		log.Fatalln("No players initialized!")
+func broadcastMessage(messageType int, message []byte, sender *websocket.Conn) {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	for _, c := range clients {
+		// if c.conn == sender { continue } // Uncomment to avoid sending echo to original sender for some message types
+		if err := c.write(messageType, message); err != nil {
+			slog.Warn("cannot send broadcast message", "remote", c.conn.RemoteAddr().String(), "err", err)
+		}
 	}
+}
 
-	if len(deck) < cardsPerPlayer*len(players) && !singlePlayerDebug { // Check only needed for multiplayer with fixed deal
-		log.Fatalf("Not enough cards for %d players with %d cards each.", len(players), cardsPerPlayer)
+func main() {
+	cfg, err := LoadConfig(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		fs := DefaultConfig().flagSet(new(string))
+		fs.SetOutput(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Usage of big-two (every flag can also be set as BIGTWO_<FLAG>, e.g. BIGTWO_TLS_CERT):")
+		fs.PrintDefaults()
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid configuration:", err)
+		os.Exit(2)
+	}
+	if err := setupLogging(os.Stderr, cfg.LogLevel, cfg.LogFormat); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	slog.Info("starting Big Two game server")
+	if info, err := os.Stat(cfg.StaticDir); err != nil || !info.IsDir() {
+		slog.Warn("static directory not found; only the APIs will be served", "dir", cfg.StaticDir)
 	}
+	configureUpgrader(cfg)
+	maxMessageSize = cfg.MaxMessageSize
+	messageRateLimits = cfg.RateLimits
+	maxViolations = cfg.MaxViolations
+	undoRequestTimeout = time.Duration(cfg.UndoTimeout)
+	voteTimeout = time.Duration(cfg.VoteTimeout)
+	sessionLifetime = time.Duration(cfg.SessionTTL)
+
+	fs := http.FileServer(http.Dir(cfg.StaticDir))
+	http.Handle("/", fs)
+	http.HandleFunc("/ws", handleWebSocket)
+	http.HandleFunc("/api/register", handleRegister)
+	http.HandleFunc("/api/login", handleLogin)
+	http.HandleFunc("POST /api/logout", handleLogout)
+	http.HandleFunc("GET /api/players/{id}/stats", handlePlayerStats)
+	http.HandleFunc("GET /api/leaderboard", handleLeaderboard)
+	http.HandleFunc("POST /api/tournaments", handleCreateTournament)
+	http.HandleFunc("GET /api/tournaments/{id}", handleGetTournament)
+	http.HandleFunc("POST /api/tournaments/{id}/{action}", handleTournamentControl)
+	registerAdminRoutes(http.DefaultServeMux)
+	http.HandleFunc("GET /metrics", handleMetrics)
+
+	store, err := OpenAccountStore(filepath.Join(cfg.DataDir, accountStoreFile))
+	if err != nil {
+		slog.Error("cannot open account store", "err", err)
+		os.Exit(1)
+	}
+	accountStore = store
 
-	for i, player := range players {
-		hand, dealt := deck.Deal(cardsPerPlayer)
-		if !dealt {
-			log.Fatalf("Failed to deal %d cards to player %s", cardsPerPlayer, player.ID)
-		}
-		player.Hand = hand
-		player.Hand.Sort()
-		player.OrderInTurn = i // Assign turn order index explicitly
+	adminToken = os.Getenv("BIGTWO_ADMIN_TOKEN") // Kept out of flags and the config file
+	if adminToken == "" {
+		slog.Info("BIGTWO_ADMIN_TOKEN is not set; the admin API is disabled")
+	}
+	audit, err := OpenAuditLog(filepath.Join(cfg.DataDir, auditLogFile))
+	if err != nil {
+		slog.Error("cannot open admin audit log", "err", err)
+		os.Exit(1)
 	}
+	adminAudit = audit
 
-	var startingPlayerIndex int
-	if singlePlayerDebug && len(players) == 1 {
-		startingPlayerIndex = 0
-		log.Println("SINGLE PLAYER MODE: Player1 starts.")
-		// Optional: Check if player 0 has 3D for log consistency, though they get all cards.
-		found3D := false
-		cardToFind := Card{Rank: Rank3, Suit: Diamonds}
-		for _, card := range players[0].Hand {
-			if card == cardToFind {
-				found3D = true
-				break
-			}
-		}
-		if found3D {
-			log.Println("SINGLE PLAYER MODE: Player1 has the 3 of Diamonds.")
+	go func() {
+		slog.Info("web server starting", "addr", cfg.Addr, "tls", cfg.TLSCert != "")
+		var err error
+		if cfg.TLSCert != "" {
+			err = http.ListenAndServeTLS(cfg.Addr, cfg.TLSCert, cfg.TLSKey, nil)
 		} else {
-			log.Println("SINGLE PLAYER MODE: Player1 does not have 3 of Diamonds (should have with full deck), but starts anyway.")
+			err = http.ListenAndServe(cfg.Addr, nil)
 		}
-	} else if len(players) > 0 { // Multiplayer logic
-		startingPlayerIndex = -1
-		cardToFind := Card{Rank: Rank3, Suit: Diamonds}
-		for i, player := range players {
-			for _, card := range player.Hand {
-				if card == cardToFind {
-					startingPlayerIndex = i
-					break
-				}
-			}
-			if startingPlayerIndex != -1 {
-				break
-			}
-		}
-		if startingPlayerIndex == -1 {
-			log.Println("CRITICAL: 3 of Diamonds not found in any hand. Defaulting to Player 0 to start.")
-			startingPlayerIndex = 0
-		}
-	} else {
-		log.Fatalln("No players to determine starting index.")
-	}
-
-	initialGameState := &GameState{
-		Players:                players,
-		CurrentTurnPlayerIndex: startingPlayerIndex,
-		LastPlayedHand:         nil,
-		RuleEngine:             NewBigTwoRuleEngine(),
-		PassCount:              0,
-		IsGameOver:             false, // Round is not over initially
-		WinnerID:               "",
-		Scores:                 make(map[string]int), // Overall scores, init empty or to 0 for players
-		RoundNumber:            1,
-		TargetScore:            100, // Default target score (penalty limit)
-		IsMatchOver:            false,
-		OverallWinnerID:        "",
-	}
-	gameInstance = initialGameState
-
-	// Initialize scores to 0 for all players
-	for _, p := range gameInstance.Players {
-		gameInstance.Scores[p.ID] = 0
-	}
-
-	log.Println("Game state initialized. Server running. Waiting for WebSocket connections...")
-	if startingPlayerIndex != -1 && len(gameInstance.Players) > 0 {
-		log.Printf("Player %s (%s) should start. CurrentTurnPlayerIndex: %d",
-			gameInstance.Players[startingPlayerIndex].Name,
-			gameInstance.Players[startingPlayerIndex].ID,
-			gameInstance.CurrentTurnPlayerIndex)
-	} else {
-		log.Println("Game starting condition not fully met (e.g. no players or no 3D found and no default). Check logs.")
+		slog.Error("web server stopped", "err", err)
+		os.Exit(1)
+	}()
+
+	players := make([]*Player, cfg.Players)
+	for i := range players {
+		players[i] = NewPlayer(i+1, fmt.Sprintf("P%d", i+1))
+	}
+	if len(players) == 1 {
+		slog.Info("initializing in single player debug mode")
 	}
 
+	gameInstanceMutex.Lock()
+	settings, _ := cfg.TableConfig.Settings() // Validated by LoadConfig
+	gameInstance = NewGameStateWithSettings(defaultTableID, players, cfg.TargetScore, settings)
+	registerTable(gameInstance)
+	gameLog(gameInstance).Info("table ready", "ruleset", gameInstance.RuleEngine.Options.Name, "scoring", gameInstance.Scoring.Name(), "endMode", gameInstance.MatchRules.EndMode.String(), "players", len(gameInstance.Players))
+	gameInstanceMutex.Unlock()
+
 	select {}
 }
 
@@ -550,107 +549,133 @@ func main() {
 // It uses the existing player objects but deals new hands and resets round-specific game variables.
 // Overall scores, RoundNumber, TargetScore, and IsMatchOver are NOT reset here.
 func resetRoundState(game *GameState) {
-	log.Println("Resetting state for next round...")
 	if game == nil || game.Players == nil {
-		log.Println("ERROR: Cannot reset round state for nil game instance or game with nil players.")
+		slog.Error("cannot reset round state without a game or players")
 		return
 	}
 
-	newDeck := NewDeck()
+	previousWinnerID := game.WinnerID // Cleared below; needed for the card exchange
+	newDeck := NewDeck()              // Jokers are dealt on top; see dealJokers
 	newDeck.Shuffle()
 
+	// Eliminated players sit out; cards are dealt among the remaining players only.
+	activePlayers := game.activePlayerCount()
 	cardsPerPlayer := 0
-	if len(game.Players) > 0 {
+	if activePlayers > 0 {
 		// Determine cards per player (e.g. 13 for 4p, 52/n for other counts)
-		if len(game.Players) == 4 {
+		if activePlayers == 4 {
 			cardsPerPlayer = 13
-		} else if len(game.Players) > 0 { // Ensure no division by zero if player count is manipulated
-			cardsPerPlayer = len(newDeck) / len(game.Players)
+		} else if activePlayers > 0 { // Ensure no division by zero if player count is manipulated
+			cardsPerPlayer = len(newDeck) / activePlayers
 		} else {
-			log.Println("ERROR: No players to deal cards to during round reset.")
+			gameLog(game).Error("no players to deal cards to")
 			return
 		}
 	} else {
-		log.Println("ERROR: No players in game instance to deal cards to during round reset.")
+		gameLog(game).Error("no players to deal cards to")
 		return
 	}
 
 	// Reset player-specific states and deal new hands
 	for _, player := range game.Players {
+		player.HasPassed = false
+		if player.IsEliminated {
+			player.Hand = Deck{}
+			continue
+		}
 		hand, dealt := newDeck.Deal(cardsPerPlayer)
 		if !dealt {
-			log.Printf("ERROR: Failed to deal %d cards to player %s during round reset.", cardsPerPlayer, player.ID)
+			playerLog(game, player).Error("cannot deal cards", "count", cardsPerPlayer)
 			player.Hand = Deck{}
 		} else {
 			player.Hand = hand
 			player.Hand.Sort()
 		}
-		player.HasPassed = false
 	}
-
-	// Determine starting player (e.g., with 3 of Diamonds)
-	startingPlayerIndex := 0 // Default
-	// Logic for finding 3 of Diamonds (same as initial setup)
-	// This part of your existing resetGameInstance was good.
-	if len(game.Players) == 1 { // Special case for single player debug/testing
-		startingPlayerIndex = 0
-	} else if len(game.Players) > 1 {
-		found3D := false
-		cardToFind := Card{Rank: Rank3, Suit: Diamonds}
-		for i, p := range game.Players {
-			for _, card := range p.Hand {
-				if card == cardToFind {
-					startingPlayerIndex = i
-					found3D = true
-					break
-				}
-			}
-			if found3D {
-				break
-			}
-		}
-		if !found3D {
-			log.Println("WARNING: 3 of Diamonds not found in any hand after reset for new round. Defaulting to Player 0 to start.")
-			startingPlayerIndex = 0 // Fallback if 3D somehow isn't dealt
-		}
+	dealJokers(game, game.RuleEngine.Options.Jokers)
+	game.dealtHandSizes = make(map[string]int, len(game.Players))
+	for _, player := range game.Players {
+		game.dealtHandSizes[player.ID] = len(player.Hand)
 	}
 
+	setOpeningPlayer(game)
+
 	// Reset round-specific game variables
-	game.CurrentTurnPlayerIndex = startingPlayerIndex
 	game.LastPlayedHand = nil
 	game.PassCount = 0
 	game.IsGameOver = false // Round is starting
 	game.WinnerID = ""      // No round winner yet
+	game.roundStartedAt = time.Now()
+	game.Penalties = make(map[string]int)
+	game.RoundPlays = make(map[string]map[HandType]int)
+	game.FinishOrder = nil
+	game.Exchange = nil
+	game.lastAction = nil
+	cancelUndoRequest(game)
+	game.QueuedActions = nil
+	if !checkInstantWin(game) {
+		startCardExchange(game, previousWinnerID)
+	}
 	// game.Scores are overall scores and are NOT reset here
 	// game.RoundNumber is incremented by caller (processNewGameAction)
 	// game.TargetScore, game.IsMatchOver, game.OverallWinnerID are NOT reset here
 
-	log.Printf("Round state reset. Player %s (%s) to start. CurrentTurnPlayerIndex: %d",
-		game.Players[startingPlayerIndex].Name,
-		game.Players[startingPlayerIndex].ID,
-		game.CurrentTurnPlayerIndex)
+	gameLog(game).Info("round dealt", "leader", game.Players[game.CurrentTurnPlayerIndex].ID)
+}
+
+// setOpeningPlayer gives the first turn to whoever holds the lowest card dealt
+// (the 3 of Diamonds in a full deal) and records that card as the required opening card.
+func setOpeningPlayer(game *GameState) {
+	startingPlayerIndex := 0 // Default
+	game.OpeningCard = nil
+	if len(game.Players) == 1 { // Special case for single player debug/testing
+		startingPlayerIndex = 0
+	} else if game.activePlayerCount() > 1 {
+		lowestIndex, lowestCard, found := findPlayerWithLowestCard(game.Players, game.RuleEngine.CardLess)
+		if found {
+			startingPlayerIndex = lowestIndex
+			game.OpeningCard = &lowestCard
+			gameLog(game).Debug("opening card", "card", lowestCard.String())
+		} else {
+			gameLog(game).Warn("no cards dealt; player 0 leads")
+			startingPlayerIndex = 0
+		}
+	}
+	game.CurrentTurnPlayerIndex = startingPlayerIndex
 }
 
 // resetMatchState resets the game to a brand new match state.
-// This includes resetting overall scores, round number, etc.
+// This includes resetting overall scores, round number, etc. From the second match on,
+// seats bound to accounts that are no longer connected are released.
 func resetMatchState(game *GameState) {
-	log.Println("Resetting full match state...")
+	if !game.MatchStartedAt.IsZero() {
+		releaseIdleSeats(game) // A table's first match keeps the seats it was set up with
+	}
 	game.RoundNumber = 1
 	game.IsGameOver = false
 	game.IsMatchOver = false
+	game.MatchAbandoned = false
+	cancelVote(game)
 	game.WinnerID = ""
 	game.OverallWinnerID = ""
+	game.OverallWinningTeamID = ""
 	game.LastPlayedHand = nil
 	game.PassCount = 0
 	game.Scores = make(map[string]int)
 	game.RoundScoresHistory = make([]map[string]int, 0) // Clear history for a new match
+	game.RoundWins = make(map[string]int)
+	game.RoundExchanges = nil
+	game.kicked = nil
+	game.forcedRound = false
+	game.MatchStartedAt = time.Now()
 
 	// Initialize scores for all players to 0 for the new match
 	for _, p := range game.Players {
 		game.Scores[p.ID] = 0
+		p.IsEliminated = false
 	}
 
 	// Now reset for the first round of the new match
 	resetRoundState(game)
-	log.Println("New match ready.")
+	gameLog(game).Info("new match")
 }
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MatchEndMode selects the condition that ends a match.
+type MatchEndMode int
+
+const (
+	EndAtTargetScore  MatchEndMode = iota // Match ends once any player's score reaches TargetScore
+	EndAfterRounds                        // Match ends after MaxRounds rounds
+	EndAtRoundWins                        // First player to win RoundWinsToWin rounds wins the match
+	EndAfterTimeLimit                     // Round in progress when TimeLimit expires is the last one
+	EndByElimination                      // Players reaching TargetScore are knocked out until one remains
+)
+
+var matchEndModeNames = []string{"targetScore", "fixedRounds", "roundWins", "timeLimit", "elimination"}
+
+// String returns the name of the match end mode.
+func (m MatchEndMode) String() string {
+	if m < 0 || int(m) >= len(matchEndModeNames) {
+		return "unknown"
+	}
+	return matchEndModeNames[m]
+}
+
+// ParseMatchEndMode returns the match end mode with the given name.
+func ParseMatchEndMode(name string) (MatchEndMode, error) {
+	for i, n := range matchEndModeNames {
+		if n == name {
+			return MatchEndMode(i), nil
+		}
+	}
+	return EndAtTargetScore, fmt.Errorf("unknown match end mode %q (available: %v)", name, matchEndModeNames)
+}
+
+// TieBreaker orders players whose match scores are equal.
+type TieBreaker int
+
+const (
+	TieBreakMostRoundWins TieBreaker = iota // More rounds won ranks higher
+	TieBreakBestLastRound                   // Lower score in the most recent round ranks higher
+	TieBreakFewestBigLoss                   // Fewer rounds with a doubled or tripled penalty ranks higher
+)
+
+var tieBreakerNames = []string{"mostRoundWins", "bestLastRound", "fewestBigLosses"}
+
+// String returns the name of the tie-breaker.
+func (tb TieBreaker) String() string {
+	if tb < 0 || int(tb) >= len(tieBreakerNames) {
+		return "unknown"
+	}
+	return tieBreakerNames[tb]
+}
+
+// ParseTieBreaker returns the tie-breaker with the given name.
+func ParseTieBreaker(name string) (TieBreaker, error) {
+	for i, n := range tieBreakerNames {
+		if n == name {
+			return TieBreaker(i), nil
+		}
+	}
+	return TieBreakMostRoundWins, fmt.Errorf("unknown tie-breaker %q (available: %v)", name, tieBreakerNames)
+}
+
+// defaultBigLossPoints is a big loss under standard scoring: 10 or more cards left, doubled.
+const defaultBigLossPoints = 20
+
+// MatchRules holds the configurable match-ending conditions for a table.
+// The score threshold for EndAtTargetScore and EndByElimination is GameState.TargetScore.
+type MatchRules struct {
+	EndMode        MatchEndMode
+	MaxRounds      int           // Used by EndAfterRounds
+	RoundWinsToWin int           // Used by EndAtRoundWins
+	TimeLimit      time.Duration // Used by EndAfterTimeLimit
+	// BigLossPoints is the round penalty counted as a big loss by TieBreakFewestBigLoss.
+	// Zero means defaultBigLossPoints.
+	BigLossPoints int
+	// TieBreakers are applied in order when the lowest scores are equal.
+	// Seat order is the final fallback.
+	TieBreakers []TieBreaker
+}
+
+// DefaultMatchRules returns the standard rules: play until someone reaches the target score.
+func DefaultMatchRules() MatchRules {
+	return MatchRules{
+		EndMode:       EndAtTargetScore,
+		BigLossPoints: defaultBigLossPoints,
+		TieBreakers:   []TieBreaker{TieBreakMostRoundWins, TieBreakBestLastRound, TieBreakFewestBigLoss},
+	}
+}
+
+// validate checks that the setting the end mode relies on is given.
+func (r MatchRules) validate() error {
+	switch {
+	case r.EndMode == EndAfterRounds && r.MaxRounds < 1:
+		return fmt.Errorf("%s matches need maxRounds of at least 1", r.EndMode)
+	case r.EndMode == EndAtRoundWins && r.RoundWinsToWin < 1:
+		return fmt.Errorf("%s matches need roundWinsToWin of at least 1", r.EndMode)
+	case r.EndMode == EndAfterTimeLimit && r.TimeLimit <= 0:
+		return fmt.Errorf("%s matches need a positive timeLimit", r.EndMode)
+	case r.BigLossPoints < 0:
+		return fmt.Errorf("bigLossPoints must not be negative, not %d", r.BigLossPoints)
+	}
+	return nil
+}
+
+// matchEndsAt returns when a time-limited match stops starting new rounds, or nil for other modes.
+func matchEndsAt(game *GameState) *time.Time {
+	if game.MatchRules.EndMode != EndAfterTimeLimit {
+		return nil
+	}
+	endsAt := game.MatchStartedAt.Add(game.MatchRules.TimeLimit)
+	if game.Paused { // The clock is stopped, so the end moves back for as long as the pause lasts
+		endsAt = endsAt.Add(time.Since(game.pausedAt))
+	}
+	return &endsAt
+}
+
+// playersInRound returns the number of players still holding cards this round.
+func (g *GameState) playersInRound() int {
+	count := 0
+	for _, p := range g.Players {
+		if !p.IsEliminated && len(p.Hand) > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// nextPlayerInRound returns the index of the next player after from who is still
+// holding cards this round, skipping eliminated players and players who have gone out.
+// If there is none it returns from.
+func (g *GameState) nextPlayerInRound(from int) int {
+	n := len(g.Players)
+	for step := 1; step <= n; step++ {
+		idx := (from + step) % n
+		if p := g.Players[idx]; !p.IsEliminated && len(p.Hand) > 0 {
+			return idx
+		}
+	}
+	return from
+}
+
+// passesToWinTrick is how many consecutive passes end the current trick: everyone
+// else still in the round, or everyone if the player who made the last play has gone out.
+func (g *GameState) passesToWinTrick() int {
+	if g.LastPlayedHand != nil {
+		if p := g.playerByID(g.LastPlayedHand.PlayerID); p != nil && len(p.Hand) == 0 {
+			return g.playersInRound()
+		}
+	}
+	return g.playersInRound() - 1
+}
+
+// activePlayerCount returns the number of players not eliminated from the match.
+func (g *GameState) activePlayerCount() int {
+	count := 0
+	for _, p := range g.Players {
+		if !p.IsEliminated {
+			count++
+		}
+	}
+	return count
+}
+
+// nextActivePlayerIndex returns the index of the next non-eliminated player after from.
+// If every other player is eliminated it returns from.
+func (g *GameState) nextActivePlayerIndex(from int) int {
+	n := len(g.Players)
+	for step := 1; step <= n; step++ {
+		idx := (from + step) % n
+		if !g.Players[idx].IsEliminated {
+			return idx
+		}
+	}
+	return from
+}
+
+// completeRound ends the round with the given winner and records its results: account
+// history, ratings and, on tournament tables, the tournament's progress.
+func completeRound(game *GameState, winner *Player) {
+	now := time.Now()
+	finishRound(game, winner, now)
+	recordRoundResults(accountStore, game, now)
+	recordMatchResults(accountStore, game, now)
+	updateRatings(accountStore, game)
+	if accountStore != nil {
+		accountStore.FlushLater()
+	}
+	if game.IsMatchOver && game.TournamentID != "" {
+		tournamentTableFinished(game)
+	}
+}
+
+// finishRound records the end of the round won by winner: it scores the round,
+// updates match totals and round wins, and checks the match end condition.
+// Assumes gameInstanceMutex is held by the caller.
+func finishRound(game *GameState, winner *Player, now time.Time) {
+	game.IsGameOver = true
+	game.WinnerID = winner.ID
+	if !game.roundStartedAt.IsZero() {
+		metrics.roundSeconds.Observe(now.Sub(game.roundStartedAt).Seconds())
+	}
+
+	// Calculate scores for the round
+	roundScores := CalculateScores(game)
+	gameLog(game).Info("round won", "player", winner.ID, "roundScores", roundScores)
+
+	// Append round scores to history
+	if game.RoundScoresHistory == nil {
+		game.RoundScoresHistory = make([]map[string]int, 0)
+	}
+	game.RoundScoresHistory = append(game.RoundScoresHistory, roundScores)
+	game.RoundExchanges = append(game.RoundExchanges, game.completedExchange())
+
+	if game.RoundWins == nil {
+		game.RoundWins = make(map[string]int)
+	}
+	game.RoundWins[winner.ID]++
+
+	for _, p := range game.Players {
+		if roundScore, ok := roundScores[p.ID]; ok {
+			game.Scores[p.ID] += roundScore // Add round score to overall score
+		}
+	}
+
+	if !matchShouldEnd(game, now) {
+		gameLog(game).Debug("match continues", "scores", game.Scores)
+		return
+	}
+
+	game.IsMatchOver = true
+	metrics.matchSeconds.Observe(matchElapsed(game, now).Seconds())
+	logger := gameLog(game).With("endMode", game.MatchRules.EndMode.String())
+	overallWinner := determineOverallWinner(game)
+	if overallWinner != nil {
+		game.OverallWinnerID = overallWinner.ID
+		if team := game.teamOf(overallWinner.ID); team != nil {
+			game.OverallWinningTeamID = team.ID
+		}
+		logger.Info("match over", "winner", overallWinner.ID, "scores", game.Scores)
+	} else {
+		logger.Error("match over without an overall winner", "scores", game.Scores)
+	}
+}
+
+// matchShouldEnd checks the table's match end condition after a round has been scored.
+// In elimination mode it also knocks out players who reached the target score.
+func matchShouldEnd(game *GameState, now time.Time) bool {
+	rules := game.MatchRules
+	switch rules.EndMode {
+	case EndAfterRounds:
+		return game.RoundNumber >= rules.MaxRounds
+	case EndAtRoundWins:
+		for _, p := range game.Players {
+			if game.RoundWins[p.ID] >= rules.RoundWinsToWin {
+				return true
+			}
+		}
+		return false
+	case EndAfterTimeLimit:
+		return matchElapsed(game, now) >= rules.TimeLimit
+	case EndByElimination:
+		for _, p := range game.Players {
+			if !p.IsEliminated && game.matchScore(p.ID) >= game.TargetScore {
+				p.IsEliminated = true
+				playerLog(game, p).Info("eliminated", "score", game.Scores[p.ID])
+			}
+		}
+		return game.activePlayerCount() <= 1
+	default: // EndAtTargetScore
+		for _, p := range game.Players {
+			// Any player (not just the winner of the round) reaching the target ends the match
+			if game.matchScore(p.ID) >= game.TargetScore {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// determineOverallWinner picks the match winner once the match is over.
+// In round-wins mode only players who reached the required wins are eligible; in
+// elimination mode only surviving players are (or everyone, if the last players
+// were knocked out together). Among the eligible players the best standing wins.
+func determineOverallWinner(game *GameState) *Player {
+	var eligible []*Player
+	for _, p := range Standings(game) {
+		switch game.MatchRules.EndMode {
+		case EndAtRoundWins:
+			if game.RoundWins[p.ID] < game.MatchRules.RoundWinsToWin {
+				continue
+			}
+		case EndByElimination:
+			if p.IsEliminated && game.activePlayerCount() > 0 {
+				continue
+			}
+		}
+		eligible = append(eligible, p)
+	}
+	if len(eligible) == 0 {
+		return nil
+	}
+	return eligible[0]
+}
+
+// Standings returns the players ordered from best to worst: lowest match score (team score in team mode) first,
+// then by the table's tie-breakers, then by seat order.
+func Standings(game *GameState) []*Player {
+	standings := make([]*Player, len(game.Players))
+	copy(standings, game.Players)
+	sort.SliceStable(standings, func(i, j int) bool {
+		return compareStanding(game, standings[i], standings[j]) < 0
+	})
+	return standings
+}
+
+// FinalStandings returns the standings of a finished match with the overall winner first.
+// The winner can differ from the best score in round-wins and elimination matches.
+func FinalStandings(game *GameState) []*Player {
+	standings := Standings(game)
+	for i, p := range standings {
+		if p.ID == game.OverallWinnerID && i > 0 {
+			copy(standings[1:i+1], standings[:i])
+			standings[0] = p
+			break
+		}
+	}
+	return standings
+}
+
+// compareStanding returns a negative number if a ranks above b, positive if below, 0 if tied.
+func compareStanding(game *GameState, a, b *Player) int {
+	if diff := game.matchScore(a.ID) - game.matchScore(b.ID); diff != 0 {
+		return diff
+	}
+	for _, tb := range game.MatchRules.TieBreakers {
+		var diff int
+		switch tb {
+		case TieBreakMostRoundWins:
+			diff = game.RoundWins[b.ID] - game.RoundWins[a.ID]
+		case TieBreakBestLastRound:
+			if n := len(game.RoundScoresHistory); n > 0 {
+				last := game.RoundScoresHistory[n-1]
+				diff = last[a.ID] - last[b.ID]
+			}
+		case TieBreakFewestBigLoss:
+			diff = bigLossCount(game, a.ID) - bigLossCount(game, b.ID)
+		}
+		if diff != 0 {
+			return diff
+		}
+	}
+	return 0
+}
+
+// bigLossCount counts the rounds in which the player scored at least the table's
+// BigLossPoints in penalties.
+func bigLossCount(game *GameState, playerID string) int {
+	threshold := game.MatchRules.BigLossPoints
+	if threshold == 0 {
+		threshold = defaultBigLossPoints
+	}
+	count := 0
+	for _, round := range game.RoundScoresHistory {
+		if round[playerID] >= threshold {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// matchTestGame builds a 3-player match in progress with the given rules and match scores.
+func matchTestGame(rules MatchRules, scores map[string]int) *GameState {
+	players := []*Player{NewPlayer(1, "P1"), NewPlayer(2, "P2"), NewPlayer(3, "P3")}
+	game := &GameState{
+		Players:        players,
+		Scores:         scores,
+		RoundNumber:    1,
+		TargetScore:    50,
+		MatchRules:     rules,
+		MatchStartedAt: time.Unix(0, 0),
+		RoundWins:      make(map[string]int),
+	}
+	return game
+}
+
+// winRound empties the winner's hand, leaves loserCards cards with everyone else, and finishes the round.
+func winRound(game *GameState, winnerIdx int, loserCards int, now time.Time) {
+	for i, p := range game.Players {
+		if i == winnerIdx || p.IsEliminated {
+			p.Hand = Deck{}
+		} else {
+			p.Hand = NewDeck()[:loserCards]
+		}
+	}
+	game.IsGameOver = false
+	finishRound(game, game.Players[winnerIdx], now)
+}
+
+func TestFinishRound_EndConditions(t *testing.T) {
+	start := time.Unix(0, 0)
+
+	t.Run("Target score", func(t *testing.T) {
+		game := matchTestGame(DefaultMatchRules(), map[string]int{"player1": 0, "player2": 45, "player3": 10})
+		winRound(game, 0, 3, start)
+		if game.IsMatchOver {
+			t.Fatal("match ended before anyone reached the target")
+		}
+		winRound(game, 0, 3, start)
+		if !game.IsMatchOver || game.OverallWinnerID != "player1" {
+			t.Errorf("IsMatchOver = %v, OverallWinnerID = %q; want true, player1", game.IsMatchOver, game.OverallWinnerID)
+		}
+	})
+
+	t.Run("Fixed rounds", func(t *testing.T) {
+		game := matchTestGame(MatchRules{EndMode: EndAfterRounds, MaxRounds: 2}, map[string]int{})
+		winRound(game, 1, 1, start)
+		if game.IsMatchOver {
+			t.Fatal("match ended after round 1 of 2")
+		}
+		game.RoundNumber++
+		winRound(game, 2, 5, start)
+		// player2: 0 + 5 = 5, player3: 1 + 0 = 1, player1: 1 + 5 = 6
+		if !game.IsMatchOver || game.OverallWinnerID != "player3" {
+			t.Errorf("IsMatchOver = %v, OverallWinnerID = %q; want true, player3", game.IsMatchOver, game.OverallWinnerID)
+		}
+	})
+
+	t.Run("First to N round wins", func(t *testing.T) {
+		game := matchTestGame(MatchRules{EndMode: EndAtRoundWins, RoundWinsToWin: 2}, map[string]int{})
+		winRound(game, 2, 9, start)
+		winRound(game, 0, 1, start)
+		if game.IsMatchOver {
+			t.Fatal("match ended before anyone had 2 round wins")
+		}
+		// player3 is first to 2 round wins.
+		winRound(game, 2, 1, start)
+		if !game.IsMatchOver || game.OverallWinnerID != "player3" {
+			t.Errorf("IsMatchOver = %v, OverallWinnerID = %q; want true, player3", game.IsMatchOver, game.OverallWinnerID)
+		}
+	})
+
+	t.Run("Time limit finishes the current round", func(t *testing.T) {
+		game := matchTestGame(MatchRules{EndMode: EndAfterTimeLimit, TimeLimit: time.Hour}, map[string]int{})
+		winRound(game, 1, 2, start.Add(59*time.Minute))
+		if game.IsMatchOver {
+			t.Fatal("match ended before the time limit")
+		}
+		winRound(game, 1, 2, start.Add(61*time.Minute))
+		if !game.IsMatchOver || game.OverallWinnerID != "player2" {
+			t.Errorf("IsMatchOver = %v, OverallWinnerID = %q; want true, player2", game.IsMatchOver, game.OverallWinnerID)
+		}
+	})
+
+	t.Run("Elimination", func(t *testing.T) {
+		game := matchTestGame(MatchRules{EndMode: EndByElimination}, map[string]int{"player1": 48, "player2": 0, "player3": 30})
+		winRound(game, 1, 5, start)
+		if game.IsMatchOver {
+			t.Fatal("match ended with two players remaining")
+		}
+		if !game.Players[0].IsEliminated || game.activePlayerCount() != 2 {
+			t.Fatalf("player1 eliminated = %v, active = %d; want true, 2", game.Players[0].IsEliminated, game.activePlayerCount())
+		}
+		if next := game.nextActivePlayerIndex(2); next != 1 {
+			t.Errorf("nextActivePlayerIndex(2) = %d, want 1 (skipping eliminated player1)", next)
+		}
+		winRound(game, 1, 10, start) // player3: 35 + 20
+		if !game.IsMatchOver || game.OverallWinnerID != "player2" {
+			t.Errorf("IsMatchOver = %v, OverallWinnerID = %q; want true, player2", game.IsMatchOver, game.OverallWinnerID)
+		}
+	})
+}
+
+func TestStandings_TieBreakers(t *testing.T) {
+	game := matchTestGame(DefaultMatchRules(), map[string]int{"player1": 10, "player2": 10, "player3": 10})
+	game.RoundWins = map[string]int{"player1": 1, "player2": 2, "player3": 1}
+	game.RoundScoresHistory = []map[string]int{
+		{"player1": 20, "player2": 0, "player3": 0},
+		{"player1": 0, "player2": 0, "player3": 10},
+	}
+
+	// player2 has the most round wins; player1 beats player3 on the last round's score.
+	standings := Standings(game)
+	want := []string{"player2", "player1", "player3"}
+	for i, p := range standings {
+		if p.ID != want[i] {
+			t.Fatalf("Standings() = %v, want %v", playerIDs(standings), want)
+		}
+	}
+
+	// A big loss is measured against the table's threshold.
+	game.RoundWins["player2"] = 1
+	game.MatchRules.TieBreakers = []TieBreaker{TieBreakFewestBigLoss}
+	if standings = Standings(game); standings[2].ID != "player1" {
+		t.Errorf("Standings() with 20-point big losses = %v, want player1 last", playerIDs(standings))
+	}
+	game.MatchRules.BigLossPoints = 10
+	if standings = Standings(game); standings[0].ID != "player2" {
+		t.Errorf("Standings() with 10-point big losses = %v, want player2 first", playerIDs(standings))
+	}
+
+	// Without tie-breakers seat order decides.
+	game.MatchRules.TieBreakers = nil
+	standings = Standings(game)
+	if standings[0].ID != "player1" {
+		t.Errorf("Standings() without tie-breakers = %v, want seat order", playerIDs(standings))
+	}
+}
+
+func playerIDs(players []*Player) []string {
+	ids := make([]string, len(players))
+	for i, p := range players {
+		ids[i] = p.ID
+	}
+	return ids
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Server metrics, served at /metrics in the Prometheus text exposition format (version 0.0.4).
+// The format is written here directly so the server needs no client library.
+
+// counter is a monotonically increasing count.
+type counter struct{ v atomic.Uint64 }
+
+func (c *counter) Inc()          { c.v.Add(1) }
+func (c *counter) Value() uint64 { return c.v.Load() }
+
+// counterVec is a set of counters distinguished by the value of one label.
+type counterVec struct {
+	label  string
+	mu     sync.Mutex
+	values map[string]uint64
+}
+
+func newCounterVec(label string) *counterVec {
+	return &counterVec{label: label, values: make(map[string]uint64)}
+}
+
+func (v *counterVec) Inc(labelValue string) {
+	v.mu.Lock()
+	v.values[labelValue]++
+	v.mu.Unlock()
+}
+
+// Value returns the count for labelValue.
+func (v *counterVec) Value(labelValue string) uint64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.values[labelValue]
+}
+
+// histogram counts observations into cumulative buckets by upper bound.
+type histogram struct {
+	bounds []float64 // Ascending upper bounds; +Inf is implied
+	mu     sync.Mutex
+	counts []uint64 // Per bucket, not cumulative; the last is +Inf
+	sum    float64
+	count  uint64
+}
+
+func newHistogram(bounds ...float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds)+1)}
+}
+
+func (h *histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.bounds, v) // First bound >= v
+	h.mu.Lock()
+	h.counts[i]++
+	h.sum += v
+	h.count++
+	h.mu.Unlock()
+}
+
+// ObserveSince records the seconds elapsed since start.
+func (h *histogram) ObserveSince(start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
+
+// metrics holds every metric the server records.
+var metrics = struct {
+	actions          *counterVec // Websocket messages received, by type
+	rejected         *counterVec // Messages refused with an error, by reason
+	writeErrors      counter     // Failed websocket writes
+	originRefused    counter     // Websocket handshakes from origins not allowed
+	abuseDisconnects counter     // Clients disconnected for too many refused messages
+	broadcastSeconds *histogram  // Time to send one game state update to a table
+	roundSeconds     *histogram
+	matchSeconds     *histogram
+}{
+	actions:          newCounterVec("type"),
+	rejected:         newCounterVec("reason"),
+	broadcastSeconds: newHistogram(0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1),
+	roundSeconds:     newHistogram(30, 60, 120, 300, 600, 900, 1800, 3600),
+	matchSeconds:     newHistogram(300, 600, 1200, 1800, 3600, 7200, 14400),
+}
+
+// actionTypes are the message types handleWebSocket understands. Anything else is counted as
+// "unknown", so that clients cannot create metric series at will.
+var actionTypes = map[string]bool{
+	"chat": true, "playCards": true, "passTurn": true, "exchangeCards": true, "queueAction": true,
+	"requestUndo": true, "respondUndo": true, "pause": true, "resume": true, "callVote": true,
+	"castVote": true, "newGame": true, "setAlias": true,
+}
+
+// countAction records a received websocket message of type msgType.
+func countAction(msgType string) {
+	if !actionTypes[msgType] {
+		msgType = "unknown"
+	}
+	metrics.actions.Inc(msgType)
+}
+
+// handleMetrics serves GET /metrics.
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	clientsMu.Lock()
+	connections := len(clients)
+	clientsMu.Unlock()
+
+	gameInstanceMutex.Lock()
+	activeGames := 0
+	for _, game := range tables {
+		if !game.IsMatchOver {
+			activeGames++
+		}
+	}
+	tableCount := len(tables)
+	gameInstanceMutex.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	writeGauge(w, "bigtwo_connections", "Open websocket connections.", float64(connections))
+	writeGauge(w, "bigtwo_tables", "Tables hosted by the server.", float64(tableCount))
+	writeGauge(w, "bigtwo_active_games", "Tables with a match in progress.", float64(activeGames))
+	writeCounterVec(w, "bigtwo_actions_total", "Websocket messages received, by type.", metrics.actions)
+	writeCounterVec(w, "bigtwo_rejected_actions_total", "Websocket messages refused with an error, by reason.", metrics.rejected)
+	writeCounter(w, "bigtwo_websocket_write_errors_total", "Failed websocket writes.", metrics.writeErrors.Value())
+	writeCounter(w, "bigtwo_websocket_origin_refused_total", "Websocket handshakes refused for their origin.", metrics.originRefused.Value())
+	writeCounter(w, "bigtwo_websocket_abuse_disconnects_total", "Clients disconnected for sending too many refused messages.", metrics.abuseDisconnects.Value())
+	writeHistogram(w, "bigtwo_broadcast_duration_seconds", "Time to send a game state update to every client at a table.", metrics.broadcastSeconds)
+	writeHistogram(w, "bigtwo_round_duration_seconds", "Length of finished rounds.", metrics.roundSeconds)
+	writeHistogram(w, "bigtwo_match_duration_seconds", "Length of finished matches, not counting pauses.", metrics.matchSeconds)
+}
+
+func writeHeader(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func writeGauge(w io.Writer, name, help string, v float64) {
+	writeHeader(w, name, help, "gauge")
+	fmt.Fprintf(w, "%s %s\n", name, formatFloat(v))
+}
+
+func writeCounter(w io.Writer, name, help string, v uint64) {
+	writeHeader(w, name, help, "counter")
+	fmt.Fprintf(w, "%s %d\n", name, v)
+}
+
+func writeCounterVec(w io.Writer, name, help string, v *counterVec) {
+	writeHeader(w, name, help, "counter")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	labelValues := make([]string, 0, len(v.values))
+	for lv := range v.values {
+		labelValues = append(labelValues, lv)
+	}
+	sort.Strings(labelValues)
+	for _, lv := range labelValues {
+		fmt.Fprintf(w, "%s{%s=\"%s\"} %d\n", name, v.label, escapeLabelValue(lv), v.values[lv])
+	}
+}
+
+func writeHistogram(w io.Writer, name, help string, h *histogram) {
+	writeHeader(w, name, help, "histogram")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", name, formatFloat(bound), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+	fmt.Fprintf(w, "%s_sum %s\n", name, formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count %d\n", name, h.count)
+}
+
+// formatFloat writes v as the exposition format expects, including +Inf, -Inf and NaN.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// scrapeMetrics serves /metrics and returns each sample line's value keyed by its name and labels.
+func scrapeMetrics(t *testing.T) map[string]float64 {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handleMetrics(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type %q", ct)
+	}
+	samples := make(map[string]float64)
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		v, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			t.Fatalf("bad sample line %q: %v", line, err)
+		}
+		samples[line[:i]] = v
+	}
+	return samples
+}
+
+func TestWriteHistogram(t *testing.T) {
+	h := newHistogram(0.1, 1)
+	for _, v := range []float64{0.05, 0.1, 0.5, 3} {
+		h.Observe(v)
+	}
+	var b strings.Builder
+	writeHistogram(&b, "x_seconds", "Test.", h)
+	want := `# HELP x_seconds Test.
+# TYPE x_seconds histogram
+x_seconds_bucket{le="0.1"} 2
+x_seconds_bucket{le="1"} 3
+x_seconds_bucket{le="+Inf"} 4
+x_seconds_sum 3.65
+x_seconds_count 4
+`
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestWriteCounterVec(t *testing.T) {
+	v := newCounterVec("reason")
+	v.Inc("b")
+	v.Inc(`a "quoted"\path`)
+	v.Inc("b")
+	var b strings.Builder
+	writeCounterVec(&b, "x_total", "Test.", v)
+	want := `# HELP x_total Test.
+# TYPE x_total counter
+x_total{reason="a \"quoted\"\\path"} 1
+x_total{reason="b"} 2
+`
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestWebSocket_Metrics(t *testing.T) {
+	h := newWSHarness(t, 2, []Deck{
+		{{Rank3, Diamonds}, {Rank4, Clubs}},
+		{{Rank5, Diamonds}, {Rank6, Hearts}},
+	})
+	before := scrapeMetrics(t)
+	cs, _ := h.connectAll(2)
+
+	cs[1].pass()
+	cs[1].expectError("not your turn")
+	cs[0].play(Card{Rank4, Clubs})
+	cs[0].expectError("")
+	cs[0].send(map[string]interface{}{"type": "teleport"})
+	cs[0].expectError("Unknown message type")
+	cs[0].play(Card{Rank3, Diamonds})
+	states(cs)
+
+	after := scrapeMetrics(t)
+	delta := func(key string) float64 { return after[key] - before[key] }
+	if after["bigtwo_connections"] < 2 || after["bigtwo_active_games"] < 1 {
+		t.Errorf("connections %v, active games %v", after["bigtwo_connections"], after["bigtwo_active_games"])
+	}
+	for key, want := range map[string]float64{
+		`bigtwo_actions_total{type="passTurn"}`:                 1,
+		`bigtwo_actions_total{type="playCards"}`:                2,
+		`bigtwo_actions_total{type="unknown"}`:                  1,
+		`bigtwo_rejected_actions_total{reason="not_your_turn"}`: 1,
+		`bigtwo_rejected_actions_total{reason="unknown_type"}`:  1,
+	} {
+		if got := delta(key); got != want {
+			t.Errorf("%s rose by %v, want %v", key, got, want)
+		}
+	}
+	if delta(`bigtwo_broadcast_duration_seconds_count`) < 1 {
+		t.Error("no broadcast latency observed")
+	}
+	var ruleRejections float64
+	for key := range after {
+		if strings.HasPrefix(key, "bigtwo_rejected_actions_total") {
+			ruleRejections += delta(key)
+		}
+	}
+	if ruleRejections != 3 {
+		t.Errorf("%v rejections counted, want 3 (turn, opening card, unknown type)", ruleRejections)
+	}
+}
+
+func TestFinishRound_RecordsDurations(t *testing.T) {
+	game := NewGameState("metrics", []*Player{NewPlayer(1, "P1"), NewPlayer(2, "P2")}, 1)
+	rounds, matches := metrics.roundSeconds.count, metrics.matchSeconds.count
+	game.Players[0].Hand = nil
+	finishRound(game, game.Players[0], game.roundStartedAt.Add(90e9))
+	if !game.IsMatchOver {
+		t.Fatal("match should end at a target score of 1")
+	}
+	if metrics.roundSeconds.count != rounds+1 || metrics.matchSeconds.count != matches+1 {
+		t.Errorf("round and match observations rose by %d and %d, want 1 each",
+			metrics.roundSeconds.count-rounds, metrics.matchSeconds.count-matches)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+// GenerateMoves enumerates every valid Big 2 play that can be made from hand.
+// If lastPlayed is non-nil, only plays that beat it are returned.
+// Plays are returned grouped by size (singles, pairs, triples, then 5-card hands),
+// and within each group in ascending card order.
+func (re *BigTwoRuleEngine) GenerateMoves(hand Deck, lastPlayed *PlayedHand) []*PlayedHand {
+	sortedHand := make(Deck, len(hand))
+	copy(sortedHand, hand)
+	sortedHand.Sort()
+
+	var moves []*PlayedHand
+	for _, size := range re.playSizes(len(sortedHand)) {
+		if lastPlayed != nil && !re.canFollowWithSize(size, lastPlayed) {
+			continue
+		}
+		forEachCombination(sortedHand, size, func(combo Deck) {
+			if !sameRankCombo(combo) && re.mustShareRank(size) {
+				return // Skip the classifier for speed.
+			}
+			played, err := re.DeterminePlayedHand(combo)
+			if err != nil {
+				return
+			}
+			if re.BeatsLastHand(played, lastPlayed) {
+				moves = append(moves, played)
+			}
+		})
+	}
+	return moves
+}
+
+// HighestSingle returns the highest single from the given moves, or nil if there is none.
+func (re *BigTwoRuleEngine) HighestSingle(moves []*PlayedHand) *PlayedHand {
+	var highest *PlayedHand
+	for _, m := range moves {
+		if m.HandType != Single {
+			continue
+		}
+		if highest == nil || re.CardLess(highest.Cards[0], m.Cards[0]) {
+			highest = m
+		}
+	}
+	return highest
+}
+
+// highestLegalSingle returns the highest single in hand that can be played on lastPlayed, or
+// nil if there is none. It is HighestSingle(GenerateMoves(hand, lastPlayed)) without
+// enumerating the larger plays, which Tiến Lên with jokers makes slow.
+func (re *BigTwoRuleEngine) highestLegalSingle(hand Deck, lastPlayed *PlayedHand) *PlayedHand {
+	if lastPlayed != nil && !re.canFollowWithSize(1, lastPlayed) {
+		return nil
+	}
+	var highest *PlayedHand
+	for _, card := range hand {
+		single, err := re.DeterminePlayedHand(Deck{card})
+		if err != nil || !re.BeatsLastHand(single, lastPlayed) {
+			continue
+		}
+		if highest == nil || re.CardLess(highest.Cards[0], single.Cards[0]) {
+			highest = single
+		}
+	}
+	return highest
+}
+
+// playSizes returns the play sizes to enumerate for a hand of n cards.
+func (re *BigTwoRuleEngine) playSizes(n int) []int {
+	if re.Options.Mode != ModeTienLen {
+		return []int{1, 2, 3, 5}
+	}
+	sizes := make([]int, 0, n)
+	for size := 1; size <= n; size++ {
+		sizes = append(sizes, size)
+	}
+	return sizes
+}
+
+// mustShareRank reports whether every play of the given size is a set of one rank.
+func (re *BigTwoRuleEngine) mustShareRank(size int) bool {
+	if re.Options.Mode == ModeTienLen {
+		return size <= 2 // Three or more cards may form a sequence
+	}
+	return size < 5
+}
+
+// canFollowWithSize reports whether a play of the given size could possibly beat lastPlayed.
+// In Big Two only 5-card bombs may be played on top of a different-sized hand; in
+// Tiến Lên four of a kind and pair sequences may chop 2s and each other.
+func (re *BigTwoRuleEngine) canFollowWithSize(size int, lastPlayed *PlayedHand) bool {
+	if re.Options.Mode == ModeTienLen {
+		return size == len(lastPlayed.Cards) || size == 4 || (size >= 6 && size%2 == 0)
+	}
+	return size == len(lastPlayed.Cards) || size == 5
+}
+
+// sameRankCombo reports whether every natural card in the combination shares the same rank.
+// Jokers can stand in for any rank.
+func sameRankCombo(cards Deck) bool {
+	var rank Rank = -1
+	for _, c := range cards {
+		if c.IsJoker() {
+			continue
+		}
+		if rank != -1 && c.Rank != rank {
+			return false
+		}
+		rank = c.Rank
+	}
+	return true
+}
+
+// forEachCombination calls fn with every k-card combination of cards, preserving their order.
+// The slice passed to fn is reused between calls; fn must copy it to retain it.
+func forEachCombination(cards Deck, k int, fn func(Deck)) {
+	if k <= 0 || k > len(cards) {
+		return
+	}
+	combo := make(Deck, k)
+	var recurse func(start, depth int)
+	recurse = func(start, depth int) {
+		if depth == k {
+			fn(combo)
+			return
+		}
+		for i := start; i <= len(cards)-(k-depth); i++ {
+			combo[depth] = cards[i]
+			recurse(i+1, depth+1)
+		}
+	}
+	recurse(0, 0)
+}
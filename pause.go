@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// PauseTable holds the table: no game actions are accepted and its clocks (the match time
+// limit and any undo request) stop until it is resumed. by is the pausing player's ID, or
+// empty for an administrator. Assumes gameInstanceMutex is held.
+func PauseTable(game *GameState, by string) error {
+	if game.Paused {
+		return fmt.Errorf("the table is already paused")
+	}
+	game.Paused = true
+	game.PausedBy = by
+	game.pausedAt = time.Now()
+	if req := game.UndoRequest; req != nil && req.timer != nil {
+		req.timer.Stop()
+	}
+	gameLog(game).Info("table paused", "by", by)
+	return nil
+}
+
+// ResumeTable lifts a pause and restarts the table's clocks where they stopped. Any player
+// can lift a player's pause, but only an administrator (by empty) can lift an
+// administrator's. Assumes gameInstanceMutex is held.
+func ResumeTable(game *GameState, by string) error {
+	if !game.Paused {
+		return fmt.Errorf("the table is not paused")
+	}
+	if game.PausedBy == "" && by != "" {
+		return fmt.Errorf("an administrator paused the table and must resume it")
+	}
+	paused := time.Since(game.pausedAt)
+	game.Paused = false
+	game.PausedBy = ""
+	game.MatchStartedAt = game.MatchStartedAt.Add(paused)
+	if req := game.UndoRequest; req != nil {
+		req.ExpiresAt = req.ExpiresAt.Add(paused)
+		expireUndoRequest(game, req)
+	}
+	gameLog(game).Info("table resumed", "by", by, "paused", paused.Round(time.Second))
+	return nil
+}
+
+// matchElapsed returns how long the match has been played at now, not counting a pause in progress.
+func matchElapsed(game *GameState, now time.Time) time.Duration {
+	if game.Paused {
+		now = game.pausedAt
+	}
+	return now.Sub(game.MatchStartedAt)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPauseTable_FreezesClocks(t *testing.T) {
+	game := NewGameState("pause", []*Player{NewPlayer(1, "P1"), NewPlayer(2, "P2")}, 100)
+	game.MatchRules = MatchRules{EndMode: EndAfterTimeLimit, TimeLimit: time.Hour}
+	game.MatchStartedAt = time.Now().Add(-50 * time.Minute)
+	game.UndoRequest = &UndoRequest{PlayerID: "player1", ExpiresAt: time.Now().Add(10 * time.Second)}
+	expireUndoRequest(game, game.UndoRequest)
+	endsAt, expiresAt := *matchEndsAt(game), game.UndoRequest.ExpiresAt
+
+	if err := PauseTable(game, "player2"); err != nil {
+		t.Fatal(err)
+	}
+	game.pausedAt = game.pausedAt.Add(-20 * time.Minute) // As if paused for twenty minutes, after thirty of play
+	if elapsed := matchElapsed(game, time.Now()); elapsed > 31*time.Minute {
+		t.Errorf("match clock ran to %s while paused", elapsed)
+	}
+	if shift := matchEndsAt(game).Sub(endsAt); shift < 20*time.Minute {
+		t.Errorf("match end moved %s during the pause, want the pause's length", shift)
+	}
+
+	if err := ResumeTable(game, "player1"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := matchElapsed(game, time.Now()); elapsed < 30*time.Minute || elapsed > 31*time.Minute {
+		t.Errorf("match clock at %s after resuming, want it to carry on from 30m", elapsed)
+	}
+	if shift := game.UndoRequest.ExpiresAt.Sub(expiresAt); shift < 20*time.Minute {
+		t.Errorf("undo request expiry moved %s, want the pause's length", shift)
+	}
+	cancelUndoRequest(game)
+}
+
+func TestResumeTable_AdministratorPause(t *testing.T) {
+	game := NewGameState("pause", []*Player{NewPlayer(1, "P1"), NewPlayer(2, "P2")}, 100)
+	if err := ResumeTable(game, "player1"); err == nil || !strings.Contains(err.Error(), "not paused") {
+		t.Errorf("resuming a running table: %v", err)
+	}
+	PauseTable(game, "")
+	if err := PauseTable(game, "player1"); err == nil || !strings.Contains(err.Error(), "already paused") {
+		t.Errorf("pausing twice: %v", err)
+	}
+	if err := ResumeTable(game, "player1"); err == nil || !strings.Contains(err.Error(), "administrator") {
+		t.Errorf("player resuming an administrator's pause: %v", err)
+	}
+	if err := ResumeTable(game, ""); err != nil || game.Paused {
+		t.Errorf("administrator resuming: %v, paused %v", err, game.Paused)
+	}
+}
+
+func TestWebSocket_PauseAndResume(t *testing.T) {
+	h := newWSHarness(t, 2, []Deck{
+		{{Rank3, Diamonds}, {Rank4, Clubs}},
+		{{Rank5, Diamonds}, {Rank6, Hearts}},
+	})
+	cs, _ := h.connectAll(2)
+
+	cs[1].send(map[string]interface{}{"type": "pause"})
+	if got := states(cs); !got[0].Paused {
+		t.Fatalf("player1 sees paused %v after player2 paused", got[0].Paused)
+	}
+	cs[0].play(Card{Rank3, Diamonds})
+	cs[0].expectError("paused")
+
+	// Votes still work, so a stalled table can always be wound up.
+	cs[0].send(map[string]interface{}{"type": "callVote", "kind": "abandon"})
+	states(cs)
+
+	cs[0].send(map[string]interface{}{"type": "resume"})
+	states(cs)
+	cs[0].play(Card{Rank3, Diamonds})
+	h.assertConsistent(cs, states(cs))
+}
@@ -6,11 +6,18 @@ import "fmt"
 type Player struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
-	Hand        Deck   `json:"hand"` // Deck type is []Card
+	Hand        Deck   `json:"-"` // Hidden information: sent only through a GameView, never with the Player
 	IsConnected bool
 	Score       int
 	OrderInTurn int  // To determine play sequence
 	HasPassed   bool `json:"hasPassed"` // Tracks if player passed in the current round of plays
+	// IsEliminated is set in elimination matches once the player reaches the target score.
+	// Eliminated players are dealt no cards and skipped in turn order.
+	IsEliminated bool `json:"isEliminated"`
+	// AccountID is the persistent account bound to this seat, empty for guests.
+	AccountID string `json:"accountId,omitempty"`
+	// Rating is the bound account's rating, cached for display. Zero for guests.
+	Rating float64 `json:"rating,omitempty"`
 }
 
 // NewPlayer creates and returns a new player.
@@ -36,6 +43,21 @@ func (d Deck) Contains(card Card) bool {
 	return false
 }
 
+// HasCards reports whether the player holds every card in cards (counting duplicates).
+func (p *Player) HasCards(cards Deck) bool {
+	held := make(map[Card]int)
+	for _, c := range p.Hand {
+		held[c]++
+	}
+	for _, c := range cards {
+		if held[c] == 0 {
+			return false
+		}
+		held[c]--
+	}
+	return true
+}
+
 // RemoveCards removes a sub-deck of cards from a player's hand.
 // Returns true if all cards were successfully found and removed, false otherwise.
 // Modifies p.Hand directly if successful.
@@ -0,0 +1,127 @@
+package main
+
+import "testing"
+
+func newPusoyDosEngine(t *testing.T) *BigTwoRuleEngine {
+	t.Helper()
+	opts, err := NewRuleOptions(RulesPusoyDos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewBigTwoRuleEngineWithOptions(opts)
+}
+
+func TestPusoyDos_DeterminePlayedHand(t *testing.T) {
+	re := newPusoyDosEngine(t)
+
+	tests := []struct {
+		name         string
+		cards        Deck
+		wantHandType HandType
+		wantEffRank  Rank
+		wantEffSuit  Suit
+		wantErr      bool
+	}{
+		// Diamonds are the highest suit, clubs the lowest
+		{"Valid Single (3C)", Deck{C(Rank3, Clubs)}, Single, Rank3, Clubs, false},
+		{"Valid Pair (AS, AD) takes diamonds", Deck{C(Ace, Spades), C(Ace, Diamonds)}, Pair, Ace, Diamonds, false},
+		{"Valid Pair (AC, AH) takes hearts", Deck{C(Ace, Clubs), C(Ace, Hearts)}, Pair, Ace, Hearts, false},
+		{"Valid Triple (7s)", Deck{C(Rank7, Diamonds), C(Rank7, Clubs), C(Rank7, Hearts)}, Triple, Rank7, -1, false},
+		{"Valid Straight (3-7)", Deck{C(Rank3, Clubs), C(Rank4, Hearts), C(Rank5, Spades), C(Rank6, Diamonds), C(Rank7, Spades)}, Straight, Rank7, Spades, false},
+		{"Valid Flush (clubs)", Deck{C(Rank3, Clubs), C(Rank5, Clubs), C(Rank8, Clubs), C(Jack, Clubs), C(King, Clubs)}, Flush, King, Clubs, false},
+		{"Valid Full House", Deck{C(Rank3, Diamonds), C(Rank3, Clubs), C(Rank3, Hearts), C(Rank5, Spades), C(Rank5, Diamonds)}, FullHouse, Rank3, -1, false},
+		{"Valid Four of a Kind + 1", Deck{C(Rank7, Diamonds), C(Rank7, Clubs), C(Rank7, Hearts), C(Rank7, Spades), C(Rank3, Diamonds)}, FourOfAKindPlusOne, Rank7, -1, false},
+		{"Invalid - Four cards", Deck{C(Rank4, Diamonds), C(Rank4, Clubs), C(Rank4, Hearts), C(Rank4, Spades)}, InvalidHand, -1, -1, true},
+		{"Invalid - Sequence of three", Deck{C(Rank3, Diamonds), C(Rank4, Clubs), C(Rank5, Hearts)}, InvalidHand, -1, -1, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cards.Sort()
+			playedHand, err := re.DeterminePlayedHand(tc.cards)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("DeterminePlayedHand() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if playedHand.HandType != tc.wantHandType {
+				t.Errorf("DeterminePlayedHand() HandType = %v, want %v", playedHand.HandType, tc.wantHandType)
+			}
+			if playedHand.EffectiveRank != tc.wantEffRank {
+				t.Errorf("DeterminePlayedHand() EffectiveRank = %v, want %v", playedHand.EffectiveRank, tc.wantEffRank)
+			}
+			if playedHand.EffectiveSuit != tc.wantEffSuit {
+				t.Errorf("DeterminePlayedHand() EffectiveSuit = %v, want %v", playedHand.EffectiveSuit, tc.wantEffSuit)
+			}
+		})
+	}
+}
+
+func TestPusoyDos_BeatsLastHand(t *testing.T) {
+	re := newPusoyDosEngine(t)
+
+	single2D := mustHand(t, re, C(Two, Diamonds))
+	single2S := mustHand(t, re, C(Two, Spades))
+	single3C := mustHand(t, re, C(Rank3, Clubs))
+	single3S := mustHand(t, re, C(Rank3, Spades))
+	pairAD := mustHand(t, re, C(Ace, Clubs), C(Ace, Diamonds))
+	pairAH := mustHand(t, re, C(Ace, Spades), C(Ace, Hearts))
+	straight7S := mustHand(t, re, C(Rank3, Clubs), C(Rank4, Hearts), C(Rank5, Spades), C(Rank6, Diamonds), C(Rank7, Spades))
+	straight7D := mustHand(t, re, C(Rank3, Spades), C(Rank4, Clubs), C(Rank5, Hearts), C(Rank6, Clubs), C(Rank7, Diamonds))
+	flushClubs := mustHand(t, re, C(Rank3, Clubs), C(Rank5, Clubs), C(Rank8, Clubs), C(Jack, Clubs), C(King, Clubs))
+	flushDiamonds := mustHand(t, re, C(Rank3, Diamonds), C(Rank5, Diamonds), C(Rank8, Diamonds), C(Jack, Diamonds), C(King, Diamonds))
+	fullHouse := mustHand(t, re, C(Rank3, Diamonds), C(Rank3, Clubs), C(Rank3, Hearts), C(Rank5, Spades), C(Rank5, Diamonds))
+	bomb := mustHand(t, re, C(Rank7, Diamonds), C(Rank7, Clubs), C(Rank7, Hearts), C(Rank7, Spades), C(Rank3, Diamonds))
+
+	tests := []struct {
+		name           string
+		currentPlay    *PlayedHand
+		lastPlayedHand *PlayedHand
+		wantBeats      bool
+	}{
+		{"Any hand leads", single3C, nil, true},
+		{"2D beats 2S", single2D, single2S, true},
+		{"2S does not beat 2D", single2S, single2D, false},
+		{"3S beats 3C", single3S, single3C, true},
+		{"Pair of aces with diamonds beats hearts", pairAD, pairAH, true},
+		{"Pair of aces with hearts does not beat diamonds", pairAH, pairAD, false},
+		{"Straight to 7D beats straight to 7S", straight7D, straight7S, true},
+		{"Flush in diamonds beats same-rank flush in clubs", flushDiamonds, flushClubs, true},
+		{"Flush beats straight", flushClubs, straight7D, true},
+		{"Full house beats flush", fullHouse, flushDiamonds, true},
+		{"Bomb beats a single 2", bomb, single2D, true},
+		{"Pair cannot beat a single", pairAD, single2S, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if gotBeats := re.BeatsLastHand(tc.currentPlay, tc.lastPlayedHand); gotBeats != tc.wantBeats {
+				t.Errorf("BeatsLastHand() for %s: got %v, want %v", tc.name, gotBeats, tc.wantBeats)
+			}
+		})
+	}
+}
+
+func TestPusoyDos_OpeningCard(t *testing.T) {
+	re := newPusoyDosEngine(t)
+	players := []*Player{NewPlayer(1, "P1"), NewPlayer(2, "P2")}
+	players[0].Hand = Deck{C(Rank3, Diamonds), C(Rank9, Hearts)}
+	players[1].Hand = Deck{C(Rank3, Clubs), C(King, Hearts)}
+	idx, card, found := findPlayerWithLowestCard(players, re.CardLess)
+	if !found || idx != 1 || card != C(Rank3, Clubs) {
+		t.Errorf("findPlayerWithLowestCard() = (%d, %s, %v), want (1, 3C, true)", idx, card, found)
+	}
+}
+
+func TestNewRuleOptions(t *testing.T) {
+	for _, name := range RulePresetNames() {
+		opts, err := NewRuleOptions(name)
+		if err != nil || opts.Name != name {
+			t.Errorf("NewRuleOptions(%q) = %+v, %v", name, opts, err)
+		}
+	}
+	if _, err := NewRuleOptions("hearts"); err == nil {
+		t.Error("NewRuleOptions(\"hearts\") succeeded, want error")
+	}
+}
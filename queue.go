@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+)
+
+// QueuedActionKind is an intent a player can leave for their next turns.
+type QueuedActionKind string
+
+const (
+	// QueueAutoPass passes every turn until the current trick is won.
+	QueueAutoPass QueuedActionKind = "autoPass"
+	// QueuePlayIfPossible plays the chosen cards on the player's next turn if they beat the
+	// table, and passes otherwise. "Pass unless I can beat with a single 2" is this with one 2.
+	QueuePlayIfPossible QueuedActionKind = "playIfPossible"
+)
+
+// QueuedAction is a player's pre-selected move, carried out by the server when their turn comes.
+// Queued actions are private: only the player who queued one is shown it.
+type QueuedAction struct {
+	Kind  QueuedActionKind `json:"kind"`
+	Cards Deck             `json:"cards,omitempty"` // For QueuePlayIfPossible
+}
+
+// QueueAction stores player's intent, replacing any earlier one, and acts on it at once if
+// it is already their turn. Assumes gameInstanceMutex is held.
+func QueueAction(game *GameState, player *Player, action QueuedAction) error {
+	switch action.Kind {
+	case QueueAutoPass:
+		action.Cards = nil
+	case QueuePlayIfPossible:
+		if len(action.Cards) == 0 {
+			return fmt.Errorf("choose the cards to play")
+		}
+		if !player.HasCards(action.Cards) {
+			return fmt.Errorf("you do not hold all of those cards")
+		}
+		if _, err := game.RuleEngine.DeterminePlayedHand(action.Cards); err != nil {
+			return fmt.Errorf("not a valid hand: %v", err)
+		}
+	default:
+		return fmt.Errorf("unknown queued action %q", action.Kind)
+	}
+	if game.IsGameOver {
+		return fmt.Errorf("the round is over")
+	}
+	if game.QueuedActions == nil {
+		game.QueuedActions = make(map[string]*QueuedAction)
+	}
+	game.QueuedActions[player.ID] = &action
+	runQueuedActions(game)
+	return nil
+}
+
+// clearAutoPasses drops every auto-pass once a trick has been won.
+func clearAutoPasses(game *GameState) {
+	for id, q := range game.QueuedActions {
+		if q.Kind == QueueAutoPass {
+			delete(game.QueuedActions, id)
+		}
+	}
+}
+
+// runQueuedActions acts for each player whose turn comes while they have an action queued,
+// until the turn reaches a player who has to decide for themselves. A queued play that is
+// not legal becomes a pass. A player leading a new trick cannot pass, so if their queued
+// action cannot be carried out it is dropped and they decide. Assumes gameInstanceMutex is held.
+func runQueuedActions(game *GameState) {
+	for !game.IsGameOver && !game.exchangeInProgress() {
+		player := game.Players[game.CurrentTurnPlayerIndex]
+		q := game.QueuedActions[player.ID]
+		if q == nil {
+			return
+		}
+		if q.Kind == QueuePlayIfPossible {
+			delete(game.QueuedActions, player.ID) // Used up on this turn either way
+			if play, err := validatePlay(game, player, q.Cards); err == nil {
+				playerLog(game, player).Info("playing queued cards", "cards", q.Cards.String())
+				if err := playCards(game, player, play); err != nil {
+					playerLog(game, player).Error("queued play failed", "err", err)
+					return
+				}
+				continue
+			}
+		}
+		if validatePass(game) != nil {
+			delete(game.QueuedActions, player.ID)
+			return
+		}
+		playerLog(game, player).Info("passing by queued action", "kind", q.Kind)
+		passTurn(game, player)
+	}
+}
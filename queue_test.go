@@ -0,0 +1,177 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// queueTestGame deals a three-player round that player1 opens with the 3 of Diamonds.
+func queueTestGame() *GameState {
+	game := NewGameState("queue", []*Player{NewPlayer(1, "P1"), NewPlayer(2, "P2"), NewPlayer(3, "P3")}, 100)
+	game.Players[0].Hand = Deck{C(Rank3, Diamonds), C(Rank4, Clubs), C(Two, Spades)}
+	game.Players[1].Hand = Deck{C(Rank5, Diamonds), C(Two, Hearts)}
+	game.Players[2].Hand = Deck{C(Rank7, Spades), C(Rank8, Spades)}
+	setOpeningPlayer(game)
+	return game
+}
+
+// mustPlay plays cards for the player whose turn it is, as the playCards handler does.
+func mustPlay(t *testing.T, game *GameState, cards ...Card) {
+	t.Helper()
+	player := game.Players[game.CurrentTurnPlayerIndex]
+	play, err := validatePlay(game, player, cards)
+	if err != nil {
+		t.Fatalf("%s cannot play %v: %v", player.ID, cards, err)
+	}
+	if err := playCards(game, player, play); err != nil {
+		t.Fatal(err)
+	}
+	runQueuedActions(game)
+}
+
+func TestRunQueuedActions(t *testing.T) {
+	tests := []struct {
+		name        string
+		noOpening   bool                 // Let player1 lead with any card
+		queue       map[int]QueuedAction // By seat
+		play        Deck                 // player1's play
+		wantCurrent string
+		wantLast    Deck
+		wantQueued  int
+	}{
+		{
+			name:        "auto-pass until the trick is won",
+			queue:       map[int]QueuedAction{1: {Kind: QueueAutoPass}, 2: {Kind: QueueAutoPass}},
+			play:        Deck{C(Rank3, Diamonds)},
+			wantCurrent: "player1",
+			wantLast:    nil,
+			wantQueued:  0,
+		},
+		{
+			name:        "play if the 2 beats the table",
+			queue:       map[int]QueuedAction{1: {Kind: QueuePlayIfPossible, Cards: Deck{C(Two, Hearts)}}},
+			play:        Deck{C(Rank3, Diamonds)},
+			wantCurrent: "player3",
+			wantLast:    Deck{C(Two, Hearts)},
+			wantQueued:  0,
+		},
+		{
+			name:        "pass when the 2 does not beat the table",
+			noOpening:   true,
+			queue:       map[int]QueuedAction{1: {Kind: QueuePlayIfPossible, Cards: Deck{C(Two, Hearts)}}},
+			play:        Deck{C(Two, Spades)},
+			wantCurrent: "player3",
+			wantLast:    Deck{C(Two, Spades)},
+			wantQueued:  0,
+		},
+		{
+			name:        "a later auto-pass waits for its turn",
+			queue:       map[int]QueuedAction{2: {Kind: QueueAutoPass}},
+			play:        Deck{C(Rank3, Diamonds)},
+			wantCurrent: "player2",
+			wantLast:    Deck{C(Rank3, Diamonds)},
+			wantQueued:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := queueTestGame()
+			if tt.noOpening {
+				game.OpeningCard = nil
+			}
+			for seat, q := range tt.queue {
+				if err := QueueAction(game, game.Players[seat], q); err != nil {
+					t.Fatal(err)
+				}
+			}
+			mustPlay(t, game, tt.play...)
+
+			if current := game.Players[game.CurrentTurnPlayerIndex].ID; current != tt.wantCurrent {
+				t.Errorf("turn passed to %s, want %s", current, tt.wantCurrent)
+			}
+			var last Deck
+			if game.LastPlayedHand != nil {
+				last = game.LastPlayedHand.Cards
+			}
+			if last.String() != tt.wantLast.String() {
+				t.Errorf("table shows %v, want %v", last, tt.wantLast)
+			}
+			if len(game.QueuedActions) != tt.wantQueued {
+				t.Errorf("%d actions still queued, want %d", len(game.QueuedActions), tt.wantQueued)
+			}
+		})
+	}
+}
+
+func TestQueueAction_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  QueuedAction
+		wantErr string
+	}{
+		{"card not held", QueuedAction{Kind: QueuePlayIfPossible, Cards: Deck{C(Two, Spades)}}, "do not hold"},
+		{"no cards", QueuedAction{Kind: QueuePlayIfPossible}, "choose the cards"},
+		{"not a hand", QueuedAction{Kind: QueuePlayIfPossible, Cards: Deck{C(Rank5, Diamonds), C(Two, Hearts)}}, "not a valid hand"},
+		{"unknown kind", QueuedAction{Kind: "autoPlay"}, "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := queueTestGame()
+			err := QueueAction(game, game.Players[1], tt.action)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("QueueAction error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQueueAction_LeaderDecides(t *testing.T) {
+	game := queueTestGame()
+	if err := QueueAction(game, game.Players[0], QueuedAction{Kind: QueueAutoPass}); err != nil {
+		t.Fatal(err)
+	}
+	if game.CurrentTurnPlayerIndex != 0 || game.QueuedActions["player1"] != nil {
+		t.Errorf("leader's auto-pass: turn %d, queued %v; want it dropped", game.CurrentTurnPlayerIndex, game.QueuedActions["player1"])
+	}
+
+	// Queuing a play on your own turn makes it straight away.
+	if err := QueueAction(game, game.Players[0], QueuedAction{Kind: QueuePlayIfPossible, Cards: Deck{C(Rank3, Diamonds)}}); err != nil {
+		t.Fatal(err)
+	}
+	if game.LastPlayedHand == nil || game.CurrentTurnPlayerIndex != 1 {
+		t.Errorf("queued play on own turn: table %v, turn %d", game.LastPlayedHand, game.CurrentTurnPlayerIndex)
+	}
+}
+
+func TestWebSocket_QueuedActionsArePrivate(t *testing.T) {
+	h := newWSHarness(t, 3, []Deck{
+		{{Rank3, Diamonds}, {Rank4, Clubs}, {Two, Spades}},
+		{{Rank5, Diamonds}, {Two, Hearts}},
+		{{Rank7, Spades}, {Rank8, Spades}},
+	})
+	cs, _ := h.connectAll(3)
+
+	cs[1].send(map[string]interface{}{"type": "queueAction", "kind": "playIfPossible", "cards": Deck{{Two, Hearts}}})
+	got := states(cs)
+	if got[1].QueuedAction == nil || got[0].QueuedAction != nil || got[2].QueuedAction != nil {
+		t.Fatalf("queued action seen as %v / %v / %v, want only player2 to see it", got[0].QueuedAction, got[1].QueuedAction, got[2].QueuedAction)
+	}
+	cs[2].send(map[string]interface{}{"type": "queueAction", "kind": "autoPass"})
+	states(cs)
+
+	// One broadcast covers player1's play and both queued actions.
+	cs[0].play(Card{Rank3, Diamonds})
+	got = states(cs)
+	h.assertConsistent(cs, got)
+	if s := got[0]; s.CurrentPlayerID != "player1" || s.LastPlayedHand == nil || s.LastPlayedHand.PlayerID != "player2" || s.PassCount != 1 {
+		t.Fatalf("after queued actions: %+v, want player2's 2 on the table and player1 to answer", s)
+	}
+	if got[1].QueuedAction != nil || got[2].QueuedAction == nil {
+		t.Errorf("queued actions after the turn: %v / %v, want the play used up and the auto-pass kept", got[1].QueuedAction, got[2].QueuedAction)
+	}
+
+	cs[2].send(map[string]interface{}{"type": "queueAction", "kind": "none"})
+	if got = states(cs); got[2].QueuedAction != nil {
+		t.Errorf("queued action %v after clearing it", got[2].QueuedAction)
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// DefaultRating is the rating given to new accounts.
+	DefaultRating = 1500.0
+	// matchKFactor scales rating changes from the final match standings.
+	matchKFactor = 32.0
+	// roundKFactor scales rating changes from each round's placements. It is smaller
+	// than matchKFactor because a match contributes many rounds.
+	roundKFactor = 4.0
+)
+
+// expectedScore is the Elo probability that a player rated ra beats one rated rb.
+func expectedScore(ra, rb float64) float64 {
+	return 1 / (1 + math.Pow(10, (rb-ra)/400))
+}
+
+// PairwiseEloDeltas computes rating changes for a multiplayer result by treating it as
+// a set of head-to-head games: every pair of players is scored 1/0.5/0 on their placements
+// (lower placement is better; equal placements draw). Each player's K is split across
+// their n-1 opponents so a multiplayer result moves ratings about as much as one 1v1 game.
+func PairwiseEloDeltas(ratings map[string]float64, placements map[string]int, k float64) map[string]float64 {
+	deltas := make(map[string]float64, len(ratings))
+	ids := make([]string, 0, len(ratings))
+	for id := range ratings {
+		if _, ok := placements[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) < 2 {
+		return deltas
+	}
+	perPair := k / float64(len(ids)-1)
+	for _, a := range ids {
+		for _, b := range ids {
+			if a == b {
+				continue
+			}
+			actual := 0.5
+			if placements[a] < placements[b] {
+				actual = 1
+			} else if placements[a] > placements[b] {
+				actual = 0
+			}
+			deltas[a] += perPair * (actual - expectedScore(ratings[a], ratings[b]))
+		}
+	}
+	return deltas
+}
+
+// placementsFromScores converts scores (lower is better) into placements where tied
+// scores share a placement: {10, 20, 20, 30} -> {1, 2, 2, 4}.
+func placementsFromScores(scores map[string]int) map[string]int {
+	placements := make(map[string]int, len(scores))
+	for id, score := range scores {
+		place := 1
+		for _, other := range scores {
+			if other < score {
+				place++
+			}
+		}
+		placements[id] = place
+	}
+	return placements
+}
+
+// matchRatingDeltas computes the rating change for each account-bound seat from a finished
+// match: one pairwise update on the final standings (the overall winner always places first)
+// plus a smaller update for each round's placements.
+func matchRatingDeltas(game *GameState, ratings map[string]float64) map[string]float64 {
+	total := make(map[string]float64)
+
+	finalScores := make(map[string]int)
+	for id := range ratings {
+		finalScores[id] = game.Scores[id]
+	}
+	finalPlacements := placementsFromScores(finalScores)
+	if _, ok := finalPlacements[game.OverallWinnerID]; ok {
+		finalPlacements[game.OverallWinnerID] = 0 // Ahead of everyone, including players tied on score
+	}
+	for id, d := range PairwiseEloDeltas(ratings, finalPlacements, matchKFactor) {
+		total[id] += d
+	}
+
+	for _, round := range game.RoundScoresHistory {
+		roundScores := make(map[string]int)
+		for id := range ratings {
+			if score, ok := round[id]; ok {
+				roundScores[id] = score
+			}
+		}
+		for id, d := range PairwiseEloDeltas(ratings, placementsFromScores(roundScores), roundKFactor) {
+			total[id] += d
+		}
+	}
+	return total
+}
+
+// updateRatings applies rating changes for a finished match to every account-bound seat
+// and refreshes the seats' displayed ratings. Guests are not rated, and neither is a match
+// in which an administrator ended a round. Assumes gameInstanceMutex is held by the caller.
+func updateRatings(store *AccountStore, game *GameState) {
+	if store == nil || !game.IsMatchOver {
+		return
+	}
+	if game.forcedRound {
+		gameLog(game).Info("match not rated: an administrator ended a round")
+		return
+	}
+	ratings := make(map[string]float64) // Keyed by player ID
+	accountIDs := make(map[string]string)
+	for _, p := range game.Players {
+		if p.AccountID == "" {
+			continue
+		}
+		account, err := store.Account(p.AccountID)
+		if err != nil {
+			playerLog(game, p).Error("cannot rate player", "account", p.AccountID, "err", err)
+			continue
+		}
+		ratings[p.ID] = account.Rating
+		accountIDs[p.ID] = p.AccountID
+	}
+	if len(ratings) < 2 {
+		return
+	}
+
+	for playerID, delta := range matchRatingDeltas(game, ratings) {
+		newRating, err := store.AdjustRating(accountIDs[playerID], delta)
+		if err != nil {
+			gameLog(game).Error("cannot update rating", "player", playerID, "account", accountIDs[playerID], "err", err)
+			continue
+		}
+		for _, p := range game.Players {
+			if p.ID == playerID {
+				p.Rating = newRating
+			}
+		}
+		gameLog(game).Info("rating changed", "player", playerID, "account", accountIDs[playerID], "delta", delta, "rating", newRating)
+	}
+}
+
+// GroupByRating splits entrants into as few tables of at most tableSize as possible, with
+// similar ratings: entrants are ordered by rating (highest first) and seated in consecutive
+// groups. When they do not divide evenly, table sizes differ by at most one.
+func GroupByRating(entrants []*TournamentEntrant, tableSize int) [][]*TournamentEntrant {
+	if tableSize <= 0 || len(entrants) == 0 {
+		return nil
+	}
+	sorted := make([]*TournamentEntrant, len(entrants))
+	copy(sorted, entrants)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Rating > sorted[j].Rating })
+
+	numTables := (len(sorted) + tableSize - 1) / tableSize
+	groups := make([][]*TournamentEntrant, numTables)
+	start := 0
+	for i := range groups {
+		size := len(sorted) / numTables
+		if i < len(sorted)%numTables {
+			size++
+		}
+		groups[i] = sorted[start : start+size]
+		start += size
+	}
+	return groups
+}
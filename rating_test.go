@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestPlacementsFromScores(t *testing.T) {
+	got := placementsFromScores(map[string]int{"a": 10, "b": 20, "c": 20, "d": 30})
+	want := map[string]int{"a": 1, "b": 2, "c": 2, "d": 4}
+	for id, place := range want {
+		if got[id] != place {
+			t.Errorf("placementsFromScores()[%s] = %d, want %d", id, got[id], place)
+		}
+	}
+}
+
+func TestPairwiseEloDeltas(t *testing.T) {
+	ratings := map[string]float64{"a": 1500, "b": 1500, "c": 1500, "d": 1500}
+
+	deltas := PairwiseEloDeltas(ratings, map[string]int{"a": 1, "b": 2, "c": 3, "d": 4}, 32)
+	sum := 0.0
+	for _, d := range deltas {
+		sum += d
+	}
+	if math.Abs(sum) > 1e-9 {
+		t.Errorf("PairwiseEloDeltas() sum = %f, want 0 (zero-sum)", sum)
+	}
+	if !(deltas["a"] > deltas["b"] && deltas["b"] > deltas["c"] && deltas["c"] > deltas["d"]) {
+		t.Errorf("PairwiseEloDeltas() = %v, want strictly decreasing by placement", deltas)
+	}
+	// Winning every pairing among equals earns K/2.
+	if math.Abs(deltas["a"]-16) > 1e-9 {
+		t.Errorf("PairwiseEloDeltas()[a] = %f, want 16", deltas["a"])
+	}
+
+	// A full draw among equals changes nothing; an upset moves the underdog more than a win by the favourite would.
+	draw := PairwiseEloDeltas(ratings, map[string]int{"a": 1, "b": 1, "c": 1, "d": 1}, 32)
+	for id, d := range draw {
+		if d != 0 {
+			t.Errorf("PairwiseEloDeltas() draw[%s] = %f, want 0", id, d)
+		}
+	}
+	uneven := map[string]float64{"fav": 1800, "dog": 1400}
+	upset := PairwiseEloDeltas(uneven, map[string]int{"fav": 2, "dog": 1}, 32)
+	expected := PairwiseEloDeltas(uneven, map[string]int{"fav": 1, "dog": 2}, 32)
+	if upset["dog"] <= expected["fav"] {
+		t.Errorf("upset gain %f should exceed favourite's expected gain %f", upset["dog"], expected["fav"])
+	}
+}
+
+func TestGroupByRating(t *testing.T) {
+	entrants := []*TournamentEntrant{{Name: "a", Rating: 1400}, {Name: "b", Rating: 1700}, {Name: "c", Rating: 1500}, {Name: "d", Rating: 1600}, {Name: "e", Rating: 1550}}
+	tests := []struct {
+		tableSize int
+		want      string
+	}{
+		{2, "[[b d] [e c] [a]]"},
+		{4, "[[b d e] [c a]]"}, // Two tables of three and two, not four and one
+		{5, "[[b d e c a]]"},
+	}
+	for _, tt := range tests {
+		tables := GroupByRating(entrants, tt.tableSize)
+		names := make([][]string, len(tables))
+		for i, table := range tables {
+			for _, e := range table {
+				names[i] = append(names[i], e.Name)
+			}
+		}
+		if got := fmt.Sprint(names); got != tt.want {
+			t.Errorf("GroupByRating(%d) = %s, want %s", tt.tableSize, got, tt.want)
+		}
+	}
+}
@@ -2,26 +2,242 @@ package main
 
 import (
 	"fmt"
-	// "sort" // Not directly used at top level of this file, but methods might use it via Deck.Sort()
+	"sort"
 )
 
+// SingleCardLeftMode selects how the "next player has one card left" house rule is enforced.
+type SingleCardLeftMode int
+
+const (
+	SingleCardLeftOff      SingleCardLeftMode = iota // Rule not in use
+	SingleCardLeftReject                             // Violating plays are rejected
+	SingleCardLeftPenalize                           // Violating plays are allowed but penalized
+)
+
+var singleCardLeftModeNames = []string{"off", "reject", "penalty"}
+
+// String returns the name of the mode.
+func (m SingleCardLeftMode) String() string {
+	if m < 0 || int(m) >= len(singleCardLeftModeNames) {
+		return "unknown"
+	}
+	return singleCardLeftModeNames[m]
+}
+
+// ParseSingleCardLeftMode returns the mode with the given name.
+func ParseSingleCardLeftMode(name string) (SingleCardLeftMode, error) {
+	for i, n := range singleCardLeftModeNames {
+		if n == name {
+			return SingleCardLeftMode(i), nil
+		}
+	}
+	return SingleCardLeftOff, fmt.Errorf("unknown single-card-left mode %q (available: %v)", name, singleCardLeftModeNames)
+}
+
+// GameMode selects the family of combinations the rule engine accepts.
+type GameMode int
+
+const (
+	ModeBigTwo  GameMode = iota // Singles, pairs, triples and five-card poker hands
+	ModeTienLen                 // Singles, pairs, triples, sequences, pair sequences and four of a kind
+)
+
+// Suit orders, lowest suit first.
+var (
+	BigTwoSuitOrder   = []Suit{Diamonds, Clubs, Hearts, Spades}
+	TienLenSuitOrder  = []Suit{Spades, Clubs, Diamonds, Hearts}
+	PusoyDosSuitOrder = []Suit{Clubs, Spades, Hearts, Diamonds}
+)
+
+// RuleOptions holds the configurable house rules applied by the rule engine.
+type RuleOptions struct {
+	// Name identifies the ruleset in match history and statistics.
+	Name string
+
+	// Mode selects which combinations may be played and how they beat each other.
+	Mode GameMode
+	// SuitOrder ranks the suits for breaking ties, lowest first. Nil means the Big Two order.
+	SuitOrder []Suit
+
+	// RequireLowestCardLead forces the first play of a round to contain the lowest card dealt.
+	// With a full four-player deal this is the 3 of Diamonds.
+	RequireLowestCardLead bool
+
+	// SingleCardLeftRule: when the next player holds one card, a player leading or
+	// following with a single must play their highest single.
+	SingleCardLeftRule SingleCardLeftMode
+	// SingleCardLeftPenalty is added to the offender's round score for each violation
+	// when SingleCardLeftRule is SingleCardLeftPenalize.
+	SingleCardLeftPenalty int
+
+	// CardExchangeCount is how many cards the previous round's biggest loser gives the
+	// winner (and the winner returns) after the deal. Zero disables the exchange.
+	CardExchangeCount int
+
+	// Jokers is the number of jokers (0-2) added to the deck. A joker stands in for any card
+	// in pairs, triples and five-card hands. They are dealt on top of the natural cards, so
+	// at four players the hands holding one have 14 cards.
+	Jokers int
+	// JokerSingleBeatsTwo lets a joker played alone beat every 2. Otherwise a lone joker
+	// counts as the highest 2.
+	JokerSingleBeatsTwo bool
+
+	// InstantWins lists the special deals that win the round immediately. Empty disables them.
+	InstantWins []InstantWinKind
+	// InstantWinPoints, if positive, is charged to every other player when a round is won
+	// on the deal, instead of the usual scoring for the cards they hold.
+	InstantWinPoints int
+}
+
+// DefaultRuleOptions returns the standard Big Two rules.
+func DefaultRuleOptions() RuleOptions {
+	return RuleOptions{
+		Name:                  RulesStandard,
+		RequireLowestCardLead: true,
+		SingleCardLeftRule:    SingleCardLeftOff,
+		SingleCardLeftPenalty: 10,
+	}
+}
+
+// Ruleset preset names.
+const (
+	RulesStandard = "standard"
+	RulesTienLen  = "tienLen"
+	RulesPusoyDos = "pusoyDos"
+)
+
+var rulePresets = map[string]func() RuleOptions{
+	RulesStandard: DefaultRuleOptions,
+	// Tiến Lên: no poker hands; sequences of any length and consecutive pairs; spades lowest, hearts highest.
+	RulesTienLen: func() RuleOptions {
+		opts := DefaultRuleOptions()
+		opts.Name = RulesTienLen
+		opts.Mode = ModeTienLen
+		opts.SuitOrder = TienLenSuitOrder
+		return opts
+	},
+	// Pusoy Dos: Big Two hands with clubs lowest and diamonds highest.
+	RulesPusoyDos: func() RuleOptions {
+		opts := DefaultRuleOptions()
+		opts.Name = RulesPusoyDos
+		opts.SuitOrder = PusoyDosSuitOrder
+		return opts
+	},
+}
+
+// NewRuleOptions returns the ruleset preset with the given name.
+func NewRuleOptions(name string) (RuleOptions, error) {
+	preset, ok := rulePresets[name]
+	if !ok {
+		return RuleOptions{}, fmt.Errorf("unknown ruleset %q (available: %v)", name, RulePresetNames())
+	}
+	return preset(), nil
+}
+
+// RulePresetNames returns the names of all ruleset presets, sorted.
+func RulePresetNames() []string {
+	names := make([]string, 0, len(rulePresets))
+	for name := range rulePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Rule error codes sent to clients alongside the human-readable message.
+const (
+	ErrCodeOpeningCardRequired   = "openingCardRequired"
+	ErrCodeMustPlayHighestSingle = "mustPlayHighestSingle"
+)
+
+// RuleError describes a play rejected by one of the configurable rules.
+// Code is a stable identifier clients can match on; Message is for display.
+type RuleError struct {
+	Code    string `json:"code"`
+	Message string `json:"content"`
+}
+
+// Error implements the error interface.
+func (e *RuleError) Error() string {
+	return e.Message
+}
+
 // BigTwoRuleEngine encapsulates the core game logic for Big Two.
 type BigTwoRuleEngine struct {
-	// No fields needed for now, as rules are stateless in this implementation.
+	Options RuleOptions
 }
 
-// NewBigTwoRuleEngine creates a new instance of the rule engine.
+// NewBigTwoRuleEngine creates a new instance of the rule engine with the default rules.
 func NewBigTwoRuleEngine() *BigTwoRuleEngine {
-	return &BigTwoRuleEngine{}
+	return NewBigTwoRuleEngineWithOptions(DefaultRuleOptions())
+}
+
+// NewBigTwoRuleEngineWithOptions creates a rule engine using the given house rules.
+func NewBigTwoRuleEngineWithOptions(opts RuleOptions) *BigTwoRuleEngine {
+	return &BigTwoRuleEngine{Options: opts}
+}
+
+// suitValue returns the suit's strength in the ruleset's suit order (0 is lowest).
+func (re *BigTwoRuleEngine) suitValue(s Suit) int {
+	if re.Options.SuitOrder == nil {
+		return int(s) // Suit constants are declared in Big Two order
+	}
+	for i, suit := range re.Options.SuitOrder {
+		if suit == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// CardLess reports whether a ranks below b in this ruleset (rank first, then suit order).
+func (re *BigTwoRuleEngine) CardLess(a, b Card) bool {
+	if a.Rank == b.Rank {
+		return re.suitValue(a.Suit) < re.suitValue(b.Suit)
+	}
+	return a.Rank < b.Rank
+}
+
+// highestSuit returns the strongest suit among the cards.
+func (re *BigTwoRuleEngine) highestSuit(cards Deck) Suit {
+	best := cards[0].Suit
+	for _, c := range cards[1:] {
+		if re.suitValue(c.Suit) > re.suitValue(best) {
+			best = c.Suit
+		}
+	}
+	return best
 }
 
 // DeterminePlayedHand analyzes a set of cards and determines if they form a valid Big 2 hand.
 // It returns the PlayedHand struct (with type, effective rank/suit) or an error if invalid.
+// Hands containing jokers are resolved to their strongest interpretation.
 func (re *BigTwoRuleEngine) DeterminePlayedHand(selectedCards Deck) (*PlayedHand, error) {
-	numCards := len(selectedCards)
+	if countJokers(selectedCards) > 0 {
+		return re.resolveJokers(selectedCards)
+	}
+	return re.classifyNatural(selectedCards)
+}
+
+// classifyNatural classifies a set of cards without jokers under the ruleset's game mode.
+func (re *BigTwoRuleEngine) classifyNatural(selectedCards Deck) (*PlayedHand, error) {
+	if re.Options.Mode == ModeTienLen {
+		return re.determineTienLenHand(selectedCards)
+	}
+	return re.determineNaturalHand(selectedCards)
+}
+
+// determineNaturalHand classifies a set of cards that contains no jokers.
+func (re *BigTwoRuleEngine) determineNaturalHand(unsortedCards Deck) (*PlayedHand, error) {
+	numCards := len(unsortedCards)
 	if numCards == 0 {
 		return nil, fmt.Errorf("no cards selected")
 	}
+	// The checks below rely on rank-then-suit order, so classify a sorted copy
+	// rather than trusting the caller's order.
+	selectedCards := make(Deck, numCards)
+	copy(selectedCards, unsortedCards)
+	selectedCards.Sort()
 
 	var handType HandType = InvalidHand
 	var effectiveRank Rank = -1
@@ -36,8 +252,7 @@ func (re *BigTwoRuleEngine) DeterminePlayedHand(selectedCards Deck) (*PlayedHand
 		if selectedCards[0].Rank == selectedCards[1].Rank {
 			handType = Pair
 			effectiveRank = selectedCards[0].Rank
-			// Assuming cards are sorted by rank then suit, selectedCards[1] has the defining suit for the pair.
-			effectiveSuit = selectedCards[1].Suit
+			effectiveSuit = re.highestSuit(selectedCards) // The stronger card of the pair decides ties
 		} else {
 			return nil, fmt.Errorf("not a valid pair (ranks differ)")
 		}
@@ -50,7 +265,6 @@ func (re *BigTwoRuleEngine) DeterminePlayedHand(selectedCards Deck) (*PlayedHand
 			return nil, fmt.Errorf("not a valid triple")
 		}
 	case 5:
-		// Cards are assumed sorted as they come from a sorted player hand.
 		// Check in order of strength for 5-card hands.
 		if isSF, sfRank, sfSuit := re.isStraightFlush(selectedCards); isSF {
 			handType = StraightFlush
@@ -91,12 +305,8 @@ func (re *BigTwoRuleEngine) DeterminePlayedHand(selectedCards Deck) (*PlayedHand
 		return nil, fmt.Errorf("selected cards do not form a valid Big 2 hand type")
 	}
 
-	playedCardsCopy := make(Deck, len(selectedCards))
-	copy(playedCardsCopy, selectedCards)
-	playedCardsCopy.Sort() // Ensure cards in the returned PlayedHand are always sorted.
-
 	return &PlayedHand{
-		Cards:         playedCardsCopy,
+		Cards:         selectedCards, // Always sorted
 		HandType:      handType,
 		EffectiveRank: effectiveRank,
 		EffectiveSuit: effectiveSuit,
@@ -108,28 +318,37 @@ func (re *BigTwoRuleEngine) BeatsLastHand(currentPlay *PlayedHand, lastPlayedHan
 	if lastPlayedHand == nil {
 		return currentPlay.HandType != InvalidHand
 	}
+	return re.ValueBeats(currentPlay.Value(), lastPlayedHand.Value())
+}
 
-	currentPlayerIsBomb := currentPlay.HandType == FourOfAKindPlusOne || currentPlay.HandType == StraightFlush
-	lastPlayerIsBomb := lastPlayedHand.HandType == FourOfAKindPlusOne || lastPlayedHand.HandType == StraightFlush
+// ValueBeats is BeatsLastHand on classified hand values, for callers that evaluate
+// hands without building a PlayedHand.
+func (re *BigTwoRuleEngine) ValueBeats(currentPlay, lastPlayedHand HandValue) bool {
+	if re.Options.Mode == ModeTienLen {
+		return re.tienLenBeats(currentPlay, lastPlayedHand)
+	}
+
+	currentPlayerIsBomb := currentPlay.IsBomb()
+	lastPlayerIsBomb := lastPlayedHand.IsBomb()
 
 	if currentPlayerIsBomb {
 		if !lastPlayerIsBomb {
 			return true // Bomb beats any non-bomb
 		}
 		// Both are bombs
-		if currentPlay.HandType == StraightFlush && lastPlayedHand.HandType == FourOfAKindPlusOne {
+		if currentPlay.Type == StraightFlush && lastPlayedHand.Type == FourOfAKindPlusOne {
 			return true // SF beats FOAK
 		}
-		if currentPlay.HandType == FourOfAKindPlusOne && lastPlayedHand.HandType == StraightFlush {
+		if currentPlay.Type == FourOfAKindPlusOne && lastPlayedHand.Type == StraightFlush {
 			return false // FOAK doesn't beat SF
 		}
 		// Same type of bomb, compare by rank, then suit if SF
-		if currentPlay.EffectiveRank > lastPlayedHand.EffectiveRank {
+		if currentPlay.Rank > lastPlayedHand.Rank {
 			return true
 		}
-		if currentPlay.EffectiveRank == lastPlayedHand.EffectiveRank {
-			if currentPlay.HandType == StraightFlush { // SF ties broken by suit
-				return currentPlay.EffectiveSuit > lastPlayedHand.EffectiveSuit
+		if currentPlay.Rank == lastPlayedHand.Rank {
+			if currentPlay.Type == StraightFlush { // SF ties broken by suit
+				return re.suitValue(currentPlay.Suit) > re.suitValue(lastPlayedHand.Suit)
 			}
 			return false // FOAKs of same rank, or SFs of same rank & suit: cannot beat
 		}
@@ -141,21 +360,21 @@ func (re *BigTwoRuleEngine) BeatsLastHand(currentPlay *PlayedHand, lastPlayedHan
 	}
 
 	// Standard hand comparison (neither is a bomb)
-	if len(currentPlay.Cards) != len(lastPlayedHand.Cards) {
+	if currentPlay.Size != lastPlayedHand.Size {
 		return false // Must be same number of cards
 	}
 
 	// If hand types are different (and it's 5-card hands, and neither are bombs - handled above)
-	if currentPlay.HandType != lastPlayedHand.HandType {
+	if currentPlay.Type != lastPlayedHand.Type {
 		// Only allow different hand types if they are both 5-card hands (non-bomb type)
-		if len(currentPlay.Cards) == 5 { // Both must be 5 cards due to len check above
+		if currentPlay.Size == 5 { // Both must be 5 cards due to len check above
 			// Allow stronger 5-card hand type to beat weaker 5-card hand type
 			// This relies on HandType enum values being in order of strength for 5-card hands.
 			// Straight < Flush < FullHouse (already covered by bomb logic: < FourOfAKind < StraightFlush)
 			// We only need to compare Straight, Flush, FullHouse here as bombs are handled.
 			// And FourOfAKindPlusOne and StraightFlush are already handled by the bomb logic above.
 			// So, this comparison effectively applies to Straight, Flush, and FullHouse.
-			return currentPlay.HandType > lastPlayedHand.HandType
+			return currentPlay.Type > lastPlayedHand.Type
 		} else {
 			// If not 5-card hands, types must match (e.g. pair vs pair, single vs single)
 			return false
@@ -163,17 +382,17 @@ func (re *BigTwoRuleEngine) BeatsLastHand(currentPlay *PlayedHand, lastPlayedHan
 	}
 
 	// Hand types are the same, compare by effective rank, then suit if applicable.
-	if currentPlay.EffectiveRank > lastPlayedHand.EffectiveRank {
+	if currentPlay.Rank > lastPlayedHand.Rank {
 		return true
 	}
-	if currentPlay.EffectiveRank < lastPlayedHand.EffectiveRank {
+	if currentPlay.Rank < lastPlayedHand.Rank {
 		return false
 	}
 
 	// Ranks are equal, compare by suit where applicable
-	switch currentPlay.HandType {
+	switch currentPlay.Type {
 	case Single, Pair, Straight, Flush:
-		return currentPlay.EffectiveSuit > lastPlayedHand.EffectiveSuit
+		return re.suitValue(currentPlay.Suit) > re.suitValue(lastPlayedHand.Suit)
 	case Triple, FullHouse:
 		return false // Ranks are equal, suit doesn't break ties
 	default:
@@ -181,6 +400,41 @@ func (re *BigTwoRuleEngine) BeatsLastHand(currentPlay *PlayedHand, lastPlayedHan
 	}
 }
 
+// ValidateOpeningPlay checks the first play of a round against the lowest-card-lead rule.
+// openingCard is the lowest card dealt this round; nil means no card is required.
+// Returns a *RuleError if the play does not include the required card.
+func (re *BigTwoRuleEngine) ValidateOpeningPlay(play *PlayedHand, openingCard *Card) error {
+	if !re.Options.RequireLowestCardLead || openingCard == nil || play == nil {
+		return nil
+	}
+	if !containsCard(play.Cards, *openingCard) {
+		return &RuleError{
+			Code:    ErrCodeOpeningCardRequired,
+			Message: fmt.Sprintf("The first play of the round must include the %s.", openingCard.String()),
+		}
+	}
+	return nil
+}
+
+// CheckSingleCardLeft applies the "next player has one card left" rule to a play.
+// hand is the current player's hand before the play and nextPlayerCardCount is the
+// size of the next player's hand. Returns a *RuleError if the play is a single but a
+// higher legal single was available. The caller decides whether to reject or penalize
+// based on Options.SingleCardLeftRule.
+func (re *BigTwoRuleEngine) CheckSingleCardLeft(play *PlayedHand, hand Deck, lastPlayed *PlayedHand, nextPlayerCardCount int) error {
+	if re.Options.SingleCardLeftRule == SingleCardLeftOff || play == nil || play.HandType != Single || nextPlayerCardCount != 1 {
+		return nil
+	}
+	highest := re.highestLegalSingle(hand, lastPlayed)
+	if highest != nil && re.CardLess(play.Cards[0], highest.Cards[0]) {
+		return &RuleError{
+			Code:    ErrCodeMustPlayHighestSingle,
+			Message: fmt.Sprintf("The next player has one card left: you must play your highest single (%s).", highest.Cards[0].String()),
+		}
+	}
+	return nil
+}
+
 // --- Helper functions for 5-card hand validation (methods of BigTwoRuleEngine) ---
 
 func (re *BigTwoRuleEngine) isStraight(cards Deck) (bool, Rank, Suit) {
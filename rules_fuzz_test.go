@@ -0,0 +1,274 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ruleEnginePresets returns an engine for every ruleset preset.
+func ruleEnginePresets(t testing.TB) map[string]*BigTwoRuleEngine {
+	engines := make(map[string]*BigTwoRuleEngine)
+	for _, name := range RulePresetNames() {
+		opts, err := NewRuleOptions(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		engines[name] = NewBigTwoRuleEngineWithOptions(opts)
+	}
+	return engines
+}
+
+// cardsFromBytes turns fuzz input into up to max distinct natural cards, in input order.
+func cardsFromBytes(data []byte, max int) Deck {
+	deck := NewDeck()
+	seen := make(map[Card]bool)
+	var cards Deck
+	for _, b := range data {
+		c := deck[int(b)%len(deck)]
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+		cards = append(cards, c)
+		if len(cards) == max {
+			break
+		}
+	}
+	return cards
+}
+
+// oracleClassify is a brute-force reference classifier for the Big Two modes, written from
+// the rules rather than from the engine: count ranks and suits, and compare the ranks
+// against an explicit list of every allowed straight.
+func oracleClassify(re *BigTwoRuleEngine, cards Deck) (HandValue, bool) {
+	invalid := HandValue{Type: InvalidHand, Rank: -1, Suit: -1}
+	rankCounts := make(map[Rank]int)
+	suits := make(map[Suit]bool)
+	var highest Card
+	for i, c := range cards {
+		rankCounts[c.Rank]++
+		suits[c.Suit] = true
+		if i == 0 || c.Rank > highest.Rank || (c.Rank == highest.Rank && re.suitValue(c.Suit) > re.suitValue(highest.Suit)) {
+			highest = c
+		}
+	}
+	switch len(cards) {
+	case 1:
+		return HandValue{Type: Single, Size: 1, Rank: highest.Rank, Suit: highest.Suit}, true
+	case 2:
+		if len(rankCounts) == 1 {
+			return HandValue{Type: Pair, Size: 2, Rank: highest.Rank, Suit: highest.Suit}, true
+		}
+		return invalid, false
+	case 3:
+		if len(rankCounts) == 1 {
+			return HandValue{Type: Triple, Size: 3, Rank: highest.Rank, Suit: -1}, true
+		}
+		return invalid, false
+	case 5:
+	default:
+		return invalid, false
+	}
+
+	// Every allowed straight as (ranks, rank of the card that decides it).
+	type straightDef struct {
+		ranks []Rank
+		top   Rank
+	}
+	var straights []straightDef
+	for low := Rank3; low+4 <= Two; low++ {
+		straights = append(straights, straightDef{[]Rank{low, low + 1, low + 2, low + 3, low + 4}, low + 4})
+	}
+	straights = append(straights, straightDef{[]Rank{Rank3, Rank4, Rank5, Ace, Two}, Rank5})
+
+	straight, straightRank, straightSuit := false, Rank(-1), Suit(-1)
+	for _, def := range straights {
+		matches := len(rankCounts) == 5
+		for _, r := range def.ranks {
+			if rankCounts[r] != 1 {
+				matches = false
+			}
+		}
+		if matches {
+			straight, straightRank = true, def.top
+			for _, c := range cards {
+				if c.Rank == def.top {
+					straightSuit = c.Suit
+				}
+			}
+		}
+	}
+	flush := len(suits) == 1
+
+	var quad, triple, pair Rank = -1, -1, -1
+	for r, n := range rankCounts {
+		switch n {
+		case 4:
+			quad = r
+		case 3:
+			triple = r
+		case 2:
+			pair = r
+		}
+	}
+	switch {
+	case straight && flush:
+		return HandValue{Type: StraightFlush, Size: 5, Rank: straightRank, Suit: straightSuit}, true
+	case quad >= 0:
+		return HandValue{Type: FourOfAKindPlusOne, Size: 5, Rank: quad, Suit: -1}, true
+	case triple >= 0 && pair >= 0:
+		return HandValue{Type: FullHouse, Size: 5, Rank: triple, Suit: -1}, true
+	case flush:
+		return HandValue{Type: Flush, Size: 5, Rank: highest.Rank, Suit: highest.Suit}, true
+	case straight:
+		return HandValue{Type: Straight, Size: 5, Rank: straightRank, Suit: straightSuit}, true
+	}
+	return invalid, false
+}
+
+// reversed returns the cards in reverse order.
+func reversed(cards Deck) Deck {
+	out := make(Deck, len(cards))
+	for i, c := range cards {
+		out[len(cards)-1-i] = c
+	}
+	return out
+}
+
+func FuzzDeterminePlayedHand(f *testing.F) {
+	f.Add([]byte{0})
+	f.Add([]byte{0, 13})
+	f.Add([]byte{4, 3, 2, 1, 0})         // 7-3 of Diamonds, reversed
+	f.Add([]byte{12, 11, 0, 1, 2})       // 2, A, 3, 4, 5 of Diamonds
+	f.Add([]byte{5, 18, 31, 44, 7})      // Four 8s and a 10
+	f.Add([]byte{9, 23, 11, 12, 13, 14}) // Six cards
+	engines := ruleEnginePresets(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cards := cardsFromBytes(data, 6)
+		if len(cards) == 0 {
+			return
+		}
+		for name, re := range engines {
+			played, err := re.DeterminePlayedHand(cards)
+			again, errAgain := re.DeterminePlayedHand(reversed(cards))
+			if (err == nil) != (errAgain == nil) || (err == nil && played.Value() != again.Value()) {
+				t.Fatalf("%s: %s and its reverse classify differently: %v/%v vs %v/%v", name, cards, played, err, again, errAgain)
+			}
+			if re.Options.Mode != ModeBigTwo {
+				continue
+			}
+			want, ok := oracleClassify(re, cards)
+			if ok != (err == nil) || (ok && played.Value() != want) {
+				t.Fatalf("%s: %s classified as %v (err %v), oracle says %+v (ok %v)", name, cards, played, err, want, ok)
+			}
+			if got, gotOK := re.ClassifyCardSet(cards.CardSet()); gotOK != ok || (ok && got != want) {
+				t.Fatalf("%s: ClassifyCardSet(%s) = %+v (ok %v), oracle says %+v", name, cards, got, gotOK, want)
+			}
+		}
+	})
+}
+
+func FuzzBeatsLastHand(f *testing.F) {
+	f.Add([]byte{0}, []byte{12})
+	f.Add([]byte{0, 13}, []byte{1, 14})
+	f.Add([]byte{0, 1, 2, 3, 4}, []byte{14, 15, 16, 17, 18})
+	f.Add([]byte{5, 18, 31, 44, 7}, []byte{12})
+	engines := ruleEnginePresets(f)
+
+	f.Fuzz(func(t *testing.T, a, b []byte) {
+		cardsA, cardsB := cardsFromBytes(a, 8), cardsFromBytes(b, 8)
+		for name, re := range engines {
+			handA, errA := re.DeterminePlayedHand(cardsA)
+			handB, errB := re.DeterminePlayedHand(cardsB)
+			if errA != nil || errB != nil {
+				continue
+			}
+			if re.BeatsLastHand(handA, handA) {
+				t.Fatalf("%s: %s beats itself", name, cardsA)
+			}
+			if re.BeatsLastHand(handA, handB) && re.BeatsLastHand(handB, handA) {
+				t.Fatalf("%s: %s and %s beat each other", name, cardsA, cardsB)
+			}
+		}
+	})
+}
+
+// handPool deals random hands and collects every play the move generator finds.
+func handPool(re *BigTwoRuleEngine, rng *rand.Rand, deals int) []*PlayedHand {
+	var pool []*PlayedHand
+	for i := 0; i < deals; i++ {
+		deck := NewDeck()
+		rng.Shuffle(len(deck), func(i, j int) { deck[i], deck[j] = deck[j], deck[i] })
+		pool = append(pool, re.GenerateMoves(deck[:13], nil)...)
+	}
+	return pool
+}
+
+func TestRuleEngine_Properties(t *testing.T) {
+	for name, re := range ruleEnginePresets(t) {
+		t.Run(name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1))
+			pool := handPool(re, rng, 3)
+			if len(pool) > 400 {
+				rng.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+				pool = pool[:400]
+			}
+
+			t.Run("Generated moves are accepted in any order", func(t *testing.T) {
+				for _, hand := range pool {
+					shuffled := append(Deck(nil), hand.Cards...)
+					rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+					again, err := re.DeterminePlayedHand(shuffled)
+					if err != nil || again.Value() != hand.Value() {
+						t.Fatalf("%v (%+v) reclassified from %s as %v, err %v", hand.Cards, hand.Value(), shuffled, again, err)
+					}
+				}
+			})
+
+			t.Run("Antisymmetry", func(t *testing.T) {
+				for _, a := range pool {
+					for _, b := range pool {
+						if re.BeatsLastHand(a, b) && re.BeatsLastHand(b, a) {
+							t.Fatalf("%v and %v beat each other", a.Cards, b.Cards)
+						}
+					}
+				}
+			})
+
+			t.Run("Transitivity within a hand type", func(t *testing.T) {
+				byType := make(map[HandValue][]*PlayedHand) // Keyed by type and size only
+				for _, h := range pool {
+					key := HandValue{Type: h.HandType, Size: len(h.Cards)}
+					byType[key] = append(byType[key], h)
+				}
+				for _, hands := range byType {
+					for _, a := range hands {
+						for _, b := range hands {
+							if !re.BeatsLastHand(a, b) {
+								continue
+							}
+							for _, c := range hands {
+								if re.BeatsLastHand(b, c) && !re.BeatsLastHand(a, c) {
+									t.Fatalf("%v beats %v beats %v, but not transitively", a.Cards, b.Cards, c.Cards)
+								}
+							}
+						}
+					}
+				}
+			})
+
+			t.Run("Following moves beat the last play", func(t *testing.T) {
+				deck := NewDeck()
+				rng.Shuffle(len(deck), func(i, j int) { deck[i], deck[j] = deck[j], deck[i] })
+				for _, last := range pool[:20] {
+					for _, m := range re.GenerateMoves(deck[:13], last) {
+						if !re.BeatsLastHand(m, last) {
+							t.Fatalf("GenerateMoves returned %v, which does not beat %v", m.Cards, last.Cards)
+						}
+					}
+				}
+			})
+		})
+	}
+}
@@ -382,3 +382,208 @@ func TestBigTwoRuleEngine_isFlush(t *testing.T) {
 		})
 	}
 }
+
+func TestBigTwoRuleEngine_ValidateOpeningPlay(t *testing.T) {
+	threeD := C(Rank3, Diamonds)
+	fourC := C(Rank4, Clubs)
+
+	tests := []struct {
+		name        string
+		opts        RuleOptions
+		cards       Deck
+		openingCard *Card
+		wantCode    string // Empty means the play is allowed
+	}{
+		{"Opening single includes 3D", DefaultRuleOptions(), Deck{threeD}, &threeD, ""},
+		{"Opening pair includes 3D", DefaultRuleOptions(), Deck{threeD, C(Rank3, Spades)}, &threeD, ""},
+		{"Opening single without 3D", DefaultRuleOptions(), Deck{C(Rank5, Hearts)}, &threeD, ErrCodeOpeningCardRequired},
+		{"Opening play must include 4C when it is the lowest dealt", DefaultRuleOptions(), Deck{C(Rank4, Spades)}, &fourC, ErrCodeOpeningCardRequired},
+		{"Opening play includes 4C when it is the lowest dealt", DefaultRuleOptions(), Deck{fourC, C(Rank4, Spades)}, &fourC, ""},
+		{"No opening card required", DefaultRuleOptions(), Deck{C(Rank5, Hearts)}, nil, ""},
+		{"Rule disabled", RuleOptions{RequireLowestCardLead: false}, Deck{C(Rank5, Hearts)}, &threeD, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			re := NewBigTwoRuleEngineWithOptions(tc.opts)
+			tc.cards.Sort()
+			play, err := re.DeterminePlayedHand(tc.cards)
+			if err != nil {
+				t.Fatalf("DeterminePlayedHand() unexpected error: %v", err)
+			}
+
+			err = re.ValidateOpeningPlay(play, tc.openingCard)
+			if tc.wantCode == "" {
+				if err != nil {
+					t.Errorf("ValidateOpeningPlay() error = %v, want nil", err)
+				}
+				return
+			}
+			ruleErr, ok := err.(*RuleError)
+			if !ok {
+				t.Fatalf("ValidateOpeningPlay() error = %v, want *RuleError", err)
+			}
+			if ruleErr.Code != tc.wantCode {
+				t.Errorf("ValidateOpeningPlay() code = %s, want %s", ruleErr.Code, tc.wantCode)
+			}
+		})
+	}
+}
+
+func TestFindPlayerWithLowestCard(t *testing.T) {
+	players := []*Player{NewPlayer(1, "P1"), NewPlayer(2, "P2"), NewPlayer(3, "P3")}
+	players[0].Hand = Deck{C(Rank5, Diamonds), C(Two, Spades)}
+	players[1].Hand = Deck{C(Rank4, Hearts), C(Ace, Clubs)}
+	players[2].Hand = Deck{C(Rank4, Clubs), C(King, Diamonds)}
+
+	idx, card, found := FindPlayerWithLowestCard(players)
+	if !found {
+		t.Fatal("FindPlayerWithLowestCard() found = false, want true")
+	}
+	if idx != 2 || card != C(Rank4, Clubs) {
+		t.Errorf("FindPlayerWithLowestCard() = (%d, %s), want (2, 4C)", idx, card)
+	}
+
+	if _, _, found := FindPlayerWithLowestCard([]*Player{NewPlayer(1, "P1")}); found {
+		t.Error("FindPlayerWithLowestCard() with no cards dealt found = true, want false")
+	}
+}
+
+func TestBigTwoRuleEngine_GenerateMoves(t *testing.T) {
+	re := NewBigTwoRuleEngine()
+	hand := Deck{C(Rank3, Diamonds), C(Rank3, Spades), C(Rank4, Clubs), C(Rank5, Hearts), C(Rank6, Spades), C(Rank7, Diamonds), C(Two, Hearts)}
+
+	countByType := func(moves []*PlayedHand) map[HandType]int {
+		counts := make(map[HandType]int)
+		for _, m := range moves {
+			counts[m.HandType]++
+		}
+		return counts
+	}
+
+	// Leading: 7 singles, 1 pair, and straights 3-7 using either 3.
+	leading := countByType(re.GenerateMoves(hand, nil))
+	if leading[Single] != 7 || leading[Pair] != 1 || leading[Straight] != 2 {
+		t.Errorf("GenerateMoves(lead) counts = %v, want 7 singles, 1 pair, 2 straights", leading)
+	}
+
+	// Following a single 6D: only 6S, 7D and 2H beat it.
+	lastSingle := &PlayedHand{Cards: Deck{C(Rank6, Diamonds)}, HandType: Single, EffectiveRank: Rank6, EffectiveSuit: Diamonds}
+	following := re.GenerateMoves(hand, lastSingle)
+	if len(following) != 3 {
+		t.Errorf("GenerateMoves(follow 6D) returned %d moves, want 3: %v", len(following), following)
+	}
+	for _, m := range following {
+		if !re.BeatsLastHand(m, lastSingle) {
+			t.Errorf("GenerateMoves(follow 6D) returned %v which does not beat 6D", m.Cards)
+		}
+	}
+
+	if highest := re.HighestSingle(following); highest == nil || highest.Cards[0] != C(Two, Hearts) {
+		t.Errorf("HighestSingle() = %v, want 2H", highest)
+	}
+}
+
+func TestHighestLegalSingle_MatchesGenerateMoves(t *testing.T) {
+	tienLen, err := NewRuleOptions(RulesTienLen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jokerBeatsTwo := DefaultRuleOptions()
+	jokerBeatsTwo.JokerSingleBeatsTwo = true
+	engines := map[string]*BigTwoRuleEngine{
+		"standard":      NewBigTwoRuleEngine(),
+		"tienLen":       NewBigTwoRuleEngineWithOptions(tienLen),
+		"jokerBeatsTwo": NewBigTwoRuleEngineWithOptions(jokerBeatsTwo),
+	}
+	hand := Deck{C(Rank4, Clubs), C(Rank4, Spades), C(Rank9, Hearts), C(Ace, Diamonds), C(Two, Clubs), NewJoker(0)}
+	for name, re := range engines {
+		lastPlays := []*PlayedHand{
+			nil,
+			mustHand(t, re, C(Rank5, Diamonds)),
+			mustHand(t, re, C(Two, Spades)),
+			mustHand(t, re, C(Queen, Diamonds), C(Queen, Spades)),
+		}
+		for _, last := range lastPlays {
+			want := re.HighestSingle(re.GenerateMoves(hand, last))
+			got := re.highestLegalSingle(hand, last)
+			if (got == nil) != (want == nil) || (got != nil && got.Cards[0] != want.Cards[0]) {
+				t.Errorf("%s, on %v: highestLegalSingle = %v, want %v", name, last, got, want)
+			}
+		}
+	}
+}
+
+func TestBigTwoRuleEngine_CheckSingleCardLeft(t *testing.T) {
+	opts := DefaultRuleOptions()
+	opts.SingleCardLeftRule = SingleCardLeftReject
+	re := NewBigTwoRuleEngineWithOptions(opts)
+
+	hand := Deck{C(Rank4, Clubs), C(Rank4, Spades), C(Rank9, Hearts), C(Ace, Diamonds)}
+	single := func(c Card) *PlayedHand {
+		return &PlayedHand{Cards: Deck{c}, HandType: Single, EffectiveRank: c.Rank, EffectiveSuit: c.Suit}
+	}
+	pair4 := &PlayedHand{Cards: Deck{C(Rank4, Clubs), C(Rank4, Spades)}, HandType: Pair, EffectiveRank: Rank4, EffectiveSuit: Spades}
+
+	tests := []struct {
+		name      string
+		re        *BigTwoRuleEngine
+		play      *PlayedHand
+		last      *PlayedHand
+		nextCards int
+		wantErr   bool
+	}{
+		{"Leading highest single", re, single(C(Ace, Diamonds)), nil, 1, false},
+		{"Leading lower single", re, single(C(Rank9, Hearts)), nil, 1, true},
+		{"Leading a pair is allowed", re, pair4, nil, 1, false},
+		{"Following with lower single", re, single(C(Rank9, Hearts)), single(C(Rank5, Diamonds)), 1, true},
+		{"Following with highest single", re, single(C(Ace, Diamonds)), single(C(Rank5, Diamonds)), 1, false},
+		{"Next player has two cards", re, single(C(Rank4, Clubs)), nil, 2, false},
+		{"Rule off", NewBigTwoRuleEngine(), single(C(Rank4, Clubs)), nil, 1, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.re.CheckSingleCardLeft(tc.play, hand, tc.last, tc.nextCards)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("CheckSingleCardLeft() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err != nil {
+				if ruleErr, ok := err.(*RuleError); !ok || ruleErr.Code != ErrCodeMustPlayHighestSingle {
+					t.Errorf("CheckSingleCardLeft() error = %v, want code %s", err, ErrCodeMustPlayHighestSingle)
+				}
+			}
+		})
+	}
+}
+
+func TestPlayCards_SingleCardLeftPenalty(t *testing.T) {
+	game := queueTestGame()
+	game.RuleEngine.Options.SingleCardLeftRule = SingleCardLeftPenalize
+	game.Players[1].Hand = Deck{C(Rank5, Diamonds)} // player2 is down to one card
+	player := game.Players[0]
+
+	// Opening with the 3 of Diamonds while holding the 2 of Spades breaks the rule.
+	play, err := validatePlay(game, player, Deck{C(Rank3, Diamonds)})
+	if err != nil || play.singleCardLeft == nil {
+		t.Fatalf("validatePlay() = %+v, %v; want a penalized play", play, err)
+	}
+
+	// A play that cannot be taken from the hand is not penalized.
+	hand := player.Hand
+	player.Hand = Deck{C(Rank4, Clubs), C(Two, Spades)}
+	if err := playCards(game, player, play); err == nil {
+		t.Fatal("playCards() without the cards in hand succeeded")
+	}
+	if len(game.Penalties) != 0 {
+		t.Errorf("penalties after a failed play = %v, want none", game.Penalties)
+	}
+
+	player.Hand = hand
+	if err := playCards(game, player, play); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := game.Penalties[player.ID], game.RuleEngine.Options.SingleCardLeftPenalty; got != want {
+		t.Errorf("penalty = %d, want %d", got, want)
+	}
+}
@@ -1,27 +1,218 @@
 package main
 
-// CalculateScores calculates the scores for each player at the end of the game.
-// For now, it's a placeholder: winner gets 0, others get the number of cards remaining.
+import (
+	"fmt"
+	"sort"
+)
+
+// ScoringStrategy computes each player's penalty points for a finished round.
+// Lower is better: the match winner is the player with the lowest accumulated score.
+// Strategies may return negative scores for the round winner.
+type ScoringStrategy interface {
+	// Name returns the preset name used to select the strategy for a table.
+	Name() string
+	// RoundScores returns the score for every player. game.WinnerID is set.
+	RoundScores(game *GameState) map[string]int
+}
+
+// Scoring preset names.
+const (
+	ScoringStandard    = "standard"
+	ScoringTwosDouble  = "twosDouble"
+	ScoringWinnerBonus = "winnerBonus"
+	ScoringChop        = "chop"
+	ScoringMoney       = "money"
+)
+
+var scoringPresets = map[string]func() ScoringStrategy{
+	ScoringStandard:    func() ScoringStrategy { return StandardScoring{} },
+	ScoringTwosDouble:  func() ScoringStrategy { return TwosDoublingScoring{} },
+	ScoringWinnerBonus: func() ScoringStrategy { return WinnerBonusScoring{Bonus: 5} },
+	ScoringChop:        func() ScoringStrategy { return ChopScoring{Multiplier: 2} },
+	ScoringMoney:       func() ScoringStrategy { return MoneyScoring{} },
+}
+
+// NewScoringStrategy returns the scoring preset with the given name.
+func NewScoringStrategy(name string) (ScoringStrategy, error) {
+	newStrategy, ok := scoringPresets[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown scoring scheme %q (available: %v)", name, ScoringStrategyNames())
+	}
+	return newStrategy(), nil
+}
+
+// ScoringStrategyNames returns the names of all scoring presets, sorted.
+func ScoringStrategyNames() []string {
+	names := make([]string, 0, len(scoringPresets))
+	for name := range scoringPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CalculateScores calculates the scores for each player at the end of the round
+// using the table's scoring strategy (standard scoring if none is set).
+// Scores stay per player in team mode; teams are compared on their combined score.
+// House-rule penalties incurred during the round are added on top.
 func CalculateScores(game *GameState) map[string]int {
-	scores := make(map[string]int)
 	if game == nil || game.Players == nil || game.WinnerID == "" {
-		return scores // Return empty scores if game state is invalid for scoring
+		return make(map[string]int) // Return empty scores if game state is invalid for scoring
+	}
+
+	var strategy ScoringStrategy = StandardScoring{}
+	if game.Scoring != nil {
+		strategy = game.Scoring
+	}
+	scores := strategy.RoundScores(game)
+	if game.InstantWin != nil && game.RuleEngine != nil && game.RuleEngine.Options.InstantWinPoints > 0 {
+		scores = instantWinScores(game, game.RuleEngine.Options.InstantWinPoints)
+	}
+
+	for _, player := range game.Players {
+		// House-rule penalties apply to everyone, including the round winner.
+		scores[player.ID] += game.Penalties[player.ID]
 	}
+	return scores
+}
+
+// scoringSchemeName returns the name of the table's scoring strategy.
+func scoringSchemeName(game *GameState) string {
+	if game.Scoring == nil {
+		return ScoringStandard
+	}
+	return game.Scoring.Name()
+}
+
+// wonRound reports whether the player is on the winning side of the round: the winner, or
+// in team mode the winner's partner, who is not penalized for the cards left in hand.
+func wonRound(game *GameState, playerID string) bool {
+	return playerID == game.WinnerID || game.areTeammates(playerID, game.WinnerID)
+}
+
+// standardHandSize is the hand each of four players is dealt from a 52-card deck.
+const standardHandSize = 13
+
+// dealtHandSize returns how many cards the player was dealt this round, or standardHandSize
+// if the deal was not recorded.
+func (g *GameState) dealtHandSize(playerID string) int {
+	if n, ok := g.dealtHandSizes[playerID]; ok {
+		return n
+	}
+	return standardHandSize
+}
+
+// cardPenalty is the standard penalty for the cards left in a losing hand of dealt cards:
+// one point per card, doubled when at most 3 cards were played and tripled when none were.
+// For a 13-card deal that is double for 10-12 cards left and triple for all 13.
+func cardPenalty(cardsLeft, dealt int) int {
+	switch {
+	case cardsLeft >= dealt:
+		return cardsLeft * 3 // Never played from
+	case cardsLeft >= dealt-3:
+		return cardsLeft * 2
+	}
+	return cardsLeft
+}
+
+// StandardScoring: the winning side scores 0, everyone else scores cardPenalty for their remaining cards.
+type StandardScoring struct{}
 
+// Name implements ScoringStrategy.
+func (StandardScoring) Name() string { return ScoringStandard }
+
+// RoundScores implements ScoringStrategy.
+func (StandardScoring) RoundScores(game *GameState) map[string]int {
+	scores := make(map[string]int)
 	for _, player := range game.Players {
-		if player.ID == game.WinnerID {
+		if wonRound(game, player.ID) {
 			scores[player.ID] = 0
 		} else {
-			// Basic scoring: number of cards left.
-			// More complex scoring (e.g., double for 10+ cards) can be added later.
-			score := len(player.Hand)
-			if score >= 10 && score < 13 {
-				score *= 2 // Double if 10, 11, 12 cards
-			} else if score == 13 {
-				score *= 3 // Triple if all 13 cards left
+			scores[player.ID] = cardPenalty(len(player.Hand), game.dealtHandSize(player.ID))
+		}
+	}
+	return scores
+}
+
+// TwosDoublingScoring is standard scoring, doubled again for each 2 left unplayed in a losing hand.
+type TwosDoublingScoring struct{}
+
+// Name implements ScoringStrategy.
+func (TwosDoublingScoring) Name() string { return ScoringTwosDouble }
+
+// RoundScores implements ScoringStrategy.
+func (TwosDoublingScoring) RoundScores(game *GameState) map[string]int {
+	scores := StandardScoring{}.RoundScores(game)
+	for _, player := range game.Players {
+		if wonRound(game, player.ID) {
+			continue
+		}
+		for _, card := range player.Hand {
+			if card.Rank == Two {
+				scores[player.ID] *= 2
 			}
-			scores[player.ID] = score
 		}
 	}
 	return scores
 }
+
+// WinnerBonusScoring is standard scoring where each loser also pays Bonus points to the winner,
+// so the winner's score goes negative by Bonus per loser.
+type WinnerBonusScoring struct {
+	Bonus int
+}
+
+// Name implements ScoringStrategy.
+func (WinnerBonusScoring) Name() string { return ScoringWinnerBonus }
+
+// RoundScores implements ScoringStrategy.
+func (s WinnerBonusScoring) RoundScores(game *GameState) map[string]int {
+	scores := StandardScoring{}.RoundScores(game)
+	for _, player := range game.Players {
+		if wonRound(game, player.ID) {
+			continue
+		}
+		scores[player.ID] += s.Bonus
+		scores[game.WinnerID] -= s.Bonus
+	}
+	return scores
+}
+
+// ChopScoring is standard scoring with losers' penalties multiplied when the round
+// was won with a bomb (four of a kind or straight flush).
+type ChopScoring struct {
+	Multiplier int
+}
+
+// Name implements ScoringStrategy.
+func (ChopScoring) Name() string { return ScoringChop }
+
+// RoundScores implements ScoringStrategy.
+func (s ChopScoring) RoundScores(game *GameState) map[string]int {
+	scores := StandardScoring{}.RoundScores(game)
+	if game.LastPlayedHand == nil || !game.LastPlayedHand.IsBomb() {
+		return scores
+	}
+	for id := range scores {
+		scores[id] *= s.Multiplier
+	}
+	return scores
+}
+
+// MoneyScoring is zero-sum: each loser pays their standard penalty to the winner,
+// whose score is the negative of the total collected. A winning partner neither pays nor collects.
+type MoneyScoring struct{}
+
+// Name implements ScoringStrategy.
+func (MoneyScoring) Name() string { return ScoringMoney }
+
+// RoundScores implements ScoringStrategy.
+func (MoneyScoring) RoundScores(game *GameState) map[string]int {
+	scores := StandardScoring{}.RoundScores(game)
+	collected := 0
+	for _, score := range scores {
+		collected += score // The winning side scores 0
+	}
+	scores[game.WinnerID] = -collected
+	return scores
+}
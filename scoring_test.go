@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+)
+
+// scoringTestGame builds a finished 4-player round: player1 won, player2 holds 3 cards
+// including one 2, player3 holds 10 cards, player4 holds all 13.
+func scoringTestGame(lastPlay *PlayedHand) *GameState {
+	players := []*Player{NewPlayer(1, "P1"), NewPlayer(2, "P2"), NewPlayer(3, "P3"), NewPlayer(4, "P4")}
+	players[1].Hand = Deck{C(Rank5, Hearts), C(Jack, Clubs), C(Two, Spades)}
+	players[2].Hand = NewDeck()[:10]
+	players[3].Hand = NewDeck()[13:26]
+	return &GameState{Players: players, WinnerID: "player1", LastPlayedHand: lastPlay}
+}
+
+func TestCalculateScores_Strategies(t *testing.T) {
+	single := &PlayedHand{Cards: Deck{C(Rank3, Diamonds)}, HandType: Single}
+	bomb := &PlayedHand{Cards: Deck{C(Rank7, Diamonds), C(Rank7, Clubs), C(Rank7, Hearts), C(Rank7, Spades), C(Rank3, Diamonds)}, HandType: FourOfAKindPlusOne}
+
+	tests := []struct {
+		name     string
+		strategy ScoringStrategy
+		lastPlay *PlayedHand
+		teams    bool // player1 partners player3, player2 partners player4
+		want     map[string]int
+	}{
+		{"Nil strategy defaults to standard", nil, single, false, map[string]int{"player1": 0, "player2": 3, "player3": 20, "player4": 39}},
+		{"Standard", StandardScoring{}, single, false, map[string]int{"player1": 0, "player2": 3, "player3": 20, "player4": 39}},
+		// NewDeck()[:10] holds no 2s; NewDeck()[13:26] holds the 2 of Clubs.
+		{"Twos doubling", TwosDoublingScoring{}, single, false, map[string]int{"player1": 0, "player2": 6, "player3": 20, "player4": 78}},
+		{"Winner bonus", WinnerBonusScoring{Bonus: 5}, single, false, map[string]int{"player1": -15, "player2": 8, "player3": 25, "player4": 44}},
+		{"Chop without bomb", ChopScoring{Multiplier: 2}, single, false, map[string]int{"player1": 0, "player2": 3, "player3": 20, "player4": 39}},
+		{"Chop with bomb", ChopScoring{Multiplier: 2}, bomb, false, map[string]int{"player1": 0, "player2": 6, "player3": 40, "player4": 78}},
+		{"Money is zero-sum", MoneyScoring{}, single, false, map[string]int{"player1": -62, "player2": 3, "player3": 20, "player4": 39}},
+		// player3 is player1's partner, so it neither pays nor collects.
+		{"Winner bonus in team mode", WinnerBonusScoring{Bonus: 5}, single, true, map[string]int{"player1": -10, "player2": 8, "player3": 0, "player4": 44}},
+		{"Money in team mode is zero-sum", MoneyScoring{}, single, true, map[string]int{"player1": -42, "player2": 3, "player3": 0, "player4": 39}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			game := scoringTestGame(tc.lastPlay)
+			game.Scoring = tc.strategy
+			if tc.teams {
+				if err := SetupPartnerships(game, false); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got := CalculateScores(game)
+			for id, want := range tc.want {
+				if got[id] != want {
+					t.Errorf("CalculateScores()[%s] = %d, want %d (all scores %v)", id, got[id], want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCardPenalty(t *testing.T) {
+	tests := []struct {
+		cardsLeft, dealt, want int
+	}{
+		{9, 13, 9},
+		{10, 13, 20},
+		{12, 13, 24},
+		{13, 13, 39},
+		{13, 14, 26}, // Dealt a joker on top
+		{14, 14, 42},
+		{13, 17, 13}, // Three players share the deck
+		{14, 17, 28},
+		{17, 17, 51},
+	}
+	for _, tt := range tests {
+		if got := cardPenalty(tt.cardsLeft, tt.dealt); got != tt.want {
+			t.Errorf("cardPenalty(%d, %d) = %d, want %d", tt.cardsLeft, tt.dealt, got, tt.want)
+		}
+	}
+}
+
+func TestCalculateScores_AddsPenalties(t *testing.T) {
+	game := scoringTestGame(nil)
+	game.Penalties = map[string]int{"player1": 10, "player2": 5}
+	got := CalculateScores(game)
+	if got["player1"] != 10 || got["player2"] != 8 {
+		t.Errorf("CalculateScores() with penalties = %v, want player1=10 player2=8", got)
+	}
+}
+
+func TestNewScoringStrategy(t *testing.T) {
+	for _, name := range ScoringStrategyNames() {
+		strategy, err := NewScoringStrategy(name)
+		if err != nil {
+			t.Fatalf("NewScoringStrategy(%q) error = %v", name, err)
+		}
+		if strategy.Name() != name {
+			t.Errorf("NewScoringStrategy(%q).Name() = %q", name, strategy.Name())
+		}
+	}
+	if _, err := NewScoringStrategy("nope"); err == nil {
+		t.Error("NewScoringStrategy(\"nope\") error = nil, want error")
+	}
+}
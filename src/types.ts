@@ -9,6 +9,9 @@ export interface PlayerInfo {
     readonly name: string;
     readonly cardCount: number;
     readonly hasPassed: boolean;
+    readonly isEliminated?: boolean;
+    readonly accountId?: string;
+    readonly rating?: number;
 }
 
 export interface PlayedHand {
@@ -19,20 +22,64 @@ export interface PlayedHand {
     readonly rank: number;
     readonly EffectiveRank: number;
     readonly EffectiveSuit: number;
+    readonly resolvedCards?: readonly Card[];
 }
 
 export type Scores = Record<string, number>;
 
+export interface Team {
+    readonly id: string;
+    readonly playerIds: readonly string[];
+}
+
 export interface RoundResult {
     readonly roundNumber: number;
     readonly scores: Scores;
     readonly winnerId?: string;
 }
 
+export interface InstantWin {
+    readonly playerId: string;
+    readonly kind: "dragon" | "fourTwos" | "sixPairs" | "oneColour";
+    readonly hand: readonly Card[];
+}
+
+export interface CardExchange {
+    readonly round: number;
+    readonly loserId: string;
+    readonly winnerId: string;
+    readonly count: number;
+    readonly phase: "give" | "return" | "complete";
+}
+
+export interface QueuedAction {
+    readonly kind: "autoPass" | "playIfPossible";
+    readonly cards?: readonly Card[];
+}
+
+export interface UndoRequest {
+    readonly playerId: string;
+    readonly accepted: readonly string[];
+    readonly expiresAt: string;
+}
+
+export interface MatchVote {
+    readonly kind: "restart" | "abandon";
+    readonly calledBy: string;
+    readonly ballots: Readonly<Record<string, boolean>>;
+    readonly yes: number;
+    readonly no: number;
+    readonly needed: number;
+    readonly expiresAt: string;
+}
+
 // Server Message Interfaces
 export interface GameStateMessage {
     readonly type: "gameState";
+    readonly role?: "seat" | "spectator" | "admin";
     readonly hand?: readonly Card[] | null;
+    readonly hands?: Record<string, readonly Card[]>;
+    readonly queuedAction?: QueuedAction;
     readonly lastPlayedHand: PlayedHand | null;
     readonly yourPlayerId: string | null;
     readonly currentPlayerId: string | null;
@@ -48,12 +95,35 @@ export interface GameStateMessage {
     readonly roundScoresHistory?: readonly RoundResult[];
     readonly winnerId: string | null; 
     readonly gameMessage?: string;
+    readonly openingCard?: Card | null;
+    readonly ruleset?: string;
+    readonly scoringScheme?: string;
+    readonly roundWins?: Scores;
+    readonly matchEndMode?: string;
+    readonly maxRounds?: number;
+    readonly roundWinsToWin?: number;
+    readonly matchEndsAt?: string;
+    readonly tableId?: string;
+    readonly tournamentId?: string;
+    readonly teams?: readonly Team[];
+    readonly teamScores?: Scores;
+    readonly finishOrder?: readonly string[];
+    readonly instantWin?: InstantWin;
+    readonly exchange?: CardExchange;
+    readonly roundExchanges?: readonly (CardExchange | null)[]; // By round, like roundScoresHistory
+    readonly undoRequest?: UndoRequest;
+    readonly paused: boolean;
+    readonly pausedBy?: string;
+    readonly vote?: MatchVote;
+    readonly matchAbandoned: boolean;
+    readonly overallWinningTeamId?: string;
 }
 
 export interface ChatMessage {
     readonly type: "chat";
     readonly sender: string;
     readonly content: string;
+    readonly channel?: "team";
 }
 
 export interface SystemMessage {
@@ -65,6 +135,8 @@ export interface ErrorMessage {
     readonly type: "error";
     readonly content: string;
     readonly context?: string;
+    readonly code?: string;
+    readonly retryAfterMs?: number; // Set on "rateLimited" errors
 }
 
 export interface ActionSuccessMessage {
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatsFilter restricts which recorded rounds and matches are aggregated.
+type StatsFilter struct {
+	Since   time.Time // Zero means all time
+	Ruleset string    // Empty means every ruleset
+}
+
+func (f StatsFilter) matches(finishedAt time.Time, ruleset string) bool {
+	if !f.Since.IsZero() && finishedAt.Before(f.Since) {
+		return false
+	}
+	return f.Ruleset == "" || f.Ruleset == ruleset
+}
+
+// PlayerStats is a player's career statistics aggregated from completed rounds and matches.
+type PlayerStats struct {
+	AccountID           string  `json:"accountId"`
+	Username            string  `json:"username"`
+	Rating              float64 `json:"rating"`
+	RoundsPlayed        int     `json:"roundsPlayed"`
+	RoundsWon           int     `json:"roundsWon"`
+	RoundWinRate        float64 `json:"roundWinRate"`
+	MatchesPlayed       int     `json:"matchesPlayed"`
+	MatchesWon          int     `json:"matchesWon"`
+	AveragePenalty      float64 `json:"averagePenalty"`
+	TriplePenaltyLosses int     `json:"triplePenaltyLosses"` // Rounds lost without playing a card
+	MostPlayedHandType  string  `json:"mostPlayedHandType,omitempty"`
+	BombsPlayed         int     `json:"bombsPlayed"`
+}
+
+// ComputePlayerStats aggregates an account's recorded history.
+func ComputePlayerStats(account Account, filter StatsFilter) PlayerStats {
+	stats := PlayerStats{AccountID: account.ID, Username: account.Username, Rating: account.Rating}
+
+	totalPenalty := 0
+	handTypeCounts := make(map[string]int)
+	for _, round := range account.Rounds {
+		if !filter.matches(round.FinishedAt, round.Ruleset) {
+			continue
+		}
+		stats.RoundsPlayed++
+		totalPenalty += round.Penalty
+		if round.Won {
+			stats.RoundsWon++
+		} else if round.NeverPlayed {
+			stats.TriplePenaltyLosses++
+		}
+		for handType, count := range round.Plays {
+			handTypeCounts[handType] += count
+		}
+	}
+	for _, match := range account.Matches {
+		if !filter.matches(match.FinishedAt, match.Ruleset) {
+			continue
+		}
+		stats.MatchesPlayed++
+		if match.Won {
+			stats.MatchesWon++
+		}
+	}
+
+	if stats.RoundsPlayed > 0 {
+		stats.RoundWinRate = float64(stats.RoundsWon) / float64(stats.RoundsPlayed)
+		stats.AveragePenalty = float64(totalPenalty) / float64(stats.RoundsPlayed)
+	}
+	stats.BombsPlayed = handTypeCounts[FourOfAKindPlusOne.String()] + handTypeCounts[StraightFlush.String()]
+	mostPlayed := 0
+	for handType, count := range handTypeCounts {
+		// Ties go to the alphabetically first name so results are stable.
+		if count > mostPlayed || (count == mostPlayed && handType < stats.MostPlayedHandType) {
+			stats.MostPlayedHandType = handType
+			mostPlayed = count
+		}
+	}
+	return stats
+}
+
+// leaderboardSorts orders leaderboard entries for each supported "sort" parameter.
+var leaderboardSorts = map[string]func(a, b PlayerStats) bool{
+	"rating":         func(a, b PlayerStats) bool { return a.Rating > b.Rating },
+	"roundWinRate":   func(a, b PlayerStats) bool { return a.RoundWinRate > b.RoundWinRate },
+	"averagePenalty": func(a, b PlayerStats) bool { return a.AveragePenalty < b.AveragePenalty },
+	"matchesWon":     func(a, b PlayerStats) bool { return a.MatchesWon > b.MatchesWon },
+	"roundsWon":      func(a, b PlayerStats) bool { return a.RoundsWon > b.RoundsWon },
+}
+
+// Leaderboard ranks every account with at least one round in the filter window.
+func Leaderboard(accounts []Account, filter StatsFilter, sortBy string, limit int) ([]PlayerStats, error) {
+	less, ok := leaderboardSorts[sortBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort %q", sortBy)
+	}
+	entries := make([]PlayerStats, 0, len(accounts))
+	for _, account := range accounts {
+		if stats := ComputePlayerStats(account, filter); stats.RoundsPlayed > 0 {
+			entries = append(entries, stats)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if less(entries[i], entries[j]) != less(entries[j], entries[i]) {
+			return less(entries[i], entries[j])
+		}
+		return entries[i].Username < entries[j].Username
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// parseStatsFilter reads the "window" (e.g. 24h, 7d, all) and "ruleset" query parameters.
+func parseStatsFilter(r *http.Request, now time.Time) (StatsFilter, error) {
+	filter := StatsFilter{Ruleset: r.URL.Query().Get("ruleset")}
+	window := r.URL.Query().Get("window")
+	if window == "" || window == "all" {
+		return filter, nil
+	}
+	var d time.Duration
+	if days, ok := strings.CutSuffix(window, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return filter, fmt.Errorf("invalid window %q", window)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		d, err = time.ParseDuration(window)
+		if err != nil || d <= 0 {
+			return filter, fmt.Errorf("invalid window %q", window)
+		}
+	}
+	filter.Since = now.Add(-d)
+	return filter, nil
+}
+
+// handlePlayerStats serves GET /api/players/{id}/stats.
+func handlePlayerStats(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStatsFilter(r, time.Now())
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	account, err := accountStore.Account(r.PathValue("id"))
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, ComputePlayerStats(account, filter))
+}
+
+// handleLeaderboard serves GET /api/leaderboard?window=7d&ruleset=standard&sort=rating&limit=50.
+func handleLeaderboard(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStatsFilter(r, time.Now())
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	sortBy := r.URL.Query().Get("sort")
+	if sortBy == "" {
+		sortBy = "rating"
+	}
+	limit := 50
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if limit, err = strconv.Atoi(l); err != nil || limit <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+	entries, err := Leaderboard(accountStore.Accounts(), filter, sortBy, limit)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"sort": sortBy, "entries": entries})
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestComputePlayerStats(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	account := Account{
+		ID: "acct1", Username: "alice", Rating: 1550,
+		Rounds: []RoundRecord{
+			{FinishedAt: now.Add(-time.Hour), Ruleset: "standard", Won: true, Penalty: 0, Plays: map[string]int{"Single": 3, "Four of a Kind": 1}},
+			{FinishedAt: now.Add(-2 * time.Hour), Ruleset: "standard", Penalty: 42, CardsLeft: 14, NeverPlayed: true, Plays: map[string]int{}}, // Dealt a joker
+			{FinishedAt: now.Add(-3 * time.Hour), Ruleset: "standard", Penalty: 6, CardsLeft: 6, Plays: map[string]int{"Pair": 2, "Straight Flush": 1}},
+			{FinishedAt: now.Add(-10 * 24 * time.Hour), Ruleset: "standard", Won: true, Plays: map[string]int{"Pair": 10}},
+			{FinishedAt: now.Add(-time.Hour), Ruleset: "tienLen", Won: true},
+		},
+		Matches: []MatchRecord{
+			{FinishedAt: now.Add(-time.Hour), Ruleset: "standard", Won: true},
+			{FinishedAt: now.Add(-30 * 24 * time.Hour), Ruleset: "standard"},
+		},
+	}
+
+	stats := ComputePlayerStats(account, StatsFilter{Since: now.Add(-7 * 24 * time.Hour), Ruleset: "standard"})
+	if stats.RoundsPlayed != 3 || stats.RoundsWon != 1 || stats.MatchesPlayed != 1 || stats.MatchesWon != 1 {
+		t.Errorf("ComputePlayerStats() counts = %+v, want 3 rounds, 1 win, 1 match won", stats)
+	}
+	if stats.AveragePenalty != 16 {
+		t.Errorf("AveragePenalty = %f, want 16", stats.AveragePenalty)
+	}
+	if stats.TriplePenaltyLosses != 1 || stats.BombsPlayed != 2 || stats.MostPlayedHandType != "Single" {
+		t.Errorf("ComputePlayerStats() = %+v, want 1 triple loss, 2 bombs, most played Single", stats)
+	}
+
+	allTime := ComputePlayerStats(account, StatsFilter{})
+	if allTime.RoundsPlayed != 5 || allTime.MostPlayedHandType != "Pair" {
+		t.Errorf("ComputePlayerStats(all time) = %+v, want 5 rounds, most played Pair", allTime)
+	}
+}
+
+func TestLeaderboard(t *testing.T) {
+	now := time.Now()
+	accounts := []Account{
+		{ID: "a", Username: "alice", Rating: 1600, Rounds: []RoundRecord{{FinishedAt: now, Penalty: 10}}},
+		{ID: "b", Username: "bob", Rating: 1700, Rounds: []RoundRecord{{FinishedAt: now, Won: true}}},
+		{ID: "c", Username: "carol", Rating: 1800}, // No rounds: not listed
+	}
+	entries, err := Leaderboard(accounts, StatsFilter{}, "rating", 10)
+	if err != nil {
+		t.Fatalf("Leaderboard() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Username != "bob" {
+		t.Errorf("Leaderboard(rating) = %+v, want [bob alice]", entries)
+	}
+	entries, _ = Leaderboard(accounts, StatsFilter{}, "averagePenalty", 1)
+	if len(entries) != 1 || entries[0].Username != "bob" {
+		t.Errorf("Leaderboard(averagePenalty, limit 1) = %+v, want [bob]", entries)
+	}
+	if _, err := Leaderboard(accounts, StatsFilter{}, "nope", 10); err == nil {
+		t.Error("Leaderboard() unknown sort error = nil, want error")
+	}
+}
+
+func TestParseStatsFilter(t *testing.T) {
+	now := time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		query     string
+		wantSince time.Time
+		wantErr   bool
+	}{
+		{"", time.Time{}, false},
+		{"window=all", time.Time{}, false},
+		{"window=7d&ruleset=standard", now.Add(-7 * 24 * time.Hour), false},
+		{"window=12h", now.Add(-12 * time.Hour), false},
+		{"window=xd", time.Time{}, true},
+		{"window=-1h", time.Time{}, true},
+	}
+	for _, tc := range tests {
+		filter, err := parseStatsFilter(httptest.NewRequest("GET", "/api/leaderboard?"+tc.query, nil), now)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseStatsFilter(%q) error = %v, wantErr %v", tc.query, err, tc.wantErr)
+			continue
+		}
+		if !filter.Since.Equal(tc.wantSince) {
+			t.Errorf("parseStatsFilter(%q).Since = %v, want %v", tc.query, filter.Since, tc.wantSince)
+		}
+	}
+}
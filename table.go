@@ -0,0 +1,188 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultTableID is the table created at startup and joined when no table is requested.
+const defaultTableID = "main"
+
+// tables holds every table hosted by the server, keyed by GameState.ID.
+// gameInstance is the default table. Protected by gameInstanceMutex, which guards
+// the state of all tables.
+var tables = make(map[string]*GameState)
+
+// registerTable adds a table to the registry. Assumes gameInstanceMutex is held.
+func registerTable(game *GameState) error {
+	if _, exists := tables[game.ID]; exists {
+		return fmt.Errorf("table %q already exists", game.ID)
+	}
+	tables[game.ID] = game
+	return nil
+}
+
+// lookupTable returns the table with the given ID, or the default table if id is empty.
+// Returns nil if no such table exists. Assumes gameInstanceMutex is held.
+func lookupTable(id string) *GameState {
+	if id == "" {
+		id = defaultTableID
+	}
+	return tables[id]
+}
+
+// tableIDs returns the IDs of all tables, sorted. Assumes gameInstanceMutex is held.
+func tableIDs() []string {
+	ids := make([]string, 0, len(tables))
+	for id := range tables {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// TableConfig names the rules a table is played under. The default table takes it from the
+// server configuration and tournament tables from the tournament's creation request.
+// The house rules are applied on top of the ruleset preset.
+type TableConfig struct {
+	Ruleset        string     `json:"ruleset"`        // Rule preset; see RulePresetNames
+	Scoring        string     `json:"scoring"`        // Scoring preset; see ScoringStrategyNames
+	EndMode        string     `json:"endMode"`        // What ends a match; see MatchEndMode
+	MaxRounds      int        `json:"maxRounds"`      // For fixedRounds
+	RoundWinsToWin int        `json:"roundWinsToWin"` // For roundWins
+	TimeLimit      Duration   `json:"timeLimit"`      // For timeLimit
+	BigLossPoints  int        `json:"bigLossPoints"`  // Round penalty counted as a big loss by fewestBigLosses
+	TieBreakers    stringList `json:"tieBreakers"`    // Applied in order to equal match scores
+
+	SingleCardLeft        string     `json:"singleCardLeft"`        // off, reject or penalty; see SingleCardLeftMode
+	SingleCardLeftPenalty int        `json:"singleCardLeftPenalty"` // Points per violation in penalty mode
+	CardExchangeCount     int        `json:"cardExchangeCount"`     // Cards the last round's biggest loser gives its winner; 0 is off
+	InstantWins           stringList `json:"instantWins"`           // Deals that win the round at once; see InstantWinKind
+	InstantWinPoints      int        `json:"instantWinPoints"`      // Charged to the others on an instant win; 0 scores their cards
+	Jokers                int        `json:"jokers"`                // Jokers added to the deck, 0 to 2
+	JokerSingleBeatsTwo   bool       `json:"jokerSingleBeatsTwo"`   // A lone joker beats every 2
+
+	TeamMode                 bool `json:"teamMode"`                 // 2v2 partnerships; needs 4 players
+	TeamRoundEndsWhenTeamOut bool `json:"teamRoundEndsWhenTeamOut"` // In team mode, a round ends once both partners are out, not the first player
+}
+
+// DefaultTableConfig returns the standard rules and scoring, played to the target score.
+func DefaultTableConfig() TableConfig {
+	opts := DefaultRuleOptions()
+	c := TableConfig{
+		Ruleset:                  RulesStandard,
+		Scoring:                  ScoringStandard,
+		SingleCardLeft:           opts.SingleCardLeftRule.String(),
+		SingleCardLeftPenalty:    opts.SingleCardLeftPenalty,
+		TeamRoundEndsWhenTeamOut: true,
+	}
+	rules := DefaultMatchRules()
+	c.EndMode = rules.EndMode.String()
+	c.BigLossPoints = rules.BigLossPoints
+	for _, tb := range rules.TieBreakers {
+		c.TieBreakers = append(c.TieBreakers, tb.String())
+	}
+	return c
+}
+
+// bindFlags registers a command-line flag for each setting, bound to c's fields.
+func (c *TableConfig) bindFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.Ruleset, "ruleset", c.Ruleset, fmt.Sprintf("rule preset of the default table (%s)", strings.Join(RulePresetNames(), ", ")))
+	fs.StringVar(&c.Scoring, "scoring", c.Scoring, fmt.Sprintf("scoring preset of the default table (%s)", strings.Join(ScoringStrategyNames(), ", ")))
+	fs.StringVar(&c.EndMode, "end-mode", c.EndMode, fmt.Sprintf("what ends a match (%s)", strings.Join(matchEndModeNames, ", ")))
+	fs.IntVar(&c.MaxRounds, "max-rounds", c.MaxRounds, "rounds in a fixedRounds match")
+	fs.IntVar(&c.RoundWinsToWin, "round-wins", c.RoundWinsToWin, "round wins that take a roundWins match")
+	fs.Var(&c.TimeLimit, "time-limit", "length of a timeLimit match, as a `duration`; the round in progress is finished")
+	fs.IntVar(&c.BigLossPoints, "big-loss-points", c.BigLossPoints, "round penalty counted as a big loss by the fewestBigLosses tie-breaker; scale it with the scoring scheme")
+	fs.Var(&c.TieBreakers, "tie-breakers", fmt.Sprintf("comma-separated `tie-breakers` for equal match scores, in order (%s)", strings.Join(tieBreakerNames, ", ")))
+	fs.StringVar(&c.SingleCardLeft, "single-card-left", c.SingleCardLeft, fmt.Sprintf("when the next player holds one card, a single must be the highest: %s", strings.Join(singleCardLeftModeNames, ", ")))
+	fs.IntVar(&c.SingleCardLeftPenalty, "single-card-left-penalty", c.SingleCardLeftPenalty, "points charged per single-card-left violation in penalty mode")
+	fs.Var(&c.InstantWins, "instant-wins", fmt.Sprintf("comma-separated `deals` that win the round at once (%v); empty is none", instantWinPriority))
+	fs.IntVar(&c.InstantWinPoints, "instant-win-points", c.InstantWinPoints, "points charged to every other player on an instant win; 0 scores the cards they hold")
+	fs.IntVar(&c.Jokers, "jokers", c.Jokers, fmt.Sprintf("jokers added to the deck, 0 to %d; each is dealt to a different player on top of their share", maxJokers))
+	fs.BoolVar(&c.JokerSingleBeatsTwo, "joker-beats-two", c.JokerSingleBeatsTwo, "a joker played alone beats every 2, instead of counting as the highest 2")
+	fs.BoolVar(&c.TeamMode, "team-mode", c.TeamMode, "2v2 partnerships, partners sitting opposite; needs 4 players")
+	fs.BoolVar(&c.TeamRoundEndsWhenTeamOut, "team-round-ends-when-team-out", c.TeamRoundEndsWhenTeamOut, "in team mode, play on after the first player goes out until both partners of a team are out")
+	fs.IntVar(&c.CardExchangeCount, "card-exchange", c.CardExchangeCount, fmt.Sprintf("cards the previous round's biggest loser gives the winner after the deal, 0 to %d; 0 is no exchange", maxCardExchangeCount))
+}
+
+// TableSettings are the rules a TableConfig names, ready to set up a table with.
+type TableSettings struct {
+	Rules      RuleOptions
+	Scoring    ScoringStrategy
+	MatchRules MatchRules
+
+	TeamMode                 bool // Partnerships are set up before the first deal
+	TeamRoundEndsWhenTeamOut bool
+}
+
+// DefaultTableSettings returns the settings of DefaultTableConfig.
+func DefaultTableSettings() TableSettings {
+	return TableSettings{Rules: DefaultRuleOptions(), Scoring: StandardScoring{}, MatchRules: DefaultMatchRules()}
+}
+
+// Settings resolves the names in c, returning every problem found together.
+func (c TableConfig) Settings() (TableSettings, error) {
+	s := DefaultTableSettings()
+	var errs []error
+	var err error
+	if s.Rules, err = NewRuleOptions(c.Ruleset); err != nil {
+		errs = append(errs, fmt.Errorf("ruleset: %w", err))
+	}
+	errs = append(errs, c.applyHouseRules(&s.Rules)...)
+	if s.Scoring, err = NewScoringStrategy(c.Scoring); err != nil {
+		errs = append(errs, fmt.Errorf("scoring: %w", err))
+	}
+	s.TeamMode, s.TeamRoundEndsWhenTeamOut = c.TeamMode, c.TeamRoundEndsWhenTeamOut
+	s.MatchRules = MatchRules{
+		MaxRounds:      c.MaxRounds,
+		RoundWinsToWin: c.RoundWinsToWin,
+		TimeLimit:      time.Duration(c.TimeLimit),
+		BigLossPoints:  c.BigLossPoints,
+		TieBreakers:    []TieBreaker{},
+	}
+	if s.MatchRules.EndMode, err = ParseMatchEndMode(c.EndMode); err != nil {
+		errs = append(errs, fmt.Errorf("endMode: %w", err))
+	} else if err := s.MatchRules.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	for _, name := range c.TieBreakers {
+		tb, err := ParseTieBreaker(name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tieBreakers: %w", err))
+			continue
+		}
+		s.MatchRules.TieBreakers = append(s.MatchRules.TieBreakers, tb)
+	}
+	return s, errors.Join(errs...)
+}
+
+// applyHouseRules sets the house rules in c on opts, returning the problems found.
+func (c TableConfig) applyHouseRules(opts *RuleOptions) []error {
+	var errs []error
+	var err error
+	if opts.SingleCardLeftRule, err = ParseSingleCardLeftMode(c.SingleCardLeft); err != nil {
+		errs = append(errs, fmt.Errorf("singleCardLeft: %w", err))
+	}
+	if opts.SingleCardLeftPenalty = c.SingleCardLeftPenalty; opts.SingleCardLeftRule == SingleCardLeftPenalize && opts.SingleCardLeftPenalty < 1 {
+		errs = append(errs, fmt.Errorf("singleCardLeftPenalty must be at least 1 in penalty mode, not %d", c.SingleCardLeftPenalty))
+	}
+	if opts.CardExchangeCount = c.CardExchangeCount; opts.CardExchangeCount < 0 || opts.CardExchangeCount > maxCardExchangeCount {
+		errs = append(errs, fmt.Errorf("cardExchangeCount must be between 0 and %d, not %d", maxCardExchangeCount, c.CardExchangeCount))
+	}
+	if opts.InstantWins, err = ParseInstantWinKinds(c.InstantWins); err != nil {
+		errs = append(errs, fmt.Errorf("instantWins: %w", err))
+	}
+	if opts.InstantWinPoints = c.InstantWinPoints; opts.InstantWinPoints < 0 {
+		errs = append(errs, fmt.Errorf("instantWinPoints must not be negative, not %d", c.InstantWinPoints))
+	}
+	if opts.Jokers = c.Jokers; opts.Jokers < 0 || opts.Jokers > maxJokers {
+		errs = append(errs, fmt.Errorf("jokers must be between 0 and %d, not %d", maxJokers, c.Jokers))
+	}
+	opts.JokerSingleBeatsTwo = c.JokerSingleBeatsTwo
+	return errs
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+)
+
+// Team is a partnership in the 2v2 variant. Partners sit opposite each other.
+type Team struct {
+	ID        string   `json:"id"`
+	PlayerIDs []string `json:"playerIds"`
+}
+
+// SetupPartnerships enables team mode on a four-player table: seats 1 and 3 form
+// "teamA" and seats 2 and 4 form "teamB". If roundEndsWhenTeamOut is set, a round
+// continues after the first player goes out and ends once both members of a team are out.
+func SetupPartnerships(game *GameState, roundEndsWhenTeamOut bool) error {
+	if len(game.Players) != 4 {
+		return errors.New("team mode needs exactly 4 players")
+	}
+	game.Teams = []Team{
+		{ID: "teamA", PlayerIDs: []string{game.Players[0].ID, game.Players[2].ID}},
+		{ID: "teamB", PlayerIDs: []string{game.Players[1].ID, game.Players[3].ID}},
+	}
+	game.TeamRoundEndsWhenTeamOut = roundEndsWhenTeamOut
+	return nil
+}
+
+// teamOf returns the team the player belongs to, or nil outside team mode.
+func (g *GameState) teamOf(playerID string) *Team {
+	for i := range g.Teams {
+		for _, id := range g.Teams[i].PlayerIDs {
+			if id == playerID {
+				return &g.Teams[i]
+			}
+		}
+	}
+	return nil
+}
+
+// areTeammates reports whether two different players are partners.
+func (g *GameState) areTeammates(a, b string) bool {
+	team := g.teamOf(a)
+	return team != nil && a != b && team == g.teamOf(b)
+}
+
+// matchScore is the score used for match-end checks and standings: the combined
+// team score in team mode, otherwise the player's own score.
+func (g *GameState) matchScore(playerID string) int {
+	team := g.teamOf(playerID)
+	if team == nil {
+		return g.Scores[playerID]
+	}
+	total := 0
+	for _, id := range team.PlayerIDs {
+		total += g.Scores[id]
+	}
+	return total
+}
+
+// TeamScores returns each team's combined match score, or nil outside team mode.
+func (g *GameState) TeamScores() map[string]int {
+	if len(g.Teams) == 0 {
+		return nil
+	}
+	scores := make(map[string]int, len(g.Teams))
+	for _, team := range g.Teams {
+		scores[team.ID] = g.matchScore(team.PlayerIDs[0])
+	}
+	return scores
+}
+
+// playerGoesOut records that a player has emptied their hand and reports whether the
+// round is over, returning the round winner. Outside team mode, or when the table ends
+// rounds on the first player out, the player who went out wins. Otherwise the round
+// ends when both members of a team are out, and the teammate who went out first wins.
+func playerGoesOut(game *GameState, player *Player) (winner *Player, roundOver bool) {
+	game.FinishOrder = append(game.FinishOrder, player.ID)
+	team := game.teamOf(player.ID)
+	if team == nil || !game.TeamRoundEndsWhenTeamOut {
+		return player, true
+	}
+	for _, id := range team.PlayerIDs {
+		if !containsString(game.FinishOrder, id) {
+			playerLog(game, player).Info("out, waiting for partner", "team", team.ID)
+			return nil, false
+		}
+	}
+	for _, id := range game.FinishOrder {
+		if game.teamOf(id) == team {
+			return game.playerByID(id), true
+		}
+	}
+	return player, true
+}
+
+// playerByID returns the seated player with the given ID, or nil.
+func (g *GameState) playerByID(id string) *Player {
+	for _, p := range g.Players {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// teamTestGame builds a four-player 2v2 match in progress.
+func teamTestGame(t *testing.T, roundEndsWhenTeamOut bool) *GameState {
+	t.Helper()
+	game := matchTestGame(DefaultMatchRules(), map[string]int{})
+	game.Players = append(game.Players, NewPlayer(4, "P4"))
+	if err := SetupPartnerships(game, roundEndsWhenTeamOut); err != nil {
+		t.Fatalf("SetupPartnerships: %v", err)
+	}
+	return game
+}
+
+func TestSetupPartnerships(t *testing.T) {
+	game := teamTestGame(t, false)
+	if !game.areTeammates("player1", "player3") || !game.areTeammates("player2", "player4") {
+		t.Errorf("opposite seats should be partners, got teams %+v", game.Teams)
+	}
+	if game.areTeammates("player1", "player2") || game.areTeammates("player1", "player1") {
+		t.Error("adjacent seats or the same player should not be teammates")
+	}
+
+	three := &GameState{Players: []*Player{NewPlayer(1, "P1"), NewPlayer(2, "P2"), NewPlayer(3, "P3")}}
+	if err := SetupPartnerships(three, false); err == nil {
+		t.Error("expected an error for a three-player table")
+	}
+}
+
+func TestTeamScoringAndMatchEnd(t *testing.T) {
+	game := teamTestGame(t, false)
+	game.Scores = map[string]int{"player1": 0, "player2": 20, "player3": 0, "player4": 15}
+
+	// player1 goes out; partner player3 is not penalized for the cards left.
+	winRound(game, 0, 3, time.Unix(0, 0))
+	want := map[string]int{"player1": 0, "player2": 3, "player3": 0, "player4": 3}
+	for id, score := range want {
+		if got := game.RoundScoresHistory[0][id]; got != score {
+			t.Errorf("round score for %s = %d, want %d", id, got, score)
+		}
+	}
+	if game.IsMatchOver {
+		t.Fatal("match ended before a team reached the target")
+	}
+
+	// teamB now has 57 combined even though neither member reached the target alone.
+	game.RoundNumber++
+	winRound(game, 2, 8, time.Unix(0, 0))
+	if got := game.TeamScores()["teamB"]; got < game.TargetScore {
+		t.Fatalf("teamB score = %d, want at least %d", got, game.TargetScore)
+	}
+	if !game.IsMatchOver || game.OverallWinningTeamID != "teamA" {
+		t.Errorf("IsMatchOver = %v, OverallWinningTeamID = %q; want true, teamA", game.IsMatchOver, game.OverallWinningTeamID)
+	}
+}
+
+func TestNewGameStateWithSettings_TeamMode(t *testing.T) {
+	settings := DefaultTableSettings()
+	settings.TeamMode, settings.TeamRoundEndsWhenTeamOut = true, true
+	players := []*Player{NewPlayer(1, "P1"), NewPlayer(2, "P2"), NewPlayer(3, "P3"), NewPlayer(4, "P4")}
+	game := NewGameStateWithSettings("t", players, 50, settings)
+	if !game.areTeammates("player1", "player3") || !game.TeamRoundEndsWhenTeamOut {
+		t.Errorf("teams = %+v, TeamRoundEndsWhenTeamOut = %v; want partnerships that end rounds when a team is out", game.Teams, game.TeamRoundEndsWhenTeamOut)
+	}
+}
+
+func TestPlayerGoesOut(t *testing.T) {
+	t.Run("First out ends the round", func(t *testing.T) {
+		game := teamTestGame(t, false)
+		winner, over := playerGoesOut(game, game.Players[1])
+		if !over || winner != game.Players[1] {
+			t.Errorf("got winner %v, over %v; want player2, true", winner, over)
+		}
+	})
+
+	t.Run("Round ends when both partners are out", func(t *testing.T) {
+		game := teamTestGame(t, true)
+		if _, over := playerGoesOut(game, game.Players[1]); over {
+			t.Fatal("round ended with player4 still in")
+		}
+		if _, over := playerGoesOut(game, game.Players[0]); over {
+			t.Fatal("round ended with player3 still in")
+		}
+		winner, over := playerGoesOut(game, game.Players[3])
+		if !over || winner != game.Players[1] {
+			t.Errorf("got winner %v, over %v; want player2 (first of teamB out), true", winner, over)
+		}
+	})
+}
+
+func TestTurnOrderSkipsPlayersWhoAreOut(t *testing.T) {
+	game := teamTestGame(t, true)
+	for i, p := range game.Players {
+		if i == 1 {
+			p.Hand = Deck{}
+		} else {
+			p.Hand = NewDeck()[:3]
+		}
+	}
+	if next := game.nextPlayerInRound(0); next != 2 {
+		t.Errorf("nextPlayerInRound(0) = %d, want 2", next)
+	}
+
+	// player2 went out on the last play, so all three remaining players must pass.
+	game.LastPlayedHand = &PlayedHand{PlayerID: "player2"}
+	if got := game.passesToWinTrick(); got != 3 {
+		t.Errorf("passesToWinTrick = %d, want 3", got)
+	}
+	game.LastPlayedHand = &PlayedHand{PlayerID: "player3"}
+	if got := game.passesToWinTrick(); got != 2 {
+		t.Errorf("passesToWinTrick = %d, want 2", got)
+	}
+}
+
+func TestRecordRoundResults_TeamRound(t *testing.T) {
+	store, err := OpenAccountStore(filepath.Join(t.TempDir(), "accounts.json"))
+	if err != nil {
+		t.Fatalf("OpenAccountStore() error = %v", err)
+	}
+	game := teamTestGame(t, true)
+	game.RuleEngine = NewBigTwoRuleEngine()
+	for _, p := range game.Players {
+		account, _, err := store.Register("user-"+p.ID, "secret1")
+		if err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+		p.AccountID = account.ID
+	}
+
+	// player1 and player2 go out, then player3 completes teamA; player4 is left holding cards.
+	game.Players[3].Hand = NewDeck()[:5]
+	for _, p := range game.Players[:3] {
+		p.Hand = Deck{}
+		playerGoesOut(game, p)
+	}
+	finishRound(game, game.Players[0], time.Unix(0, 0))
+	recordRoundResults(store, game, time.Unix(0, 0))
+
+	want := map[string]struct {
+		won       bool
+		cardsLeft int
+	}{
+		"player1": {true, 0},
+		"player2": {false, 0},
+		"player3": {false, 0},
+		"player4": {false, 5},
+	}
+	for _, p := range game.Players {
+		account, err := store.Account(p.AccountID)
+		if err != nil {
+			t.Fatalf("Account(%s) error = %v", p.ID, err)
+		}
+		if len(account.Rounds) != 1 {
+			t.Errorf("%s has %d round records, want 1", p.ID, len(account.Rounds))
+			continue
+		}
+		round := account.Rounds[0]
+		if round.Won != want[p.ID].won || round.CardsLeft != want[p.ID].cardsLeft {
+			t.Errorf("%s round record = won %v, %d cards left; want won %v, %d cards left",
+				p.ID, round.Won, round.CardsLeft, want[p.ID].won, want[p.ID].cardsLeft)
+		}
+	}
+}
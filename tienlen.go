@@ -0,0 +1,95 @@
+package main
+
+import "fmt"
+
+// determineTienLenHand classifies a set of cards under Tiến Lên rules: singles, pairs,
+// triples, four of a kind, sequences of three or more singles and sequences of three or
+// more pairs. 2s cannot appear in sequences and there are no five-card poker hands.
+func (re *BigTwoRuleEngine) determineTienLenHand(selectedCards Deck) (*PlayedHand, error) {
+	if len(selectedCards) == 0 {
+		return nil, fmt.Errorf("no cards selected")
+	}
+	cards := make(Deck, len(selectedCards))
+	copy(cards, selectedCards)
+	cards.Sort()
+
+	hand := &PlayedHand{Cards: cards, EffectiveSuit: -1}
+	top := cards[len(cards)-1]
+	switch {
+	case len(cards) == 1:
+		hand.HandType, hand.EffectiveRank, hand.EffectiveSuit = Single, top.Rank, top.Suit
+	case sameRankCombo(cards) && len(cards) == 2:
+		hand.HandType, hand.EffectiveRank, hand.EffectiveSuit = Pair, top.Rank, re.highestSuit(cards)
+	case sameRankCombo(cards) && len(cards) == 3:
+		hand.HandType, hand.EffectiveRank = Triple, top.Rank
+	case sameRankCombo(cards) && len(cards) == 4:
+		hand.HandType, hand.EffectiveRank = FourOfAKind, top.Rank
+	case len(cards) >= 3 && isRankRun(cards, 1):
+		hand.HandType, hand.EffectiveRank, hand.EffectiveSuit = Sequence, top.Rank, top.Suit
+	case len(cards) >= 6 && isRankRun(cards, 2):
+		hand.HandType, hand.EffectiveRank, hand.EffectiveSuit = PairSequence, top.Rank, re.highestSuit(cards[len(cards)-2:])
+	default:
+		return nil, fmt.Errorf("selected cards do not form a valid Tiến Lên hand")
+	}
+	return hand, nil
+}
+
+// isRankRun reports whether sorted cards are groups of width cards of the same rank with
+// consecutive ranks and no 2s, e.g. 5-6-7 (width 1) or 55-66-77 (width 2).
+func isRankRun(cards Deck, width int) bool {
+	if len(cards)%width != 0 {
+		return false
+	}
+	for i, c := range cards {
+		if c.Rank == Two || c.IsJoker() {
+			return false
+		}
+		if c.Rank != cards[0].Rank+Rank(i/width) {
+			return false
+		}
+	}
+	return true
+}
+
+// pairCount returns the number of pairs in a pair sequence.
+func pairCount(hand HandValue) int {
+	return hand.Size / 2
+}
+
+// tienLenChops reports whether current beats last by "chopping": a three-pair sequence
+// or four of a kind beats a single 2, four of a kind or a four-pair sequence beats a pair
+// of 2s, four of a kind beats a three-pair sequence, and a four-pair sequence beats four
+// of a kind or a three-pair sequence.
+func tienLenChops(current, last HandValue) bool {
+	switch {
+	case last.Type == Single && last.Rank == Two:
+		return current.Type == FourOfAKind || (current.Type == PairSequence && pairCount(current) >= 3)
+	case last.Type == Pair && last.Rank == Two:
+		return current.Type == FourOfAKind || (current.Type == PairSequence && pairCount(current) >= 4)
+	case last.Type == PairSequence && pairCount(last) == 3:
+		return current.Type == FourOfAKind || (current.Type == PairSequence && pairCount(current) >= 4)
+	case last.Type == FourOfAKind:
+		return current.Type == PairSequence && pairCount(current) >= 4
+	}
+	return false
+}
+
+// tienLenBeats compares two plays under Tiến Lên rules. Outside of chops, a play must
+// match the type and length of the last one and be higher: by rank, then by the suit of
+// its highest card (singles, pairs, sequences, pair sequences).
+func (re *BigTwoRuleEngine) tienLenBeats(current, last HandValue) bool {
+	if tienLenChops(current, last) {
+		return true
+	}
+	if current.Type != last.Type || current.Size != last.Size {
+		return false
+	}
+	if current.Rank != last.Rank {
+		return current.Rank > last.Rank
+	}
+	switch current.Type {
+	case Single, Pair, Sequence, PairSequence:
+		return re.suitValue(current.Suit) > re.suitValue(last.Suit)
+	}
+	return false // Triples and four of a kind of the same rank cannot beat each other
+}
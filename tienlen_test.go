@@ -0,0 +1,174 @@
+package main
+
+import "testing"
+
+func newTienLenEngine(t *testing.T) *BigTwoRuleEngine {
+	t.Helper()
+	opts, err := NewRuleOptions(RulesTienLen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewBigTwoRuleEngineWithOptions(opts)
+}
+
+// mustHand classifies cards with the engine, failing the test if they are not a valid hand.
+func mustHand(t *testing.T, re *BigTwoRuleEngine, cards ...Card) *PlayedHand {
+	t.Helper()
+	hand, err := re.DeterminePlayedHand(Deck(cards))
+	if err != nil {
+		t.Fatalf("DeterminePlayedHand(%s): %v", Deck(cards), err)
+	}
+	return hand
+}
+
+func TestTienLen_DeterminePlayedHand(t *testing.T) {
+	re := newTienLenEngine(t)
+
+	tests := []struct {
+		name         string
+		cards        Deck
+		wantHandType HandType
+		wantEffRank  Rank
+		wantEffSuit  Suit
+		wantErr      bool
+	}{
+		// Singles, pairs and triples (hearts are the highest suit, spades the lowest)
+		{"Valid Single (3S)", Deck{C(Rank3, Spades)}, Single, Rank3, Spades, false},
+		{"Valid Pair (9S, 9H) takes hearts", Deck{C(Rank9, Spades), C(Rank9, Hearts)}, Pair, Rank9, Hearts, false},
+		{"Valid Pair (9S, 9D) takes diamonds", Deck{C(Rank9, Spades), C(Rank9, Diamonds)}, Pair, Rank9, Diamonds, false},
+		{"Valid Triple (7s)", Deck{C(Rank7, Diamonds), C(Rank7, Clubs), C(Rank7, Hearts)}, Triple, Rank7, -1, false},
+		{"Invalid Pair (3D, 4D)", Deck{C(Rank3, Diamonds), C(Rank4, Diamonds)}, InvalidHand, -1, -1, true},
+
+		// Four of a kind is a four-card play
+		{"Valid Four of a Kind (8s)", Deck{C(Rank8, Diamonds), C(Rank8, Clubs), C(Rank8, Hearts), C(Rank8, Spades)}, FourOfAKind, Rank8, -1, false},
+
+		// Sequences of any length, no 2s
+		{"Valid Sequence (3-4-5)", Deck{C(Rank3, Spades), C(Rank4, Diamonds), C(Rank5, Clubs)}, Sequence, Rank5, Clubs, false},
+		{"Valid Sequence (3-4-5-6)", Deck{C(Rank3, Spades), C(Rank4, Diamonds), C(Rank5, Clubs), C(Rank6, Hearts)}, Sequence, Rank6, Hearts, false},
+		{"Valid Sequence (7 to A)", Deck{C(Rank7, Spades), C(Rank8, Spades), C(Rank9, Clubs), C(Rank10, Hearts), C(Jack, Hearts), C(Queen, Diamonds), C(King, Spades), C(Ace, Diamonds)}, Sequence, Ace, Diamonds, false},
+		{"Invalid Sequence (Q-K-A-2)", Deck{C(Queen, Spades), C(King, Spades), C(Ace, Clubs), C(Two, Hearts)}, InvalidHand, -1, -1, true},
+		{"Invalid Sequence (gap)", Deck{C(Rank3, Spades), C(Rank4, Spades), C(Rank6, Clubs)}, InvalidHand, -1, -1, true},
+
+		// Consecutive pairs
+		{"Valid Pair Sequence (33-44-55)", Deck{C(Rank3, Spades), C(Rank3, Hearts), C(Rank4, Clubs), C(Rank4, Diamonds), C(Rank5, Spades), C(Rank5, Clubs)}, PairSequence, Rank5, Clubs, false},
+		{"Valid Pair Sequence (4 pairs)", Deck{C(Rank9, Spades), C(Rank9, Hearts), C(Rank10, Clubs), C(Rank10, Diamonds), C(Jack, Spades), C(Jack, Clubs), C(Queen, Spades), C(Queen, Hearts)}, PairSequence, Queen, Hearts, false},
+		{"Invalid Pair Sequence (two pairs)", Deck{C(Rank3, Spades), C(Rank3, Hearts), C(Rank4, Clubs), C(Rank4, Diamonds)}, InvalidHand, -1, -1, true},
+		{"Invalid Pair Sequence (with 2s)", Deck{C(King, Spades), C(King, Hearts), C(Ace, Clubs), C(Ace, Diamonds), C(Two, Spades), C(Two, Clubs)}, InvalidHand, -1, -1, true},
+
+		// No poker hands
+		{"Full House is not a Tiến Lên hand", Deck{C(Rank3, Diamonds), C(Rank3, Clubs), C(Rank3, Hearts), C(Rank5, Spades), C(Rank5, Diamonds)}, InvalidHand, -1, -1, true},
+		{"Flush is not a Tiến Lên hand", Deck{C(Rank3, Diamonds), C(Rank5, Diamonds), C(Rank8, Diamonds), C(Jack, Diamonds), C(King, Diamonds)}, InvalidHand, -1, -1, true},
+		{"Invalid - No cards", Deck{}, InvalidHand, -1, -1, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cards.Sort()
+			playedHand, err := re.DeterminePlayedHand(tc.cards)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("DeterminePlayedHand() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if playedHand.HandType != tc.wantHandType {
+				t.Errorf("DeterminePlayedHand() HandType = %v, want %v", playedHand.HandType, tc.wantHandType)
+			}
+			if playedHand.EffectiveRank != tc.wantEffRank {
+				t.Errorf("DeterminePlayedHand() EffectiveRank = %v, want %v", playedHand.EffectiveRank, tc.wantEffRank)
+			}
+			if playedHand.EffectiveSuit != tc.wantEffSuit {
+				t.Errorf("DeterminePlayedHand() EffectiveSuit = %v, want %v", playedHand.EffectiveSuit, tc.wantEffSuit)
+			}
+		})
+	}
+}
+
+func TestTienLen_BeatsLastHand(t *testing.T) {
+	re := newTienLenEngine(t)
+
+	single3H := mustHand(t, re, C(Rank3, Hearts))
+	single3S := mustHand(t, re, C(Rank3, Spades))
+	single2S := mustHand(t, re, C(Two, Spades))
+	single2H := mustHand(t, re, C(Two, Hearts))
+	pair2s := mustHand(t, re, C(Two, Spades), C(Two, Clubs))
+	pair9D := mustHand(t, re, C(Rank9, Spades), C(Rank9, Diamonds))
+	pair9H := mustHand(t, re, C(Rank9, Clubs), C(Rank9, Hearts))
+	triple7 := mustHand(t, re, C(Rank7, Diamonds), C(Rank7, Clubs), C(Rank7, Hearts))
+	seq345 := mustHand(t, re, C(Rank3, Spades), C(Rank4, Diamonds), C(Rank5, Clubs))
+	seq456 := mustHand(t, re, C(Rank4, Spades), C(Rank5, Diamonds), C(Rank6, Spades))
+	seq345H := mustHand(t, re, C(Rank3, Clubs), C(Rank4, Clubs), C(Rank5, Hearts))
+	seq3456 := mustHand(t, re, C(Rank3, Spades), C(Rank4, Diamonds), C(Rank5, Clubs), C(Rank6, Hearts))
+	quad8 := mustHand(t, re, C(Rank8, Diamonds), C(Rank8, Clubs), C(Rank8, Hearts), C(Rank8, Spades))
+	quadJ := mustHand(t, re, C(Jack, Diamonds), C(Jack, Clubs), C(Jack, Hearts), C(Jack, Spades))
+	threePairs := mustHand(t, re, C(Rank3, Spades), C(Rank3, Hearts), C(Rank4, Clubs), C(Rank4, Diamonds), C(Rank5, Spades), C(Rank5, Clubs))
+	threePairsHigher := mustHand(t, re, C(Rank6, Spades), C(Rank6, Hearts), C(Rank7, Clubs), C(Rank7, Diamonds), C(Rank8, Spades), C(Rank8, Clubs))
+	fourPairs := mustHand(t, re, C(Rank9, Spades), C(Rank9, Hearts), C(Rank10, Clubs), C(Rank10, Diamonds), C(Jack, Spades), C(Jack, Clubs), C(Queen, Spades), C(Queen, Hearts))
+
+	tests := []struct {
+		name           string
+		currentPlay    *PlayedHand
+		lastPlayedHand *PlayedHand
+		wantBeats      bool
+	}{
+		{"Any hand leads", seq3456, nil, true},
+
+		// Ordinary comparisons use the Tiến Lên suit order (spades < clubs < diamonds < hearts)
+		{"3H beats 3S", single3H, single3S, true},
+		{"3S does not beat 3H", single3S, single3H, false},
+		{"2H beats 2S", single2H, single2S, true},
+		{"Pair 9 with hearts beats pair 9 with diamonds", pair9H, pair9D, true},
+		{"Sequence 4-6 beats 3-5", seq456, seq345, true},
+		{"Sequence 3-5 ending in hearts beats 3-5 ending in clubs", seq345H, seq345, true},
+		{"Sequence length must match", seq3456, seq345, false},
+		{"Triple cannot beat a single", triple7, single3H, false},
+		{"Higher four of a kind beats lower", quadJ, quad8, true},
+		{"Higher three-pair sequence beats lower", threePairsHigher, threePairs, true},
+
+		// Chops
+		{"Three pairs chop a single 2", threePairs, single2H, true},
+		{"Four of a kind chops a single 2", quad8, single2H, true},
+		{"Four of a kind chops a pair of 2s", quad8, pair2s, true},
+		{"Three pairs do not chop a pair of 2s", threePairs, pair2s, false},
+		{"Four pairs chop a pair of 2s", fourPairs, pair2s, true},
+		{"Four of a kind chops three pairs", quad8, threePairsHigher, true},
+		{"Four pairs chop four of a kind", fourPairs, quadJ, true},
+		{"Four of a kind does not chop four pairs", quadJ, fourPairs, false},
+		{"Three pairs cannot be played on a non-2 single", threePairs, single3H, false},
+		{"Four of a kind cannot be played on a pair of 9s", quad8, pair9H, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if gotBeats := re.BeatsLastHand(tc.currentPlay, tc.lastPlayedHand); gotBeats != tc.wantBeats {
+				t.Errorf("BeatsLastHand() for %s: got %v, want %v", tc.name, gotBeats, tc.wantBeats)
+			}
+		})
+	}
+}
+
+func TestTienLen_OpeningCardAndMoves(t *testing.T) {
+	re := newTienLenEngine(t)
+	players := []*Player{NewPlayer(1, "P1"), NewPlayer(2, "P2")}
+	players[0].Hand = Deck{C(Rank3, Diamonds), C(Rank9, Hearts)}
+	players[1].Hand = Deck{C(Rank3, Spades), C(King, Hearts)}
+	idx, card, found := findPlayerWithLowestCard(players, re.CardLess)
+	if !found || idx != 1 || card != C(Rank3, Spades) {
+		t.Errorf("findPlayerWithLowestCard() = (%d, %s, %v), want (1, 3S, true)", idx, card, found)
+	}
+
+	hand := Deck{C(Rank3, Spades), C(Rank4, Clubs), C(Rank5, Diamonds), C(Rank5, Hearts), C(Two, Hearts)}
+	moves := re.GenerateMoves(hand, nil)
+	counts := make(map[HandType]int)
+	for _, m := range moves {
+		counts[m.HandType]++
+	}
+	// Singles: 5. Pairs: 55. Sequences: 3-4-5 (x2).
+	if counts[Single] != 5 || counts[Pair] != 1 || counts[Sequence] != 2 {
+		t.Errorf("GenerateMoves() counts = %v, want 5 singles, 1 pair, 2 sequences", counts)
+	}
+
+	if highest := re.HighestSingle(moves); highest == nil || highest.Cards[0] != C(Two, Hearts) {
+		t.Errorf("HighestSingle() = %v, want 2H", highest)
+	}
+}
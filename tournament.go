@@ -0,0 +1,453 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// TournamentStatus is the lifecycle state of a tournament.
+type TournamentStatus string
+
+const (
+	TournamentRegistering TournamentStatus = "registering"
+	TournamentRunning     TournamentStatus = "running"
+	TournamentFinished    TournamentStatus = "finished"
+)
+
+// TournamentResult is an entrant's finish at one table of one stage.
+type TournamentResult struct {
+	Stage     int    `json:"stage"`
+	TableID   string `json:"tableId"`
+	Placement int    `json:"placement"`
+	Score     int    `json:"score"`
+}
+
+// Ways of seating a stage's entrants at its tables.
+const (
+	SeatingBalanced = "balanced" // Snake-seeded, so every table gets a mix of strong and weak entrants
+	SeatingByRating = "byRating" // Entrants of similar rating sit together; see GroupByRating
+)
+
+// TournamentEntrant is a person registered in a tournament.
+type TournamentEntrant struct {
+	Name       string             `json:"name"`
+	AccountID  string             `json:"accountId,omitempty"`
+	Rating     float64            `json:"rating"`
+	Eliminated bool               `json:"eliminated"`
+	Results    []TournamentResult `json:"results"`
+}
+
+// Tournament runs a multi-stage, multi-table event. Each stage seats the remaining
+// entrants at tables of up to TableSize; when every table's match is over, the top
+// AdvancePerTable finishers at each table advance to the next stage. The stage with a
+// single table is the final.
+type Tournament struct {
+	ID                 string               `json:"id"`
+	Name               string               `json:"name"`
+	OrganizerAccountID string               `json:"organizerAccountId"`
+	TableSize          int                  `json:"tableSize"`
+	AdvancePerTable    int                  `json:"advancePerTable"`
+	TargetScore        int                  `json:"targetScore"`
+	Table              TableConfig          `json:"table"`       // Rules every table is played under
+	Seating            string               `json:"seating"`     // SeatingBalanced or SeatingByRating
+	AutoAdvance        bool                 `json:"autoAdvance"` // Seat the next stage as soon as the current one finishes
+	Status             TournamentStatus     `json:"status"`
+	Stage              int                  `json:"stage"`       // Current stage, 0 before the start
+	StageTables        []string             `json:"stageTables"` // Table IDs of the current stage
+	Entrants           []*TournamentEntrant `json:"entrants"`
+	ChampionName       string               `json:"championName,omitempty"`
+	CreatedAt          time.Time            `json:"createdAt"`
+
+	settings TableSettings      // Resolved from Table
+	champion *TournamentEntrant // Named by ChampionName, which guests may share
+	// seats maps a table ID and seat player ID to the entrant sitting there.
+	seats map[string]map[string]*TournamentEntrant
+}
+
+// tournaments holds every tournament by ID. Protected by gameInstanceMutex.
+var (
+	tournaments      = make(map[string]*Tournament)
+	nextTournamentID = 0
+)
+
+// NewTournament validates the settings and registers a tournament. Assumes gameInstanceMutex is held.
+func NewTournament(name, organizerAccountID string, entrants []*TournamentEntrant, tableSize, advancePerTable, targetScore int, autoAdvance bool, table TableConfig, seating string) (*Tournament, error) {
+	if tableSize < 3 || tableSize > 4 {
+		return nil, errors.New("tableSize must be 3 or 4") // Smaller tables could leave a player alone at a table
+	}
+	if advancePerTable < 1 || advancePerTable >= tableSize {
+		return nil, errors.New("advancePerTable must be at least 1 and less than tableSize")
+	}
+	if len(entrants) < 2 {
+		return nil, errors.New("a tournament needs at least 2 entrants")
+	}
+	if targetScore <= 0 {
+		targetScore = 100
+	}
+	switch seating {
+	case "":
+		seating = SeatingBalanced
+	case SeatingBalanced, SeatingByRating:
+	default:
+		return nil, fmt.Errorf("seating must be %s or %s", SeatingBalanced, SeatingByRating)
+	}
+	if table.TeamMode {
+		return nil, errors.New("teamMode is not supported at tournament tables") // Stages can seat 3 players
+	}
+	settings, err := table.Settings()
+	if err != nil {
+		return nil, err
+	}
+	nextTournamentID++
+	t := &Tournament{
+		ID:                 fmt.Sprintf("t%d", nextTournamentID),
+		Name:               name,
+		OrganizerAccountID: organizerAccountID,
+		TableSize:          tableSize,
+		AdvancePerTable:    advancePerTable,
+		TargetScore:        targetScore,
+		Table:              table,
+		Seating:            seating,
+		settings:           settings,
+		AutoAdvance:        autoAdvance,
+		Status:             TournamentRegistering,
+		Entrants:           entrants,
+		CreatedAt:          time.Now(),
+		seats:              make(map[string]map[string]*TournamentEntrant),
+	}
+	tournaments[t.ID] = t
+	return t, nil
+}
+
+// Start seats the first stage. Entrants are seeded by rating. Assumes gameInstanceMutex is held.
+func (t *Tournament) Start() error {
+	if t.Status != TournamentRegistering {
+		return fmt.Errorf("tournament is %s", t.Status)
+	}
+	seeded := make([]*TournamentEntrant, len(t.Entrants))
+	copy(seeded, t.Entrants)
+	sort.SliceStable(seeded, func(i, j int) bool { return seeded[i].Rating > seeded[j].Rating })
+	t.Status = TournamentRunning
+	return t.seatStage(seeded)
+}
+
+// Advance closes the current stage once every table's match is over and seats the next
+// stage, or finishes the tournament after the final. The closed stage's tables are removed
+// once their results are recorded. Assumes gameInstanceMutex is held.
+func (t *Tournament) Advance() error {
+	if t.Status != TournamentRunning {
+		return fmt.Errorf("tournament is %s", t.Status)
+	}
+	for _, tableID := range t.StageTables {
+		if game := tables[tableID]; game == nil || !game.IsMatchOver {
+			return fmt.Errorf("table %s has not finished its match", tableID)
+		}
+	}
+
+	// Seed the next stage by this stage's finish: placement, then match score.
+	var advancing []*TournamentEntrant
+	for _, tableID := range t.StageTables {
+		game := tables[tableID]
+		// At least one player per table is knocked out so every stage shrinks the field.
+		advanceCount := min(t.AdvancePerTable, len(game.Players)-1)
+		for placement, p := range FinalStandings(game) {
+			entrant := t.seats[tableID][p.ID]
+			entrant.Results = append(entrant.Results, TournamentResult{
+				Stage: t.Stage, TableID: tableID, Placement: placement + 1, Score: game.Scores[p.ID],
+			})
+			if placement < advanceCount && len(t.StageTables) > 1 {
+				advancing = append(advancing, entrant)
+			} else if placement > 0 || len(t.StageTables) > 1 {
+				entrant.Eliminated = true
+			} else {
+				t.champion, t.ChampionName = entrant, entrant.Name
+			}
+		}
+	}
+
+	final := len(t.StageTables) == 1
+	t.closeStage()
+	if final {
+		t.Status = TournamentFinished
+		slog.Info("tournament finished", "tournament", t.ID, "champion", t.ChampionName)
+		return nil
+	}
+	sort.SliceStable(advancing, func(i, j int) bool {
+		a, b := lastResult(advancing[i]), lastResult(advancing[j])
+		if a.Placement != b.Placement {
+			return a.Placement < b.Placement
+		}
+		return a.Score < b.Score
+	})
+	return t.seatStage(advancing)
+}
+
+// closeStage removes the current stage's tables from the server. Clients still connected to
+// one keep their view of its finished match. Assumes gameInstanceMutex is held.
+func (t *Tournament) closeStage() {
+	for _, tableID := range t.StageTables {
+		delete(tables, tableID)
+		delete(t.seats, tableID)
+	}
+	t.StageTables = nil
+}
+
+func lastResult(e *TournamentEntrant) TournamentResult {
+	return e.Results[len(e.Results)-1]
+}
+
+// seatStage creates the tables for the next stage, seating the entrants (already ordered
+// strongest first) as t.Seating says. Assumes gameInstanceMutex is held.
+func (t *Tournament) seatStage(seeded []*TournamentEntrant) error {
+	t.Stage++
+	var groups [][]*TournamentEntrant
+	if t.Seating == SeatingByRating {
+		groups = GroupByRating(seeded, t.TableSize)
+	} else {
+		groups = snakeSeat(seeded, t.TableSize)
+	}
+
+	t.StageTables = nil
+	for i, group := range groups {
+		tableID := fmt.Sprintf("%s-s%d-t%d", t.ID, t.Stage, i+1)
+		players := make([]*Player, len(group))
+		t.seats[tableID] = make(map[string]*TournamentEntrant)
+		for j, entrant := range group {
+			players[j] = NewPlayer(j+1, entrant.Name)
+			players[j].AccountID = entrant.AccountID // Reserve the seat for the entrant's account
+			players[j].Rating = entrant.Rating
+			t.seats[tableID][players[j].ID] = entrant
+		}
+		game := NewGameStateWithSettings(tableID, players, t.TargetScore, t.settings)
+		game.TournamentID = t.ID
+		game.RotateSeats = true
+		if err := registerTable(game); err != nil {
+			return err
+		}
+		t.StageTables = append(t.StageTables, tableID)
+	}
+	slog.Info("tournament stage seated", "tournament", t.ID, "stage", t.Stage, "tables", t.StageTables)
+	return nil
+}
+
+// snakeSeat splits seeded entrants (strongest first) into tables of at most tableSize,
+// dealing them out in a snake so each table gets a balanced mix.
+func snakeSeat(seeded []*TournamentEntrant, tableSize int) [][]*TournamentEntrant {
+	numTables := (len(seeded) + tableSize - 1) / tableSize
+	groups := make([][]*TournamentEntrant, numTables)
+	for i, entrant := range seeded {
+		pass, pos := i/numTables, i%numTables
+		if pass%2 == 1 {
+			pos = numTables - 1 - pos // Snake back on odd passes
+		}
+		groups[pos] = append(groups[pos], entrant)
+	}
+	return groups
+}
+
+// tournamentTableFinished is called when a tournament table's match ends. With AutoAdvance
+// set, the next stage is seated once every table in the stage is done.
+// Assumes gameInstanceMutex is held by the caller.
+func tournamentTableFinished(game *GameState) {
+	t := tournaments[game.TournamentID]
+	if t == nil || !t.AutoAdvance {
+		return
+	}
+	for _, tableID := range t.StageTables {
+		if tables[tableID] == nil || !tables[tableID].IsMatchOver {
+			return
+		}
+	}
+	if err := t.Advance(); err != nil {
+		gameLog(game).Error("cannot advance tournament", "tournament", t.ID, "err", err)
+	}
+}
+
+// Standings returns the entrants ordered by how far they progressed, then by their
+// most recent placement and score.
+func (t *Tournament) Standings() []*TournamentEntrant {
+	standings := make([]*TournamentEntrant, len(t.Entrants))
+	copy(standings, t.Entrants)
+	sort.SliceStable(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		if a == t.champion {
+			return true
+		}
+		if b == t.champion {
+			return false
+		}
+		if len(a.Results) != len(b.Results) {
+			return len(a.Results) > len(b.Results)
+		}
+		if len(a.Results) == 0 {
+			return false
+		}
+		ra, rb := lastResult(a), lastResult(b)
+		if ra.Placement != rb.Placement {
+			return ra.Placement < rb.Placement
+		}
+		return ra.Score < rb.Score
+	})
+	return standings
+}
+
+// SeatingForRound returns the seat order for round (0-based) of an n-player table as
+// indices into the original seating. Seat 0 stays put and the others cycle through every
+// permutation, so over (n-1)! rounds each player follows every other player equally often.
+func SeatingForRound(n, round int) []int {
+	rest := make([]int, 0, n)
+	for i := 1; i < n; i++ {
+		rest = append(rest, i)
+	}
+	perms := permutations(rest)
+	order := append([]int{0}, perms[round%len(perms)]...)
+	return order[:n]
+}
+
+// permutations returns every ordering of items in lexicographic order.
+func permutations(items []int) [][]int {
+	if len(items) <= 1 {
+		return [][]int{append([]int(nil), items...)}
+	}
+	var result [][]int
+	for i, first := range items {
+		rest := make([]int, 0, len(items)-1)
+		rest = append(rest, items[:i]...)
+		rest = append(rest, items[i+1:]...)
+		for _, perm := range permutations(rest) {
+			result = append(result, append([]int{first}, perm...))
+		}
+	}
+	return result
+}
+
+// rotateSeats reorders a table's players for the given round using SeatingForRound.
+// Assumes gameInstanceMutex is held by the caller.
+func rotateSeats(game *GameState) {
+	if game.baseSeating == nil {
+		game.baseSeating = make([]*Player, len(game.Players))
+		copy(game.baseSeating, game.Players)
+	}
+	order := SeatingForRound(len(game.baseSeating), game.RoundNumber-1)
+	for i, idx := range order {
+		game.Players[i] = game.baseSeating[idx]
+		game.Players[i].OrderInTurn = i
+	}
+}
+
+// --- Organizer API ---
+
+type tournamentEntrantRequest struct {
+	Name      string `json:"name"`
+	AccountID string `json:"accountId"`
+}
+
+type createTournamentRequest struct {
+	Name            string                     `json:"name"`
+	Entrants        []tournamentEntrantRequest `json:"entrants"`
+	TableSize       int                        `json:"tableSize"`
+	AdvancePerTable int                        `json:"advancePerTable"`
+	TargetScore     int                        `json:"targetScore"`
+	AutoAdvance     bool                       `json:"autoAdvance"`
+	Table           TableConfig                `json:"table"`   // Keys left out keep their defaults
+	Seating         string                     `json:"seating"` // SeatingBalanced (the default) or SeatingByRating
+}
+
+// tournamentView is the published form of a tournament, with entrants in standings order.
+func tournamentView(t *Tournament) map[string]interface{} {
+	return map[string]interface{}{
+		"id": t.ID, "name": t.Name, "status": t.Status, "stage": t.Stage,
+		"tableSize": t.TableSize, "advancePerTable": t.AdvancePerTable, "targetScore": t.TargetScore,
+		"table": t.Table, "seating": t.Seating, "stageTables": t.StageTables, "championName": t.ChampionName, "standings": t.Standings(),
+	}
+}
+
+// handleCreateTournament serves POST /api/tournaments. The caller's account becomes the organizer.
+func handleCreateTournament(w http.ResponseWriter, r *http.Request) {
+	organizer, err := authenticateHandshake(accountStore, r)
+	if err != nil || organizer == nil {
+		writeJSONError(w, http.StatusUnauthorized, "an account session token is required")
+		return
+	}
+	req := createTournamentRequest{Table: DefaultTableConfig()}
+	if err := decodeJSONBody(r, &req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	entrants := make([]*TournamentEntrant, 0, len(req.Entrants))
+	for _, e := range req.Entrants {
+		entrant := &TournamentEntrant{Name: e.Name, AccountID: e.AccountID, Rating: DefaultRating}
+		if e.AccountID != "" {
+			account, err := accountStore.Account(e.AccountID)
+			if err != nil {
+				writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("entrant %q: %v", e.Name, err))
+				return
+			}
+			entrant.Rating = account.Rating
+			if entrant.Name == "" {
+				entrant.Name = account.Username
+			}
+		}
+		entrants = append(entrants, entrant)
+	}
+
+	gameInstanceMutex.Lock()
+	defer gameInstanceMutex.Unlock()
+	t, err := NewTournament(req.Name, organizer.ID, entrants, req.TableSize, req.AdvancePerTable, req.TargetScore, req.AutoAdvance, req.Table, req.Seating)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, tournamentView(t))
+}
+
+// handleTournamentControl serves the organizer's POST /api/tournaments/{id}/{action}
+// where action is "start" or "advance".
+func handleTournamentControl(w http.ResponseWriter, r *http.Request) {
+	organizer, err := authenticateHandshake(accountStore, r)
+	if err != nil || organizer == nil {
+		writeJSONError(w, http.StatusUnauthorized, "an account session token is required")
+		return
+	}
+	gameInstanceMutex.Lock()
+	defer gameInstanceMutex.Unlock()
+	t := tournaments[r.PathValue("id")]
+	if t == nil {
+		writeJSONError(w, http.StatusNotFound, "tournament not found")
+		return
+	}
+	if t.OrganizerAccountID != organizer.ID {
+		writeJSONError(w, http.StatusForbidden, "only the organizer can control this tournament")
+		return
+	}
+	switch r.PathValue("action") {
+	case "start":
+		err = t.Start()
+	case "advance":
+		err = t.Advance()
+	default:
+		writeJSONError(w, http.StatusNotFound, "unknown action")
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, tournamentView(t))
+}
+
+// handleGetTournament serves GET /api/tournaments/{id} with the published standings.
+func handleGetTournament(w http.ResponseWriter, r *http.Request) {
+	gameInstanceMutex.Lock()
+	defer gameInstanceMutex.Unlock()
+	t := tournaments[r.PathValue("id")]
+	if t == nil {
+		writeJSONError(w, http.StatusNotFound, "tournament not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, tournamentView(t))
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSeatingForRound_BalancesSuccessors(t *testing.T) {
+	const n = 4
+	rounds := 6 // (n-1)!
+	follows := make(map[[2]int]int)
+	for round := 0; round < rounds; round++ {
+		order := SeatingForRound(n, round)
+		if order[0] != 0 || len(order) != n {
+			t.Fatalf("SeatingForRound(%d, %d) = %v, want %d seats starting with 0", n, round, order, n)
+		}
+		for i := range order {
+			follows[[2]int{order[i], order[(i+1)%n]}]++
+		}
+	}
+	// Every ordered pair (a is followed by b) occurs equally often.
+	for a := 0; a < n; a++ {
+		for b := 0; b < n; b++ {
+			if a != b && follows[[2]int{a, b}] != 2 {
+				t.Errorf("player %d followed by %d %d times over %d rounds, want 2", a, b, follows[[2]int{a, b}], rounds)
+			}
+		}
+	}
+}
+
+// finishTableMatch ends a tournament table's match with the seats ranked in the given order.
+func finishTableMatch(game *GameState, order []int) {
+	game.IsGameOver = true
+	game.IsMatchOver = true
+	for rank, idx := range order {
+		game.Scores[game.Players[idx].ID] = rank * 10
+	}
+	game.OverallWinnerID = game.Players[order[0]].ID
+}
+
+func TestTournament_StagesAndChampion(t *testing.T) {
+	entrants := make([]*TournamentEntrant, 8)
+	for i := range entrants {
+		entrants[i] = &TournamentEntrant{Name: fmt.Sprintf("E%d", i+1), Rating: float64(1500 + 10*i)}
+	}
+	tour, err := NewTournament("Weekly", "acct1", entrants, 4, 2, 50, true, DefaultTableConfig(), "")
+	if err != nil {
+		t.Fatalf("NewTournament() error = %v", err)
+	}
+	t.Cleanup(func() {
+		delete(tournaments, tour.ID)
+		for id, game := range tables {
+			if game.TournamentID == tour.ID {
+				delete(tables, id)
+			}
+		}
+	})
+
+	if err := tour.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if len(tour.StageTables) != 2 {
+		t.Fatalf("stage 1 tables = %v, want 2", tour.StageTables)
+	}
+	// Snake seeding: E8 (top seed) and E5 sit at table 1 with E4 and E1.
+	table1 := tables[tour.StageTables[0]]
+	if table1.Players[0].Name != "E8" || table1.Players[1].Name != "E5" || table1.Players[2].Name != "E4" {
+		t.Errorf("table 1 seating = %v, want snake seeded E8, E5, E4, E1", playerIDs(table1.Players))
+	}
+	if err := tour.Advance(); err == nil {
+		t.Error("Advance() before tables finished error = nil, want error")
+	}
+
+	// Finish stage 1: seats 2 and 3 win at each table. Auto-advance seats the final.
+	stage1 := tour.StageTables
+	for _, tableID := range stage1 {
+		finishTableMatch(tables[tableID], []int{2, 3, 0, 1})
+		tournamentTableFinished(tables[tableID])
+	}
+	if tour.Stage != 2 || len(tour.StageTables) != 1 {
+		t.Fatalf("after stage 1: stage %d tables %v, want stage 2 with one final table", tour.Stage, tour.StageTables)
+	}
+	for _, tableID := range stage1 {
+		if tables[tableID] != nil {
+			t.Errorf("stage 1 table %s still registered after the stage closed", tableID)
+		}
+	}
+	final := tables[tour.StageTables[0]]
+	if len(final.Players) != 4 {
+		t.Fatalf("final table has %d players, want 4", len(final.Players))
+	}
+
+	finishTableMatch(final, []int{3, 0, 1, 2})
+	tournamentTableFinished(final)
+	if tour.Status != TournamentFinished || tour.ChampionName != final.Players[3].Name {
+		t.Errorf("status %s champion %q, want finished with %q", tour.Status, tour.ChampionName, final.Players[3].Name)
+	}
+	if tables[final.ID] != nil || len(tour.StageTables) != 0 {
+		t.Errorf("final table still registered (stage tables %v) after the tournament finished", tour.StageTables)
+	}
+	standings := tour.Standings()
+	if standings[0].Name != tour.ChampionName || !standings[len(standings)-1].Eliminated {
+		t.Errorf("Standings() first = %q last eliminated = %v, want champion first", standings[0].Name, standings[len(standings)-1].Eliminated)
+	}
+}
+
+func TestTournament_StandingsWithSharedNames(t *testing.T) {
+	knockedOut := &TournamentEntrant{Name: "Guest", Eliminated: true, Results: []TournamentResult{{Stage: 1, Placement: 4}}}
+	runnerUp := &TournamentEntrant{Name: "E3", Eliminated: true, Results: []TournamentResult{{Stage: 1, Placement: 1}, {Stage: 2, Placement: 2}}}
+	champion := &TournamentEntrant{Name: "Guest", Results: []TournamentResult{{Stage: 1, Placement: 2}, {Stage: 2, Placement: 1}}}
+	tour := &Tournament{Entrants: []*TournamentEntrant{knockedOut, runnerUp, champion}, ChampionName: "Guest", champion: champion}
+
+	standings := tour.Standings()
+	if standings[0] != champion || standings[1] != runnerUp || standings[2] != knockedOut {
+		t.Errorf("Standings() = %+v, %+v, %+v; want the champion, the runner-up, then the other Guest", standings[0], standings[1], standings[2])
+	}
+}
+
+func TestRotateSeats(t *testing.T) {
+	game := NewGameState("rotate-test", []*Player{NewPlayer(1, "A"), NewPlayer(2, "B"), NewPlayer(3, "C")}, 100)
+	game.RoundNumber = 2
+	rotateSeats(game)
+	if got := playerIDs(game.Players); got[0] != "player1" || got[1] != "player3" || got[2] != "player2" {
+		t.Errorf("rotateSeats() round 2 = %v, want [player1 player3 player2]", got)
+	}
+	game.RoundNumber = 3
+	rotateSeats(game)
+	if got := playerIDs(game.Players); got[1] != "player2" {
+		t.Errorf("rotateSeats() round 3 = %v, want original order", got)
+	}
+}
+
+func TestTournament_TableConfig(t *testing.T) {
+	entrants := make([]*TournamentEntrant, 3)
+	for i := range entrants {
+		entrants[i] = &TournamentEntrant{Name: fmt.Sprintf("E%d", i+1), Rating: DefaultRating}
+	}
+	bad := DefaultTableConfig()
+	bad.Scoring = "golf"
+	if _, err := NewTournament("Bad", "acct1", entrants, 3, 1, 50, false, bad, ""); err == nil {
+		t.Error("NewTournament() with an unknown scoring scheme succeeded, want an error")
+	}
+
+	table := DefaultTableConfig()
+	table.Ruleset, table.Scoring = RulesTienLen, ScoringMoney
+	table.EndMode, table.MaxRounds, table.TieBreakers = "fixedRounds", 6, stringList{"bestLastRound"}
+	table.SingleCardLeft, table.CardExchangeCount = "penalty", 2
+	table.InstantWins, table.InstantWinPoints = stringList{"dragon", "sixPairs"}, 30
+	table.Jokers, table.JokerSingleBeatsTwo = 1, true
+	tour, err := NewTournament("Cup", "acct1", entrants, 3, 1, 50, false, table, "")
+	if err != nil {
+		t.Fatalf("NewTournament() error = %v", err)
+	}
+	t.Cleanup(func() {
+		delete(tournaments, tour.ID)
+		for _, id := range tour.StageTables {
+			delete(tables, id)
+		}
+	})
+	if err := tour.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	game := tables[tour.StageTables[0]]
+	if game.RuleEngine.Options.Name != RulesTienLen || game.Scoring.Name() != ScoringMoney {
+		t.Errorf("table plays %s with %s scoring, want tienLen with money", game.RuleEngine.Options.Name, game.Scoring.Name())
+	}
+	if r := game.MatchRules; r.EndMode != EndAfterRounds || r.MaxRounds != 6 || len(r.TieBreakers) != 1 || r.TieBreakers[0] != TieBreakBestLastRound {
+		t.Errorf("table match rules = %+v, want 6 fixed rounds broken by the last round", r)
+	}
+	if opts := game.RuleEngine.Options; opts.SingleCardLeftRule != SingleCardLeftPenalize || opts.SingleCardLeftPenalty != 10 || opts.CardExchangeCount != 2 {
+		t.Errorf("single card left = %s with penalty %d, exchange %d; want penalty of 10, exchange 2",
+			opts.SingleCardLeftRule, opts.SingleCardLeftPenalty, opts.CardExchangeCount)
+	}
+	if opts := game.RuleEngine.Options; len(opts.InstantWins) != 2 || opts.InstantWins[1] != InstantWinSixPairs || opts.InstantWinPoints != 30 {
+		t.Errorf("instant wins = %v for %d points, want dragon and sixPairs for 30", opts.InstantWins, opts.InstantWinPoints)
+	}
+	if opts := game.RuleEngine.Options; opts.Jokers != 1 || !opts.JokerSingleBeatsTwo {
+		t.Errorf("jokers = %d, single beats two = %v; want 1, true", opts.Jokers, opts.JokerSingleBeatsTwo)
+	}
+}
+
+func TestTournament_SeatingByRating(t *testing.T) {
+	entrants := make([]*TournamentEntrant, 6)
+	for i := range entrants {
+		entrants[i] = &TournamentEntrant{Name: fmt.Sprintf("E%d", i+1), Rating: float64(1500 + 10*i)}
+	}
+	if _, err := NewTournament("Bad", "acct1", entrants, 3, 1, 50, false, DefaultTableConfig(), "random"); err == nil {
+		t.Error("NewTournament() with an unknown seating succeeded, want an error")
+	}
+	tour, err := NewTournament("Ladder", "acct1", entrants, 3, 1, 50, false, DefaultTableConfig(), SeatingByRating)
+	if err != nil {
+		t.Fatalf("NewTournament() error = %v", err)
+	}
+	t.Cleanup(func() {
+		delete(tournaments, tour.ID)
+		for _, id := range tour.StageTables {
+			delete(tables, id)
+		}
+	})
+	if err := tour.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	for i, want := range [][]string{{"E6", "E5", "E4"}, {"E3", "E2", "E1"}} {
+		game := tables[tour.StageTables[i]]
+		for j, p := range game.Players {
+			if p.Name != want[j] {
+				t.Errorf("table %d seats %v, want %v", i+1, playerNames(game.Players), want)
+				break
+			}
+		}
+	}
+}
+
+func playerNames(players []*Player) []string {
+	names := make([]string, len(players))
+	for i, p := range players {
+		names[i] = p.Name
+	}
+	return names
+}
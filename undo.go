@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// undoRequestTimeout is how long opponents have to accept an undo request.
+var undoRequestTimeout = 30 * time.Second
+
+// turnSnapshot is the part of the table a single play or pass changes, captured just
+// before the action so that it can be taken back.
+type turnSnapshot struct {
+	PlayerID               string
+	Hand                   Deck            // The acting player's hand
+	HasPassed              map[string]bool // Every player's, since winning a trick clears them all
+	LastPlayedHand         *PlayedHand
+	PassCount              int
+	CurrentTurnPlayerIndex int
+	OpeningCard            *Card
+	FinishOrder            []string
+	RoundPlays             map[HandType]int // The acting player's
+	Penalty                int              // The acting player's penalty points this round
+}
+
+// UndoRequest is a pending request to take back a player's last action.
+type UndoRequest struct {
+	PlayerID  string    `json:"playerId"`
+	Accepted  []string  `json:"accepted"` // Opponents who have agreed so far
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	timer *time.Timer
+}
+
+// newTurnSnapshot captures the table before player acts. Assumes gameInstanceMutex is held.
+func newTurnSnapshot(game *GameState, player *Player) *turnSnapshot {
+	s := &turnSnapshot{
+		PlayerID:               player.ID,
+		Hand:                   append(Deck{}, player.Hand...),
+		HasPassed:              make(map[string]bool, len(game.Players)),
+		LastPlayedHand:         game.LastPlayedHand,
+		PassCount:              game.PassCount,
+		CurrentTurnPlayerIndex: game.CurrentTurnPlayerIndex,
+		OpeningCard:            game.OpeningCard,
+		FinishOrder:            append([]string(nil), game.FinishOrder...),
+		RoundPlays:             make(map[HandType]int),
+		Penalty:                game.Penalties[player.ID],
+	}
+	for _, p := range game.Players {
+		s.HasPassed[p.ID] = p.HasPassed
+	}
+	for ht, n := range game.RoundPlays[player.ID] {
+		s.RoundPlays[ht] = n
+	}
+	return s
+}
+
+// recordAction makes snapshot the action that can be taken back. Any other player's
+// pending undo request lapses, since someone has now acted after it.
+func recordAction(game *GameState, snapshot *turnSnapshot) {
+	game.lastAction = snapshot
+	cancelUndoRequest(game)
+}
+
+// isRanked reports whether the table's results count towards ratings: tournament tables,
+// and tables with at least two account-bound seats (see updateRatings).
+func (g *GameState) isRanked() bool {
+	if g.TournamentID != "" {
+		return true
+	}
+	bound := 0
+	for _, p := range g.Players {
+		if p.AccountID != "" {
+			bound++
+		}
+	}
+	return bound >= 2
+}
+
+// undoOpponents returns the players whose consent an undo by playerID needs.
+func undoOpponents(game *GameState, playerID string) []*Player {
+	var opponents []*Player
+	for _, p := range game.Players {
+		if p.ID != playerID && !p.IsEliminated {
+			opponents = append(opponents, p)
+		}
+	}
+	return opponents
+}
+
+// RequestUndo opens a request by player to take back their last play or pass.
+// It is only possible while nobody has acted since. Assumes gameInstanceMutex is held.
+func RequestUndo(game *GameState, player *Player) (*UndoRequest, error) {
+	switch {
+	case game.isRanked():
+		return nil, fmt.Errorf("undo is disabled on ranked tables")
+	case game.IsGameOver:
+		return nil, fmt.Errorf("the round is over")
+	case game.UndoRequest != nil:
+		return nil, fmt.Errorf("an undo request is already pending")
+	case game.lastAction == nil || game.lastAction.PlayerID != player.ID:
+		return nil, fmt.Errorf("you can only take back your own last action before anyone else acts")
+	}
+	game.UndoRequest = &UndoRequest{
+		PlayerID:  player.ID,
+		Accepted:  []string{},
+		ExpiresAt: time.Now().Add(undoRequestTimeout),
+	}
+	return game.UndoRequest, nil
+}
+
+// RespondUndo records player's answer to the pending undo request. The undo is applied
+// once every opponent has accepted; a single refusal cancels it for good. Reports whether the
+// request was resolved either way. Assumes gameInstanceMutex is held.
+func RespondUndo(game *GameState, player *Player, accept bool) (resolved bool, err error) {
+	req := game.UndoRequest
+	switch {
+	case req == nil:
+		return false, fmt.Errorf("there is no undo request to answer")
+	case req.PlayerID == player.ID:
+		return false, fmt.Errorf("you cannot answer your own undo request")
+	case containsString(req.Accepted, player.ID):
+		return false, fmt.Errorf("you have already accepted")
+	}
+	if !accept {
+		cancelUndoRequest(game)
+		game.lastAction = nil // One request per action
+		return true, nil
+	}
+	req.Accepted = append(req.Accepted, player.ID)
+	for _, p := range undoOpponents(game, req.PlayerID) {
+		if !containsString(req.Accepted, p.ID) {
+			return false, nil
+		}
+	}
+	cancelUndoRequest(game)
+	applyUndo(game)
+	return true, nil
+}
+
+// cancelUndoRequest drops the pending undo request, if any, and stops its timer.
+func cancelUndoRequest(game *GameState) {
+	if game.UndoRequest != nil && game.UndoRequest.timer != nil {
+		game.UndoRequest.timer.Stop()
+	}
+	game.UndoRequest = nil
+}
+
+// applyUndo restores the table to the last action's snapshot.
+func applyUndo(game *GameState) {
+	s := game.lastAction
+	game.lastAction = nil
+	player := game.playerByID(s.PlayerID)
+	player.Hand = s.Hand
+	for _, p := range game.Players {
+		p.HasPassed = s.HasPassed[p.ID]
+	}
+	game.LastPlayedHand = s.LastPlayedHand
+	game.PassCount = s.PassCount
+	game.CurrentTurnPlayerIndex = s.CurrentTurnPlayerIndex
+	game.OpeningCard = s.OpeningCard
+	game.FinishOrder = s.FinishOrder
+	if game.RoundPlays != nil {
+		game.RoundPlays[s.PlayerID] = s.RoundPlays
+	}
+	if game.Penalties != nil {
+		game.Penalties[s.PlayerID] = s.Penalty
+	}
+	playerLog(game, player).Info("last action undone")
+}
+
+// expireUndoRequest cancels req for good when its time runs out, unless it was resolved first.
+func expireUndoRequest(game *GameState, req *UndoRequest) {
+	req.timer = time.AfterFunc(time.Until(req.ExpiresAt), func() {
+		gameInstanceMutex.Lock()
+		defer gameInstanceMutex.Unlock()
+		if game.UndoRequest != req {
+			return
+		}
+		game.UndoRequest = nil
+		game.lastAction = nil
+		broadcastSystemMessage(game, "The undo request expired.")
+		broadcastGameState(game)
+	})
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestUndo_Validation(t *testing.T) {
+	newGame := func() *GameState {
+		game := NewGameState("undo", []*Player{NewPlayer(1, "P1"), NewPlayer(2, "P2"), NewPlayer(3, "P3")}, 100)
+		recordAction(game, newTurnSnapshot(game, game.Players[0]))
+		return game
+	}
+	tests := []struct {
+		name    string
+		setup   func(*GameState)
+		player  int
+		wantErr string
+	}{
+		{"own last action", func(*GameState) {}, 0, ""},
+		{"someone else's action", func(*GameState) {}, 1, "your own last action"},
+		{"nothing to take back", func(g *GameState) { g.lastAction = nil }, 0, "your own last action"},
+		{"round over", func(g *GameState) { g.IsGameOver = true }, 0, "round is over"},
+		{"tournament table", func(g *GameState) { g.TournamentID = "t1" }, 0, "ranked"},
+		{"rated seats", func(g *GameState) { g.Players[1].AccountID, g.Players[2].AccountID = "a", "b" }, 0, "ranked"},
+		{"one account is unrated", func(g *GameState) { g.Players[1].AccountID = "a" }, 0, ""},
+		{"already pending", func(g *GameState) { g.UndoRequest = &UndoRequest{PlayerID: "player1"} }, 0, "already pending"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := newGame()
+			tt.setup(game)
+			_, err := RequestUndo(game, game.Players[tt.player])
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("RequestUndo: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("RequestUndo error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRespondUndo_RestoresTrickWin(t *testing.T) {
+	game := NewGameState("undo", []*Player{NewPlayer(1, "P1"), NewPlayer(2, "P2")}, 100)
+	p1, p2 := game.Players[0], game.Players[1]
+	p1.Hand, p2.Hand = Deck{C(Rank4, Clubs), C(Two, Spades)}, Deck{C(Rank5, Clubs)}
+	game.LastPlayedHand = &PlayedHand{Cards: []Card{C(Rank3, Diamonds)}, PlayerID: p2.ID}
+	game.CurrentTurnPlayerIndex = 0
+	game.OpeningCard = nil
+	before := *game
+
+	// player1 passes, which wins player2 the trick and clears every pass.
+	recordAction(game, newTurnSnapshot(game, p1))
+	p1.HasPassed = true
+	game.LastPlayedHand, game.PassCount, game.CurrentTurnPlayerIndex = nil, 0, 1
+	p1.HasPassed = false
+
+	if _, err := RequestUndo(game, p1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RespondUndo(game, p1, true); err == nil {
+		t.Error("the requester was allowed to accept their own undo")
+	}
+	resolved, err := RespondUndo(game, p2, true)
+	if err != nil || !resolved {
+		t.Fatalf("RespondUndo = %v, %v; want the undo applied", resolved, err)
+	}
+	if game.LastPlayedHand != before.LastPlayedHand || game.CurrentTurnPlayerIndex != 0 || game.UndoRequest != nil || game.lastAction != nil {
+		t.Errorf("after undo: last %v, turn %d, request %v, snapshot %v", game.LastPlayedHand, game.CurrentTurnPlayerIndex, game.UndoRequest, game.lastAction)
+	}
+	if _, err := RequestUndo(game, p1); err == nil {
+		t.Error("the same action was taken back twice")
+	}
+}
+
+// undoTestHarness seats three scripted clients; player1 holds the 3 of Diamonds.
+func undoTestHarness(t *testing.T) (*wsHarness, []*wsTestClient) {
+	h := newWSHarness(t, 3, []Deck{
+		{{Rank3, Diamonds}, {Rank4, Clubs}, {Two, Spades}},
+		{{Rank5, Diamonds}, {Rank6, Hearts}},
+		{{Rank7, Spades}, {Rank8, Spades}},
+	})
+	cs, _ := h.connectAll(3)
+	return h, cs
+}
+
+func respondUndo(c *wsTestClient, accept bool) {
+	c.send(map[string]interface{}{"type": "respondUndo", "accept": accept})
+}
+
+func TestWebSocket_UndoWithConsent(t *testing.T) {
+	h, cs := undoTestHarness(t)
+	p1, p2, p3 := cs[0], cs[1], cs[2]
+
+	p1.play(Card{Rank3, Diamonds})
+	states(cs)
+	p1.send(map[string]interface{}{"type": "requestUndo"})
+	got := states(cs)
+	if req := got[1].UndoRequest; req == nil || req.PlayerID != "player1" {
+		t.Fatalf("undo request %+v, want one from player1", req)
+	}
+
+	respondUndo(p2, true)
+	if got = states(cs); got[0].UndoRequest == nil || !reflect.DeepEqual(got[0].UndoRequest.Accepted, []string{"player2"}) {
+		t.Fatalf("after one acceptance: %+v, want it pending with player2 accepted", got[0].UndoRequest)
+	}
+	respondUndo(p3, true)
+	got = states(cs)
+	h.assertConsistent(cs, got)
+	if s := got[0]; s.UndoRequest != nil || s.LastPlayedHand != nil || s.CurrentPlayerID != "player1" || len(s.Hand) != 3 || s.OpeningCard == nil {
+		t.Fatalf("after undo: %+v, want the opening play taken back", s)
+	}
+
+	// Refused: the table stays as it is.
+	p1.play(Card{Rank3, Diamonds})
+	states(cs)
+	p2.pass()
+	states(cs)
+	p1.send(map[string]interface{}{"type": "requestUndo"})
+	p1.expectError("your own last action")
+	p2.send(map[string]interface{}{"type": "requestUndo"})
+	states(cs)
+	respondUndo(p3, false)
+	got = states(cs)
+	if s := got[0]; s.UndoRequest != nil || s.CurrentPlayerID != "player3" || s.PassCount != 1 {
+		t.Fatalf("after refusal: %+v, want player3 to act after player2's pass", s)
+	}
+
+	p2.send(map[string]interface{}{"type": "requestUndo"})
+	p2.expectError("your own last action") // One request per action
+
+	// Lapsed: the next player acting ends the chance to take back.
+	p3.play(Card{Rank7, Spades})
+	states(cs)
+	p3.send(map[string]interface{}{"type": "requestUndo"})
+	states(cs)
+	p1.play(Card{Two, Spades})
+	if got = states(cs); got[0].UndoRequest != nil {
+		t.Fatalf("undo request %+v still pending after player1 acted", got[0].UndoRequest)
+	}
+	respondUndo(p2, true)
+	p2.expectError("no undo request")
+}
+
+func TestWebSocket_UndoRequestExpires(t *testing.T) {
+	defer func(d time.Duration) { undoRequestTimeout = d }(undoRequestTimeout)
+	undoRequestTimeout = 50 * time.Millisecond
+
+	_, cs := undoTestHarness(t)
+	cs[0].play(Card{Rank3, Diamonds})
+	states(cs)
+	cs[0].send(map[string]interface{}{"type": "requestUndo"})
+	states(cs)
+
+	for _, c := range cs {
+		var msg struct{ Content string }
+		for !strings.Contains(msg.Content, "expired") {
+			json.Unmarshal(c.next("chat"), &msg)
+		}
+	}
+	if got := states(cs); got[0].UndoRequest != nil || got[0].CurrentPlayerID != "player2" {
+		t.Fatalf("after expiry: %+v, want no request and the play to stand", got[0])
+	}
+}
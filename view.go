@@ -0,0 +1,176 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// ViewerRole is the kind of recipient a GameView is built for.
+type ViewerRole int
+
+const (
+	ViewerSeat      ViewerRole = iota // A seated player: sees their own hand only
+	ViewerSpectator                   // Watches the table: sees no hands
+	ViewerAdmin                       // Server operator: sees every hand
+)
+
+// String returns the role name used in the gameState payload.
+func (r ViewerRole) String() string {
+	switch r {
+	case ViewerSeat:
+		return "seat"
+	case ViewerSpectator:
+		return "spectator"
+	case ViewerAdmin:
+		return "admin"
+	default:
+		return "unknown"
+	}
+}
+
+// Viewer identifies who a GameView is for. PlayerID is the viewer's seat for ViewerSeat.
+type Viewer struct {
+	Role     ViewerRole
+	PlayerID string
+}
+
+// PlayerView is the public information about a seat. It never includes cards.
+type PlayerView struct {
+	ID           string  `json:"id"`
+	Name         string  `json:"name"`
+	CardCount    int     `json:"cardCount"`
+	HasPassed    bool    `json:"hasPassed"`
+	IsEliminated bool    `json:"isEliminated"`
+	AccountID    string  `json:"accountId"`
+	Rating       float64 `json:"rating"`
+}
+
+// GameView is one viewer's projection of a GameState, and the only form in which game
+// state is sent over the wire. GameState and Player are never marshalled for clients:
+// the hidden information (every hand) lives there, and the projection decides what of
+// it the viewer may see. Only Hand and Hands carry private cards.
+type GameView struct {
+	Type              string          `json:"type"`
+	Role              string          `json:"role"`
+	Hand              Deck            `json:"hand"`                   // The viewer's own hand; empty unless seated
+	Hands             map[string]Deck `json:"hands,omitempty"`        // Every hand, admin views only
+	QueuedAction      *QueuedAction   `json:"queuedAction,omitempty"` // The viewer's own queued action
+	LastPlayedHand    *PlayedHand     `json:"lastPlayedHand"`
+	CurrentPlayerID   string          `json:"currentPlayerId"`
+	CurrentPlayerName string          `json:"currentPlayerName"`
+	YourPlayerID      string          `json:"yourPlayerId"`
+	PassCount         int             `json:"passCount"`
+	PlayersInfo       []PlayerView    `json:"playersInfo"`
+	GameMessage       string          `json:"gameMessage,omitempty"`
+	IsGameOver        bool            `json:"isGameOver"`
+	WinnerID          string          `json:"winnerId,omitempty"`
+	Scores            map[string]int  `json:"scores,omitempty"`
+
+	// Multi-round/match state
+	RoundNumber        int              `json:"roundNumber"`
+	TargetScore        int              `json:"targetScore"`
+	IsMatchOver        bool             `json:"isMatchOver"`
+	OverallWinnerID    string           `json:"overallWinnerId,omitempty"`
+	RoundScoresHistory []map[string]int `json:"roundScoresHistory,omitempty"`
+	OpeningCard        *Card            `json:"openingCard,omitempty"`
+	TableID            string           `json:"tableId"`
+	TournamentID       string           `json:"tournamentId,omitempty"`
+	Teams              []Team           `json:"teams,omitempty"`
+	TeamScores         map[string]int   `json:"teamScores,omitempty"`
+	FinishOrder        []string         `json:"finishOrder,omitempty"`
+	InstantWin         *InstantWin      `json:"instantWin,omitempty"` // The winning hand is shown to everyone
+	Exchange           *CardExchange    `json:"exchange,omitempty"`
+	RoundExchanges     []*CardExchange  `json:"roundExchanges,omitempty"` // By round, like RoundScoresHistory
+	UndoRequest        *UndoRequest     `json:"undoRequest,omitempty"`
+	Paused             bool             `json:"paused"`
+	PausedBy           string           `json:"pausedBy,omitempty"`
+	Vote               *MatchVote       `json:"vote,omitempty"`
+	MatchAbandoned     bool             `json:"matchAbandoned"`
+	WinningTeamID      string           `json:"overallWinningTeamId,omitempty"`
+	Ruleset            string           `json:"ruleset"`
+	ScoringScheme      string           `json:"scoringScheme"`
+	RoundWins          map[string]int   `json:"roundWins,omitempty"`
+	MatchEndMode       string           `json:"matchEndMode"`
+	MaxRounds          int              `json:"maxRounds,omitempty"`
+	RoundWinsToWin     int              `json:"roundWinsToWin,omitempty"`
+	MatchEndsAt        *time.Time       `json:"matchEndsAt,omitempty"`
+}
+
+// NewGameView projects the game for the given viewer. Assumes gameInstanceMutex is held.
+// A seat viewer whose player is not at the table gets an empty hand.
+func NewGameView(game *GameState, viewer Viewer) GameView {
+	view := GameView{
+		Type:              "gameState",
+		Role:              viewer.Role.String(),
+		Hand:              Deck{},
+		LastPlayedHand:    game.LastPlayedHand,
+		CurrentPlayerName: "N/A",
+		YourPlayerID:      "Observer",
+		PassCount:         game.PassCount,
+		PlayersInfo:       make([]PlayerView, len(game.Players)),
+		IsGameOver:        game.IsGameOver,
+		WinnerID:          game.WinnerID,
+		Scores:            game.Scores,
+
+		RoundNumber:        game.RoundNumber,
+		TargetScore:        game.TargetScore,
+		IsMatchOver:        game.IsMatchOver,
+		OverallWinnerID:    game.OverallWinnerID,
+		RoundScoresHistory: game.RoundScoresHistory,
+		OpeningCard:        game.OpeningCard,
+		TableID:            game.ID,
+		TournamentID:       game.TournamentID,
+		Teams:              game.Teams,
+		TeamScores:         game.TeamScores(),
+		FinishOrder:        game.FinishOrder,
+		InstantWin:         game.InstantWin,
+		Exchange:           game.Exchange,
+		UndoRequest:        game.UndoRequest,
+		Paused:             game.Paused,
+		PausedBy:           game.PausedBy,
+		Vote:               game.Vote,
+		MatchAbandoned:     game.MatchAbandoned,
+		WinningTeamID:      game.OverallWinningTeamID,
+		Ruleset:            game.RuleEngine.Options.Name,
+		ScoringScheme:      scoringSchemeName(game),
+		RoundWins:          game.RoundWins,
+		MatchEndMode:       game.MatchRules.EndMode.String(),
+		MaxRounds:          game.MatchRules.MaxRounds,
+		RoundWinsToWin:     game.MatchRules.RoundWinsToWin,
+		MatchEndsAt:        matchEndsAt(game),
+	}
+	if game.CurrentTurnPlayerIndex >= 0 && game.CurrentTurnPlayerIndex < len(game.Players) {
+		view.CurrentPlayerID = game.Players[game.CurrentTurnPlayerIndex].ID
+		view.CurrentPlayerName = game.Players[game.CurrentTurnPlayerIndex].Name
+	}
+	if game.RuleEngine.Options.CardExchangeCount > 0 {
+		view.RoundExchanges = game.RoundExchanges
+	}
+
+	for i, p := range game.Players {
+		view.PlayersInfo[i] = PlayerView{
+			ID:           p.ID,
+			Name:         p.Name,
+			CardCount:    len(p.Hand),
+			HasPassed:    p.HasPassed,
+			IsEliminated: p.IsEliminated,
+			AccountID:    p.AccountID,
+			Rating:       math.Round(p.Rating),
+		}
+	}
+
+	switch viewer.Role {
+	case ViewerSeat:
+		view.YourPlayerID = viewer.PlayerID
+		if p := game.playerByID(viewer.PlayerID); p != nil {
+			view.Hand = append(Deck{}, p.Hand...)
+			view.QueuedAction = game.QueuedActions[p.ID]
+		}
+	case ViewerAdmin:
+		view.Hands = make(map[string]Deck, len(game.Players))
+		for _, p := range game.Players {
+			view.Hands[p.ID] = append(Deck{}, p.Hand...)
+		}
+	}
+	return view
+}
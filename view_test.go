@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// viewTestGames returns tables in the situations a gameState is broadcast in.
+func viewTestGames(t *testing.T) map[string]*GameState {
+	t.Helper()
+	newTable := func(n int) *GameState {
+		players := make([]*Player, n)
+		for i := range players {
+			players[i] = NewPlayer(i+1, "P")
+		}
+		return NewGameState("view", players, 100)
+	}
+
+	midTrick := newTable(3)
+	opener := midTrick.Players[midTrick.CurrentTurnPlayerIndex]
+	played, err := midTrick.RuleEngine.DeterminePlayedHand(Deck{*midTrick.OpeningCard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	played.PlayerID = opener.ID
+	opener.RemoveCards(played.Cards)
+	midTrick.LastPlayedHand = played
+	midTrick.OpeningCard = nil
+	midTrick.CurrentTurnPlayerIndex = midTrick.nextPlayerInRound(midTrick.CurrentTurnPlayerIndex)
+
+	exchange := exchangeTestGame()
+	if err := ApplyExchangeCards(exchange, exchange.Players[2], Deck{C(Two, Spades), C(Two, Diamonds)}); err != nil {
+		t.Fatal(err)
+	}
+
+	teams := newTable(4)
+	if err := SetupPartnerships(teams, true); err != nil {
+		t.Fatal(err)
+	}
+
+	roundOver := newTable(4)
+	winner := roundOver.Players[0]
+	winner.Hand = Deck{}
+	finishRound(roundOver, winner, time.Now())
+
+	return map[string]*GameState{
+		"fresh deal":  newTable(4),
+		"mid trick":   midTrick,
+		"exchange":    exchange,
+		"team mode":   teams,
+		"round over":  roundOver,
+		"two players": newTable(2),
+	}
+}
+
+// cardsInJSON returns every card object anywhere in the JSON document.
+func cardsInJSON(t *testing.T, data []byte) []Card {
+	t.Helper()
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	var cards []Card
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			rank, hasRank := v["rank"].(float64)
+			suit, hasSuit := v["suit"].(float64)
+			if hasRank && hasSuit {
+				cards = append(cards, Card{Rank: Rank(rank), Suit: Suit(suit)})
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+	return cards
+}
+
+// assertNoHandCards fails if data contains a card held by any of the given players.
+// The round's opening card is public: whoever holds it must lead with it.
+func assertNoHandCards(t *testing.T, what string, data []byte, game *GameState, players ...*Player) {
+	t.Helper()
+	for _, c := range cardsInJSON(t, data) {
+		if game.OpeningCard != nil && c == *game.OpeningCard {
+			continue
+		}
+		for _, p := range players {
+			if containsCard(p.Hand, c) {
+				t.Errorf("%s exposes %s from %s's hand", what, c, p.ID)
+			}
+		}
+	}
+}
+
+func TestGameView_SeatSeesOnlyOwnHand(t *testing.T) {
+	for name, game := range viewTestGames(t) {
+		for _, seat := range game.Players {
+			view := NewGameView(game, Viewer{Role: ViewerSeat, PlayerID: seat.ID})
+			data, err := json.Marshal(view)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var opponents []*Player
+			for _, p := range game.Players {
+				if p != seat {
+					opponents = append(opponents, p)
+				}
+			}
+			assertNoHandCards(t, name+" view for "+seat.ID, data, game, opponents...)
+			if view.YourPlayerID != seat.ID || len(view.Hand) != len(seat.Hand) || view.Hands != nil {
+				t.Errorf("%s: view for %s has player %q, %d of %d cards, hands %v", name, seat.ID, view.YourPlayerID, len(view.Hand), len(seat.Hand), view.Hands)
+			}
+		}
+	}
+}
+
+func TestGameView_Roles(t *testing.T) {
+	for name, game := range viewTestGames(t) {
+		spectator, _ := json.Marshal(NewGameView(game, Viewer{Role: ViewerSpectator}))
+		assertNoHandCards(t, name+" spectator view", spectator, game, game.Players...)
+
+		// A seat ID on a spectator viewer grants nothing beyond the role.
+		spoofed, _ := json.Marshal(NewGameView(game, Viewer{Role: ViewerSpectator, PlayerID: game.Players[0].ID}))
+		assertNoHandCards(t, name+" spectator view with a seat ID", spoofed, game, game.Players...)
+
+		admin := NewGameView(game, Viewer{Role: ViewerAdmin})
+		for _, p := range game.Players {
+			if len(admin.Hands[p.ID]) != len(p.Hand) {
+				t.Errorf("%s: admin sees %d of %s's %d cards", name, len(admin.Hands[p.ID]), p.ID, len(p.Hand))
+			}
+		}
+		if len(admin.Hand) != 0 {
+			t.Errorf("%s: admin view has a seat hand %v", name, admin.Hand)
+		}
+	}
+}
+
+// Game state must never reach the wire except through a GameView, but if it is
+// marshalled directly by mistake the hands still stay hidden.
+func TestGameState_MarshalHidesHands(t *testing.T) {
+	for name, game := range viewTestGames(t) {
+		data, err := json.Marshal(game)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertNoHandCards(t, name+" GameState", data, game, game.Players...)
+		for _, p := range game.Players {
+			data, _ := json.Marshal(p)
+			assertNoHandCards(t, name+" Player", data, game, p)
+		}
+	}
+}
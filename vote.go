@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// voteTimeout is how long a vote on the match stays open.
+var voteTimeout = 60 * time.Second
+
+// VoteKind is what a match vote decides.
+type VoteKind string
+
+const (
+	// VoteRestart starts a new match from scratch, discarding the scores so far.
+	VoteRestart VoteKind = "restart"
+	// VoteAbandon ends the match without a result. Nothing is recorded for it.
+	VoteAbandon VoteKind = "abandon"
+)
+
+// MatchVote is an open vote to restart or abandon the match. Ballots are public, so the
+// tally is visible to everyone at the table.
+type MatchVote struct {
+	Kind      VoteKind        `json:"kind"`
+	CalledBy  string          `json:"calledBy"`
+	Ballots   map[string]bool `json:"ballots"` // Player ID to yes or no, for those who have voted
+	Yes       int             `json:"yes"`
+	No        int             `json:"no"`
+	Needed    int             `json:"needed"` // Yes votes that carry it: a majority of the seats
+	ExpiresAt time.Time       `json:"expiresAt"`
+
+	timer *time.Timer
+}
+
+// CallVote opens a vote by player, who votes yes. It may be decided at once if a single
+// vote is a majority; see CastVote for the results. Assumes gameInstanceMutex is held.
+func CallVote(game *GameState, player *Player, kind VoteKind) (vote *MatchVote, resolved, passed bool, err error) {
+	switch {
+	case kind != VoteRestart && kind != VoteAbandon:
+		return nil, false, false, fmt.Errorf("unknown vote %q", kind)
+	case game.TournamentID != "":
+		return nil, false, false, fmt.Errorf("tournament matches are ended by the organizer")
+	case game.IsMatchOver:
+		return nil, false, false, fmt.Errorf("the match is already over")
+	case game.Vote != nil:
+		return nil, false, false, fmt.Errorf("a vote is already open")
+	}
+	game.Vote = &MatchVote{
+		Kind:      kind,
+		CalledBy:  player.ID,
+		Ballots:   make(map[string]bool),
+		Needed:    len(game.Players)/2 + 1,
+		ExpiresAt: time.Now().Add(voteTimeout),
+	}
+	vote = game.Vote
+	resolved, passed, err = CastVote(game, player, true)
+	return vote, resolved, passed, err
+}
+
+// CastVote records player's ballot in the open vote. The vote passes, and takes effect, as
+// soon as a majority of the seats has voted yes, and fails once that can no longer happen.
+// Reports whether the vote was decided, and if so whether it passed. Assumes gameInstanceMutex is held.
+func CastVote(game *GameState, player *Player, yes bool) (resolved, passed bool, err error) {
+	vote := game.Vote
+	switch {
+	case vote == nil:
+		return false, false, fmt.Errorf("there is no vote open")
+	case game.playerByID(player.ID) == nil:
+		return false, false, fmt.Errorf("only players at the table can vote")
+	}
+	if _, voted := vote.Ballots[player.ID]; voted {
+		return false, false, fmt.Errorf("you have already voted")
+	}
+	vote.Ballots[player.ID] = yes
+	if yes {
+		vote.Yes++
+	} else {
+		vote.No++
+	}
+
+	switch {
+	case vote.Yes >= vote.Needed:
+		cancelVote(game)
+		applyVote(game, vote.Kind)
+		return true, true, nil
+	case vote.No > len(game.Players)-vote.Needed:
+		cancelVote(game)
+		return true, false, nil
+	}
+	return false, false, nil
+}
+
+// cancelVote closes the open vote, if any, and stops its timer.
+func cancelVote(game *GameState) {
+	if game.Vote != nil && game.Vote.timer != nil {
+		game.Vote.timer.Stop()
+	}
+	game.Vote = nil
+}
+
+// applyVote carries out a vote that passed.
+func applyVote(game *GameState, kind VoteKind) {
+	gameLog(game).Info("vote passed", "kind", kind)
+	switch kind {
+	case VoteRestart:
+		resetMatchState(game)
+	case VoteAbandon:
+		abandonMatch(game)
+	}
+}
+
+// abandonMatch ends the match where it stands, without a winner or any recorded results.
+// A new match can then be started as after any finished match.
+func abandonMatch(game *GameState) {
+	game.IsGameOver = true
+	game.IsMatchOver = true
+	game.MatchAbandoned = true
+	game.WinnerID = ""
+	game.OverallWinnerID = ""
+	game.OverallWinningTeamID = ""
+	game.Exchange = nil
+	game.lastAction = nil
+	cancelUndoRequest(game)
+	game.QueuedActions = nil
+}
+
+// expireVote closes vote as failed when its time runs out, unless it was decided first.
+func expireVote(game *GameState, vote *MatchVote) {
+	vote.timer = time.AfterFunc(time.Until(vote.ExpiresAt), func() {
+		gameInstanceMutex.Lock()
+		defer gameInstanceMutex.Unlock()
+		if game.Vote != vote {
+			return
+		}
+		game.Vote = nil
+		broadcastSystemMessage(game, fmt.Sprintf("The vote to %s the match ran out of time (%d of %d yes votes needed).", vote.Kind, vote.Yes, vote.Needed))
+		broadcastGameState(game)
+	})
+}
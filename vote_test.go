@@ -0,0 +1,132 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCastVote_Majority(t *testing.T) {
+	type ballot struct {
+		seat int
+		yes  bool
+	}
+	tests := []struct {
+		name         string
+		seats        int
+		kind         VoteKind
+		ballots      []ballot // After the caller's yes from seat 0
+		wantResolved bool
+		wantPassed   bool
+	}{
+		{"two of three restart", 3, VoteRestart, []ballot{{1, true}}, true, true},
+		{"one of three is open", 3, VoteRestart, nil, false, false},
+		{"two of three refuse", 3, VoteAbandon, []ballot{{1, false}, {2, false}}, true, false},
+		{"split three is open", 3, VoteAbandon, []ballot{{1, false}}, false, false},
+		{"two of four is not a majority", 4, VoteAbandon, []ballot{{1, true}}, false, false},
+		{"three of four abandon", 4, VoteAbandon, []ballot{{1, true}, {2, false}, {3, true}}, true, true},
+		{"two against in four fails", 4, VoteRestart, []ballot{{1, false}, {2, false}}, true, false},
+		{"both of two", 2, VoteAbandon, []ballot{{1, true}}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			players := make([]*Player, tt.seats)
+			for i := range players {
+				players[i] = NewPlayer(i+1, "P")
+			}
+			game := NewGameState("vote", players, 100)
+			game.Scores[players[0].ID] = 40
+			game.RoundNumber = 3
+
+			_, resolved, passed, err := CallVote(game, players[0], tt.kind)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, b := range tt.ballots {
+				if resolved {
+					t.Fatalf("vote decided before seat %d voted", b.seat)
+				}
+				if resolved, passed, err = CastVote(game, players[b.seat], b.yes); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if resolved != tt.wantResolved || passed != tt.wantPassed {
+				t.Fatalf("resolved %v, passed %v; want %v, %v", resolved, passed, tt.wantResolved, tt.wantPassed)
+			}
+			if resolved != (game.Vote == nil) {
+				t.Errorf("vote %+v still open after being decided %v", game.Vote, resolved)
+			}
+
+			restarted := game.RoundNumber == 1 && game.Scores[players[0].ID] == 0 && !game.IsMatchOver
+			abandoned := game.IsMatchOver && game.MatchAbandoned && game.OverallWinnerID == ""
+			if want := passed && tt.kind == VoteRestart; restarted != want {
+				t.Errorf("match restarted %v, want %v", restarted, want)
+			}
+			if want := passed && tt.kind == VoteAbandon; abandoned != want {
+				t.Errorf("match abandoned %v, want %v", abandoned, want)
+			}
+		})
+	}
+}
+
+func TestCallVote_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(*GameState)
+		kind    VoteKind
+		wantErr string
+	}{
+		{"unknown kind", func(*GameState) {}, "surrender", "unknown vote"},
+		{"tournament table", func(g *GameState) { g.TournamentID = "t1" }, VoteAbandon, "organizer"},
+		{"match over", func(g *GameState) { g.IsMatchOver = true }, VoteRestart, "already over"},
+		{"vote open", func(g *GameState) { g.Vote = &MatchVote{Kind: VoteRestart} }, VoteAbandon, "already open"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := NewGameState("vote", []*Player{NewPlayer(1, "P1"), NewPlayer(2, "P2"), NewPlayer(3, "P3")}, 100)
+			tt.setup(game)
+			_, _, _, err := CallVote(game, game.Players[0], tt.kind)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("CallVote error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	game := NewGameState("vote", []*Player{NewPlayer(1, "P1"), NewPlayer(2, "P2"), NewPlayer(3, "P3")}, 100)
+	CallVote(game, game.Players[0], VoteRestart)
+	if _, _, err := CastVote(game, game.Players[0], false); err == nil || !strings.Contains(err.Error(), "already voted") {
+		t.Errorf("second ballot error %v, want already voted", err)
+	}
+}
+
+func TestWebSocket_NewGameMidRoundNeedsAVote(t *testing.T) {
+	h := newWSHarness(t, 3, []Deck{
+		{{Rank3, Diamonds}, {Rank4, Clubs}},
+		{{Rank5, Diamonds}, {Rank6, Hearts}},
+		{{Rank7, Spades}, {Rank8, Spades}},
+	})
+	cs, _ := h.connectAll(3)
+	cs[0].play(Card{Rank3, Diamonds})
+	states(cs)
+
+	cs[1].send(map[string]interface{}{"type": "newGame"})
+	cs[1].expectError("Call a vote")
+
+	cs[1].send(map[string]interface{}{"type": "callVote", "kind": "restart"})
+	got := states(cs)
+	if v := got[2].Vote; v == nil || v.Kind != VoteRestart || v.Yes != 1 || v.Needed != 2 || !v.Ballots["player2"] {
+		t.Fatalf("tally seen by player3: %+v, want player2's yes of 2 needed", v)
+	}
+	cs[0].send(map[string]interface{}{"type": "castVote", "yes": false})
+	if got = states(cs); got[2].Vote == nil || got[2].Vote.No != 1 {
+		t.Fatalf("tally after player1's no: %+v", got[2].Vote)
+	}
+	cs[0].send(map[string]interface{}{"type": "castVote", "yes": true})
+	cs[0].expectError("already voted")
+
+	cs[2].send(map[string]interface{}{"type": "castVote", "yes": true})
+	got = states(cs)
+	h.assertConsistent(cs, got)
+	if s := got[0]; s.Vote != nil || s.RoundNumber != 1 || s.LastPlayedHand != nil {
+		t.Errorf("after the vote passed: %+v, want a fresh match", s)
+	}
+}
@@ -0,0 +1,507 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// These tests drive the real websocket handler end to end. Run them with -race:
+// broadcasts, chat and action replies write to the same connections from different goroutines.
+
+const wsTestTimeout = 5 * time.Second
+
+// wsHarness serves handleWebSocket on an httptest listener for a table created by the test.
+type wsHarness struct {
+	t      *testing.T
+	server *httptest.Server
+	game   *GameState
+}
+
+// wsTestClient is a scripted player connected to the harness.
+type wsTestClient struct {
+	t        *testing.T
+	conn     *websocket.Conn
+	playerID string
+	frames   chan []byte
+	readErr  error // Why the connection stopped, once frames is closed
+}
+
+// wsGameState is the part of the gameState payload the tests assert on.
+type wsGameState struct {
+	Hand           Deck `json:"hand"`
+	LastPlayedHand *struct {
+		Cards    Deck   `json:"cards"`
+		PlayerID string `json:"playerId"`
+	} `json:"lastPlayedHand"`
+	CurrentPlayerID string `json:"currentPlayerId"`
+	YourPlayerID    string `json:"yourPlayerId"`
+	PassCount       int    `json:"passCount"`
+	PlayersInfo     []struct {
+		ID        string `json:"id"`
+		CardCount int    `json:"cardCount"`
+		HasPassed bool   `json:"hasPassed"`
+	} `json:"playersInfo"`
+	IsGameOver   bool           `json:"isGameOver"`
+	WinnerID     string         `json:"winnerId"`
+	Scores       map[string]int `json:"scores"`
+	RoundNumber  int            `json:"roundNumber"`
+	OpeningCard  *Card          `json:"openingCard"`
+	UndoRequest  *UndoRequest   `json:"undoRequest"`
+	QueuedAction *QueuedAction  `json:"queuedAction"`
+	Role         string         `json:"role"`
+	Paused       bool           `json:"paused"`
+	Vote         *MatchVote     `json:"vote"`
+}
+
+// newWSHarness registers a table with the given seats and serves the websocket endpoint.
+// If hands is non-nil, the deal is replaced with it before anyone connects.
+func newWSHarness(t *testing.T, seats int, hands []Deck) *wsHarness {
+	t.Helper()
+	log.SetOutput(io.Discard) // The handlers log every message at DEBUG level
+	t.Cleanup(func() { log.SetOutput(defaultLogOutput) })
+
+	players := make([]*Player, seats)
+	for i := range players {
+		players[i] = NewPlayer(i+1, fmt.Sprintf("P%d", i+1))
+	}
+	tableID := fmt.Sprintf("test-%d", wsTableSeq.Add(1))
+
+	gameInstanceMutex.Lock()
+	game := NewGameState(tableID, players, 100)
+	if hands != nil {
+		for i, p := range game.Players {
+			p.Hand = hands[i]
+		}
+		setOpeningPlayer(game)
+	}
+	if err := registerTable(game); err != nil {
+		gameInstanceMutex.Unlock()
+		t.Fatalf("registerTable: %v", err)
+	}
+	gameInstanceMutex.Unlock()
+
+	h := &wsHarness{t: t, server: httptest.NewServer(http.HandlerFunc(handleWebSocket)), game: game}
+	t.Cleanup(func() {
+		h.server.Close()
+		h.waitForDisconnects()
+		gameInstanceMutex.Lock()
+		delete(tables, tableID)
+		gameInstanceMutex.Unlock()
+	})
+	return h
+}
+
+// wsTableSeq numbers harness tables so tests with several tables get distinct IDs.
+var wsTableSeq atomic.Int64
+
+// defaultLogOutput is restored after each harness silences the server log.
+var defaultLogOutput = log.Writer()
+
+// waitForDisconnects blocks until every handler for the harness table has cleaned up its client.
+func (h *wsHarness) waitForDisconnects() {
+	deadline := time.Now().Add(wsTestTimeout)
+	for time.Now().Before(deadline) {
+		clientsMu.Lock()
+		remaining := 0
+		for _, c := range clients {
+			if c.game == h.game {
+				remaining++
+			}
+		}
+		clientsMu.Unlock()
+		if remaining == 0 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	h.t.Errorf("clients of table %s still registered after the server closed", h.game.ID)
+}
+
+// connect dials the table and waits for the client's first game state.
+func (h *wsHarness) connect() (*wsTestClient, wsGameState) {
+	h.t.Helper()
+	c := h.dial("")
+	state := c.nextState()
+	c.playerID = state.YourPlayerID
+	return c, state
+}
+
+// dial opens a connection to the table with the extra query parameters, such as a session
+// token, without waiting for anything.
+func (h *wsHarness) dial(query string) *wsTestClient {
+	h.t.Helper()
+	return h.dialWith(websocket.DefaultDialer, query)
+}
+
+// dialFrom is dial from another loopback address, such as 127.0.0.2, as if from another host.
+func (h *wsHarness) dialFrom(ip, query string) *wsTestClient {
+	h.t.Helper()
+	netDialer := &net.Dialer{LocalAddr: &net.TCPAddr{IP: net.ParseIP(ip)}}
+	return h.dialWith(&websocket.Dialer{NetDialContext: netDialer.DialContext, HandshakeTimeout: wsTestTimeout}, query)
+}
+
+func (h *wsHarness) dialWith(dialer *websocket.Dialer, query string) *wsTestClient {
+	h.t.Helper()
+	url := "ws" + strings.TrimPrefix(h.server.URL, "http") + "/ws?table=" + h.game.ID + query
+	conn, _, err := dialer.Dial(url, nil)
+	if err != nil {
+		h.t.Fatalf("dial %s: %v", url, err)
+	}
+	c := &wsTestClient{t: h.t, conn: conn, frames: make(chan []byte, 256)}
+	go func() {
+		defer close(c.frames)
+		for {
+			_, frame, err := conn.ReadMessage()
+			if err != nil {
+				c.readErr = err
+				return
+			}
+			c.frames <- frame
+		}
+	}()
+	h.t.Cleanup(func() { conn.Close() })
+	return c
+}
+
+// connectAll seats n clients and drains the state broadcast each later connection triggers,
+// so every client's next frame reflects the next action. It returns each client's latest state.
+func (h *wsHarness) connectAll(n int) ([]*wsTestClient, []wsGameState) {
+	h.t.Helper()
+	cs := make([]*wsTestClient, n)
+	got := make([]wsGameState, n)
+	for i := range cs {
+		cs[i], got[i] = h.connect()
+	}
+	for i, c := range cs {
+		for j := i + 1; j < n; j++ {
+			got[i] = c.nextState()
+		}
+	}
+	return cs, got
+}
+
+// send writes a JSON message to the server.
+func (c *wsTestClient) send(msg map[string]interface{}) {
+	c.t.Helper()
+	if err := c.conn.WriteJSON(msg); err != nil {
+		c.t.Fatalf("%s: write %v: %v", c.playerID, msg["type"], err)
+	}
+}
+
+func (c *wsTestClient) play(cards ...Card) {
+	c.t.Helper()
+	c.send(map[string]interface{}{"type": "playCards", "cards": cards})
+}
+
+func (c *wsTestClient) pass() {
+	c.t.Helper()
+	c.send(map[string]interface{}{"type": "passTurn"})
+}
+
+// next returns the next frame of the given type, skipping others. Every frame read,
+// skipped or not, is checked for leaked cards.
+func (c *wsTestClient) next(msgType string) []byte {
+	c.t.Helper()
+	timeout := time.After(wsTestTimeout)
+	for {
+		select {
+		case frame, ok := <-c.frames:
+			if !ok {
+				c.t.Fatalf("%s: connection closed while waiting for %q", c.playerID, msgType)
+			}
+			var msg map[string]interface{}
+			if err := json.Unmarshal(frame, &msg); err != nil {
+				c.t.Fatalf("%s: malformed frame %s: %v", c.playerID, frame, err)
+			}
+			if path, found := findCard(msg, "", privateStateFields); found {
+				c.t.Fatalf("%s: %s frame exposes a card at %s: %s", c.playerID, msg["type"], path, frame)
+			}
+			if msg["type"] == msgType {
+				return frame
+			}
+		case <-timeout:
+			c.t.Fatalf("%s: timed out waiting for %q", c.playerID, msgType)
+		}
+	}
+}
+
+// nextState returns the next gameState sent to the client.
+func (c *wsTestClient) nextState() wsGameState {
+	c.t.Helper()
+	var state wsGameState
+	if err := json.Unmarshal(c.next("gameState"), &state); err != nil {
+		c.t.Fatalf("%s: decoding gameState: %v", c.playerID, err)
+	}
+	if c.playerID != "" && state.YourPlayerID != c.playerID {
+		c.t.Fatalf("gameState for %s addressed to %s", c.playerID, state.YourPlayerID)
+	}
+	if state.Role != "seat" {
+		c.t.Fatalf("%s: gameState built for role %q, want seat", c.playerID, state.Role)
+	}
+	return state
+}
+
+// expectError waits for an error frame containing want.
+func (c *wsTestClient) expectError(want string) {
+	c.t.Helper()
+	var msg struct{ Content string }
+	json.Unmarshal(c.next("error"), &msg)
+	if !strings.Contains(msg.Content, want) {
+		c.t.Fatalf("%s: error %q, want it to mention %q", c.playerID, msg.Content, want)
+	}
+}
+
+// privateStateFields are the top-level GameView keys allowed to carry cards in a seat's frames:
+// the recipient's own hand and queued action, and the cards already face up on the table.
+var privateStateFields = map[string]bool{"hand": true, "queuedAction": true, "lastPlayedHand": true, "openingCard": true}
+
+// findCard reports the path of the first card object in v outside the allowed top-level fields.
+func findCard(v interface{}, path string, allowed map[string]bool) (string, bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		_, hasRank := v["rank"]
+		_, hasSuit := v["suit"]
+		if hasRank && hasSuit {
+			return path, true
+		}
+		for k, child := range v {
+			if path == "" && allowed[k] {
+				continue
+			}
+			if p, found := findCard(child, path+"."+k, allowed); found {
+				return p, true
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			if p, found := findCard(child, fmt.Sprintf("%s[%d]", path, i), allowed); found {
+				return p, true
+			}
+		}
+	}
+	return "", false
+}
+
+// states reads the next game state of every client, in client order.
+func states(cs []*wsTestClient) []wsGameState {
+	out := make([]wsGameState, len(cs))
+	for i, c := range cs {
+		out[i] = c.nextState()
+	}
+	return out
+}
+
+// assertConsistent checks that each client sees exactly its own hand and only card
+// counts for everyone else, and that all clients agree on the public state.
+func (h *wsHarness) assertConsistent(cs []*wsTestClient, got []wsGameState) {
+	h.t.Helper()
+	gameInstanceMutex.Lock()
+	defer gameInstanceMutex.Unlock()
+	for i, c := range cs {
+		p := h.game.playerByID(c.playerID)
+		if p == nil {
+			h.t.Fatalf("client %d seated as unknown player %q", i, c.playerID)
+		}
+		if !reflect.DeepEqual(got[i].Hand, p.Hand) && !(len(got[i].Hand) == 0 && len(p.Hand) == 0) {
+			h.t.Errorf("%s sees hand %v, want %v", c.playerID, got[i].Hand, p.Hand)
+		}
+		for _, info := range got[i].PlayersInfo {
+			if want := len(h.game.playerByID(info.ID).Hand); info.CardCount != want {
+				h.t.Errorf("%s sees %d cards for %s, want %d", c.playerID, info.CardCount, info.ID, want)
+			}
+		}
+		if got[i].CurrentPlayerID != got[0].CurrentPlayerID || got[i].PassCount != got[0].PassCount || got[i].IsGameOver != got[0].IsGameOver {
+			h.t.Errorf("%s and %s disagree on the public state: %+v vs %+v", c.playerID, cs[0].playerID, got[i], got[0])
+		}
+	}
+}
+
+func TestWebSocket_ScriptedRound(t *testing.T) {
+	h := newWSHarness(t, 3, []Deck{
+		{{Rank3, Diamonds}, {Rank4, Clubs}, {Two, Spades}},
+		{{Rank5, Diamonds}, {Rank6, Hearts}},
+		{{Rank7, Spades}, {Rank8, Spades}},
+	})
+	cs, _ := h.connectAll(3)
+	p1, p2, p3 := cs[0], cs[1], cs[2]
+	for i, c := range cs {
+		if want := fmt.Sprintf("player%d", i+1); c.playerID != want {
+			t.Fatalf("client %d seated as %s, want %s", i, c.playerID, want)
+		}
+	}
+
+	steps := []struct {
+		name        string
+		act         func()
+		wantCurrent string
+		wantLast    Deck // nil means a new trick
+		wantPasses  int
+	}{
+		{"opening play", func() { p1.play(Card{Rank3, Diamonds}) }, "player2", Deck{{Rank3, Diamonds}}, 0},
+		{"follow", func() { p2.play(Card{Rank5, Diamonds}) }, "player3", Deck{{Rank5, Diamonds}}, 0},
+		{"pass", func() { p3.pass() }, "player1", Deck{{Rank5, Diamonds}}, 1},
+		{"beat after a pass", func() { p1.play(Card{Two, Spades}) }, "player2", Deck{{Two, Spades}}, 0},
+		{"first pass", func() { p2.pass() }, "player3", Deck{{Two, Spades}}, 1},
+		{"trick reset", func() { p3.pass() }, "player1", nil, 0},
+	}
+	for _, step := range steps {
+		step.act()
+		got := states(cs)
+		h.assertConsistent(cs, got)
+		s := got[0]
+		if s.CurrentPlayerID != step.wantCurrent || s.PassCount != step.wantPasses {
+			t.Fatalf("%s: current %s with %d passes, want %s with %d", step.name, s.CurrentPlayerID, s.PassCount, step.wantCurrent, step.wantPasses)
+		}
+		if step.wantLast == nil {
+			if s.LastPlayedHand != nil {
+				t.Fatalf("%s: last played %v, want a new trick", step.name, s.LastPlayedHand.Cards)
+			}
+		} else if s.LastPlayedHand == nil || !reflect.DeepEqual(s.LastPlayedHand.Cards, step.wantLast) {
+			t.Fatalf("%s: last played %+v, want %v", step.name, s.LastPlayedHand, step.wantLast)
+		}
+	}
+
+	// Rejected actions are answered only to the sender and change nothing.
+	p2.play(Card{Rank6, Hearts})
+	p2.expectError("not your turn")
+	p1.pass()
+	p1.expectError("cannot pass")
+
+	p1.play(Card{Rank4, Clubs})
+	got := states(cs)
+	h.assertConsistent(cs, got)
+	if s := got[0]; !s.IsGameOver || s.WinnerID != "player1" || s.Scores["player2"] != 1 || s.Scores["player3"] != 2 {
+		t.Fatalf("round end: over=%v winner=%q scores=%v, want player1 to win with scores 1 and 2", s.IsGameOver, s.WinnerID, s.Scores)
+	}
+
+	p3.pass()
+	p3.expectError("Game is over")
+
+	p2.send(map[string]interface{}{"type": "newGame"})
+	got = states(cs)
+	h.assertConsistent(cs, got)
+	for i, s := range got {
+		if s.IsGameOver || s.RoundNumber != 2 || len(s.Hand) != 17 {
+			t.Errorf("%s after newGame: over=%v round=%d hand=%d cards, want round 2 with 17 cards", cs[i].playerID, s.IsGameOver, s.RoundNumber, len(s.Hand))
+		}
+	}
+}
+
+// TestWebSocket_FullRounds plays whole rounds with random deals. Each client decides from
+// its own gameState alone, playing its first legal move or passing, which also checks that
+// the state a client receives is enough to play.
+func TestWebSocket_FullRounds(t *testing.T) {
+	for _, seats := range []int{2, 4} {
+		t.Run(fmt.Sprintf("%d players", seats), func(t *testing.T) {
+			h := newWSHarness(t, seats, nil)
+			setRateLimits(t, rateLimits{"*": {PerSecond: 1000, Burst: 1000}}) // Plays at machine speed
+			cs, got := h.connectAll(seats)
+			h.assertConsistent(cs, got)
+			re := NewBigTwoRuleEngine()
+
+			for round := 1; round <= 2; round++ {
+				if round > 1 {
+					cs[round%seats].send(map[string]interface{}{"type": "newGame"})
+					got = states(cs)
+					h.assertConsistent(cs, got)
+				}
+
+				for turns := 0; !got[0].IsGameOver; turns++ {
+					if turns > 500 {
+						t.Fatalf("round %d did not finish", round)
+					}
+					var current *wsTestClient
+					var view wsGameState
+					for i, c := range cs {
+						if c.playerID == got[0].CurrentPlayerID {
+							current, view = c, got[i]
+						}
+					}
+					var last *PlayedHand
+					if view.LastPlayedHand != nil {
+						var err error
+						if last, err = re.DeterminePlayedHand(view.LastPlayedHand.Cards); err != nil {
+							t.Fatalf("table shows an invalid hand %v: %v", view.LastPlayedHand.Cards, err)
+						}
+					}
+					moves := re.LegalMoves(NewCardSet(view.Hand...), last)
+					if view.OpeningCard != nil {
+						moves = []CardSet{NewCardSet(*view.OpeningCard)}
+					}
+					if len(moves) == 0 {
+						current.pass()
+					} else {
+						current.play(moves[0].Deck()...)
+					}
+					got = states(cs)
+					h.assertConsistent(cs, got)
+				}
+
+				gameInstanceMutex.Lock()
+				winner := h.game.playerByID(got[0].WinnerID)
+				wentOut := winner != nil && len(winner.Hand) == 0
+				gameInstanceMutex.Unlock()
+				if !wentOut {
+					t.Fatalf("round %d ended with winner %q still holding cards", round, got[0].WinnerID)
+				}
+				if got[0].RoundNumber != round {
+					t.Fatalf("round number %d, want %d", got[0].RoundNumber, round)
+				}
+			}
+		})
+	}
+}
+
+func TestWebSocket_ChatStaysAtTable(t *testing.T) {
+	h := newWSHarness(t, 2, nil)
+	other := newWSHarness(t, 2, nil)
+	cs, _ := h.connectAll(2)
+	outsider, _ := other.connect()
+
+	cs[0].send(map[string]interface{}{"type": "chat", "content": "gg"})
+	for _, c := range cs {
+		var msg struct{ Sender, Content string }
+		for msg.Content != "gg" {
+			json.Unmarshal(c.next("chat"), &msg)
+		}
+		if !strings.Contains(msg.Sender, "player1") {
+			t.Errorf("%s: chat from %q, want player1", c.playerID, msg.Sender)
+		}
+	}
+
+	select {
+	case frame := <-outsider.frames:
+		if strings.Contains(string(frame), "gg") {
+			t.Fatalf("chat leaked to another table: %s", frame)
+		}
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWebSocket_TableFull(t *testing.T) {
+	h := newWSHarness(t, 2, nil)
+	h.connectAll(2)
+
+	url := "ws" + strings.TrimPrefix(h.server.URL, "http") + "/ws?table=" + h.game.ID
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	var msg struct{ Type, Content string }
+	if err := conn.ReadJSON(&msg); err != nil || msg.Type != "error" || !strings.Contains(msg.Content, "full") {
+		t.Fatalf("third client got %+v (%v), want a table full error", msg, err)
+	}
+}